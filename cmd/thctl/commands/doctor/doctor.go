@@ -1,123 +1,387 @@
 package doctor
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/THCloudAI/thctl/internal/config"
+	"github.com/THCloudAI/thctl/internal/lotus"
+	"github.com/THCloudAI/thctl/internal/storage"
+	"github.com/THCloudAI/thctl/pkg/framework/output"
 )
 
+// Statuses of a diagnostic check
+const (
+	statusOK   = "ok"
+	statusInfo = "info"
+	statusWarn = "warn"
+	statusFail = "fail"
+)
+
+// Sections of the diagnostic report
+const (
+	sectionConfig  = "config"
+	sectionLotus   = "lotus"
+	sectionStorage = "storage"
+)
+
+const (
+	// maxSyncedLag is the lag in epochs behind the wall clock up to which the node is in sync
+	maxSyncedLag = 2
+	// maxCatchingUpLag is the lag in epochs beyond which the node is out of sync
+	maxCatchingUpLag = 10
+	// slowRoundTripMs is the average round trip in milliseconds above which the node is slow
+	slowRoundTripMs = 500
+)
+
+// check is the outcome of a single diagnostic check
+type check struct {
+	Section string `json:"section"`
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// report holds the outcome of every diagnostic check. It is healthy when no check failed.
+type report struct {
+	Healthy    bool                            `json:"healthy"`
+	ConfigFile string                          `json:"configFile,omitempty"`
+	Checks     []check                         `json:"checks"`
+	Lotus      *lotus.NodeHealth               `json:"lotus,omitempty"`
+	Endpoints  []lotus.EndpointStatus          `json:"endpoints,omitempty"`
+	Storage    map[string]*storage.ProbeReport `json:"storage,omitempty"`
+}
+
+// add records the outcome of a check
+func (r *report) add(section, name, status, message string) {
+	r.Checks = append(r.Checks, check{Section: section, Name: name, Status: status, Message: message})
+	if status == statusFail {
+		r.Healthy = false
+	}
+}
+
+// failed reports whether the named check failed
+func (r *report) failed(name string) bool {
+	for _, c := range r.Checks {
+		if c.Name == name && c.Status == statusFail {
+			return true
+		}
+	}
+	return false
+}
+
 // NewDoctorCmd creates a new doctor command
 func NewDoctorCmd() *cobra.Command {
+	var timeout time.Duration
+
 	cmd := &cobra.Command{
 		Use:   "doctor",
 		Short: "Run diagnostic checks",
-		Long:  "Run diagnostic checks to verify configuration and environment settings",
+		Long: `Run diagnostic checks to verify configuration and environment settings, and the
+health of the configured Lotus node:
+- API version, network and round trip time
+- Chain head height against the wall clock, and the sync workers
+- Permissions of the API token (read, write, sign, admin)
+- With LOTUS_API_ENDPOINTS, the head of every endpoint against the others
+
+and of every configured storage provider (OSS, S3, COS, OBS, MinIO):
+- Clock skew against the Date header of the provider
+- Credentials, and the bucket and its region
+- List, put, get and delete permissions, using a temporary probe object that is
+  deleted again
+
+JSON and YAML output is meant for monitoring; the command exits non-zero when a check fails.
+
+Examples:
+  # Check the configuration and the Lotus node
+  thctl doctor
+
+  # Structured result for monitoring
+  thctl doctor -o json`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := runDoctorChecks(); err != nil {
+			format, _ := cmd.Flags().GetString("output")
+			if format != "json" && format != "yaml" && format != "table" {
+				return fmt.Errorf("unsupported output format: %s", format)
+			}
+
+			r := runDoctorChecks(cmd.Context(), timeout)
+
+			switch format {
+			case "json", "yaml":
+				resp := &lotus.Response{
+					Version:   "1.0",
+					Timestamp: time.Now().Unix(),
+					Status:    "success",
+					Data:      r,
+				}
+				var err error
+				if format == "json" {
+					err = output.JSON(resp)
+				} else {
+					err = output.YAML(resp)
+				}
+				if err != nil {
+					return err
+				}
+			default:
+				printReport(r)
+			}
+
+			if !r.Healthy {
 				cmd.SilenceUsage = true
-				return err
+				return fmt.Errorf("diagnostic checks failed")
 			}
 			return nil
 		},
 	}
 
+	cmd.Flags().DurationVar(&timeout, "timeout", 15*time.Second, "Time allowed for the Lotus node checks and for each storage provider")
+	cmd.Flags().StringP("output", "o", "table", "Output format: json, yaml, or table")
+
 	return cmd
 }
 
-func runDoctorChecks() error {
-	fmt.Println("🔍 Running diagnostic checks...")
-	fmt.Println()
+func runDoctorChecks(ctx context.Context, timeout time.Duration) *report {
+	r := &report{Healthy: true, Checks: make([]check, 0)}
 
-	// First, check for .thctl.env file
-	fmt.Println("📁 Checking configuration file...")
+	// First, check for .thctl.env file in the current directory, then the home directory
 	envFile := ".thctl.env"
-	configFound := false
-	var configPath string
-
-	// Check in current directory
 	if _, err := os.Stat(envFile); err == nil {
-		configPath = envFile
-		fmt.Printf("✅ Found .thctl.env in current directory: %s\n", envFile)
-		configFound = true
-	} else {
-		// Check in home directory
-		home, err := os.UserHomeDir()
-		if err == nil {
-			homeEnvFile := filepath.Join(home, ".thctl.env")
-			if _, err := os.Stat(homeEnvFile); err == nil {
-				configPath = homeEnvFile
-				fmt.Printf("✅ Found .thctl.env in home directory: %s\n", homeEnvFile)
-				configFound = true
-			}
+		r.ConfigFile = envFile
+		r.add(sectionConfig, "config file", statusOK, fmt.Sprintf("Found .thctl.env in current directory: %s", envFile))
+	} else if home, err := os.UserHomeDir(); err == nil {
+		homeEnvFile := filepath.Join(home, ".thctl.env")
+		if _, err := os.Stat(homeEnvFile); err == nil {
+			r.ConfigFile = homeEnvFile
+			r.add(sectionConfig, "config file", statusOK, fmt.Sprintf("Found .thctl.env in home directory: %s", homeEnvFile))
 		}
 	}
-
-	if !configFound {
-		fmt.Println()
-		fmt.Println("📊 Diagnostic Summary:")
-		fmt.Println(" Configuration file (.thctl.env) not found!")
-		fmt.Println("   Please create a .thctl.env file in either:")
-		fmt.Printf("   - Current directory: %s\n", envFile)
-		if home, err := os.UserHomeDir(); err == nil {
-			fmt.Printf("   - Home directory: %s\n", filepath.Join(home, ".thctl.env"))
-		}
-		fmt.Println()
-		fmt.Println("   The .thctl.env file should contain:")
-		fmt.Println("   LOTUS_API_URL=http://your-lotus-node:1234/rpc/v0    # Required for fil commands")
-		fmt.Println("   LOTUS_API_TOKEN=your-api-token                      # Required for fil commands")
-		fmt.Println("   THC_API_KEY=your-api-key                           # Optional")
-		return fmt.Errorf("configuration file not found")
+	if r.ConfigFile == "" {
+		r.add(sectionConfig, "config file", statusFail, "Configuration file (.thctl.env) not found")
 	}
 
-	fmt.Println()
-
 	// Now check the configuration values
-	fmt.Println("🔧 Checking configuration values...")
 	cfg, err := config.Load()
 	if err != nil {
-		fmt.Printf(" Failed to load configuration: %v\n", err)
-		return fmt.Errorf("failed to load configuration from %s", configPath)
+		r.add(sectionConfig, "config values", statusFail, fmt.Sprintf("Failed to load configuration: %v", err))
+		return r
 	}
 
-	var missingLotusConfig []string
-
 	// Check Lotus configuration (required for fil commands)
-	if cfg.Lotus.APIURL == "" || cfg.Lotus.APIURL == "http://127.0.0.1:1234/rpc/v0" {
-		missingLotusConfig = append(missingLotusConfig, "LOTUS_API_URL")
-		fmt.Println("❌ LOTUS_API_URL is not set")
+	if n := len(cfg.Lotus.Endpoints); n > 0 {
+		r.add(sectionConfig, "LOTUS_API_ENDPOINTS", statusOK, fmt.Sprintf("LOTUS_API_ENDPOINTS: %d endpoints, primary %s", n, cfg.Lotus.APIURL))
+	} else if os.Getenv("LOTUS_API_URL") == "" {
+		r.add(sectionConfig, "LOTUS_API_URL", statusFail, fmt.Sprintf("LOTUS_API_URL is not set, the default %s is used", cfg.Lotus.APIURL))
 	} else {
-		fmt.Printf("✅ LOTUS_API_URL: %s\n", cfg.Lotus.APIURL)
+		r.add(sectionConfig, "LOTUS_API_URL", statusOK, fmt.Sprintf("LOTUS_API_URL: %s", cfg.Lotus.APIURL))
 	}
 
-	if cfg.Lotus.AuthToken == "" {
-		missingLotusConfig = append(missingLotusConfig, "LOTUS_API_TOKEN")
-		fmt.Println("❌ LOTUS_API_TOKEN is not set")
-	} else {
-		fmt.Println("✅ LOTUS_API_TOKEN is configured")
+	switch {
+	case len(cfg.Lotus.Endpoints) > 0:
+		// The tokens are given with the endpoints
+	case cfg.Lotus.AuthToken == "":
+		r.add(sectionConfig, "LOTUS_API_TOKEN", statusFail, "LOTUS_API_TOKEN is not set")
+	default:
+		r.add(sectionConfig, "LOTUS_API_TOKEN", statusOK, "LOTUS_API_TOKEN is configured")
 	}
 
 	// Check optional THCloud configuration
 	if cfg.THCloud.APIKey == "" {
-		fmt.Println("ℹ️ THC_API_KEY is not set (optional)")
+		r.add(sectionConfig, "THC_API_KEY", statusInfo, "THC_API_KEY is not set (optional)")
 	} else {
-		fmt.Println("✅ THC_API_KEY is configured")
+		r.add(sectionConfig, "THC_API_KEY", statusOK, "THC_API_KEY is configured")
+	}
+
+	checkLotus(ctx, r, cfg, timeout)
+	checkEndpoints(ctx, r, cfg, timeout)
+	checkStorage(ctx, r, cfg, timeout)
+	return r
+}
+
+// checkLotus connects to the configured Lotus node and checks its health
+func checkLotus(ctx context.Context, r *report, cfg *config.Config, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := lotus.New(lotus.Config{
+		APIURL:    cfg.Lotus.APIURL,
+		AuthToken: cfg.Lotus.AuthToken,
+		Timeout:   timeout,
+	})
+	health, err := client.GetNodeHealth(ctx)
+	if err != nil {
+		r.add(sectionLotus, "connection", statusFail, fmt.Sprintf("Cannot reach the Lotus API at %s: %v", cfg.Lotus.APIURL, err))
+		return
+	}
+	r.Lotus = health
+
+	status := statusOK
+	if health.RoundTripMs > slowRoundTripMs {
+		status = statusWarn
+	}
+	r.add(sectionLotus, "connection", status, fmt.Sprintf("Connected to %s, round trip %.1f ms (max %.1f ms)",
+		health.Endpoint, health.RoundTripMs, health.MaxRoundTripMs))
+	r.add(sectionLotus, "version", statusOK, fmt.Sprintf("Lotus %s, API %s", health.Version, health.APIVersion))
+	r.add(sectionLotus, "network", statusOK, fmt.Sprintf("%s, network version %d", health.Network, health.NetworkVersion))
+
+	lag := fmt.Sprintf("head at %d, %d epochs (%s) behind the wall clock",
+		health.HeadHeight, health.LagEpochs, time.Duration(health.LagSeconds)*time.Second)
+	switch {
+	case health.LagEpochs <= maxSyncedLag:
+		r.add(sectionLotus, "chain head", statusOK, "In sync: "+lag)
+	case health.LagEpochs <= maxCatchingUpLag:
+		r.add(sectionLotus, "chain head", statusWarn, "Falling behind: "+lag)
+	default:
+		r.add(sectionLotus, "chain head", statusFail, "Out of sync: "+lag)
+	}
+
+	checkSyncWorkers(r, health)
+	checkToken(r, health)
+}
+
+// checkEndpoints compares the heads of the configured endpoints when there are several.
+// An endpoint that is down or lagging is only a warning since calls fail over from it.
+func checkEndpoints(ctx context.Context, r *report, cfg *config.Config, timeout time.Duration) {
+	if len(cfg.Lotus.Endpoints) < 2 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	endpoints := make([]lotus.Endpoint, len(cfg.Lotus.Endpoints))
+	for i, e := range cfg.Lotus.Endpoints {
+		endpoints[i] = lotus.Endpoint{URL: e.URL, Token: e.Token}
+	}
+	client := lotus.New(lotus.Config{
+		Timeout:   timeout,
+		Endpoints: endpoints,
+		MaxLag:    cfg.Lotus.MaxLag,
+	})
+	r.Endpoints = client.EndpointStatuses(ctx)
+
+	healthy := 0
+	for i, e := range r.Endpoints {
+		name := fmt.Sprintf("endpoint %d", i+1)
+		if !e.Healthy {
+			r.add(sectionLotus, name, statusWarn, fmt.Sprintf("%s is out of rotation: %s", e.URL, e.Error))
+			continue
+		}
+		healthy++
+		r.add(sectionLotus, name, statusOK, fmt.Sprintf("%s: head at %d, %d epochs behind the best endpoint", e.URL, e.HeadHeight, e.LagEpochs))
+	}
+	if healthy == 0 {
+		r.add(sectionLotus, "endpoints", statusFail, "No healthy Lotus endpoint")
+	}
+}
+
+// checkSyncWorkers reports the sync workers of the node, failing when one errored
+func checkSyncWorkers(r *report, health *lotus.NodeHealth) {
+	if len(health.Syncs) == 0 {
+		r.add(sectionLotus, "sync", statusInfo, "No active sync workers")
+		return
+	}
+	for _, s := range health.Syncs {
+		name := fmt.Sprintf("sync worker %d", s.WorkerID)
+		switch s.Stage {
+		case "error":
+			r.add(sectionLotus, name, statusFail, fmt.Sprintf("Sync worker %d failed at %d: %s", s.WorkerID, s.Height, s.Message))
+		case "idle", "complete":
+			r.add(sectionLotus, name, statusOK, fmt.Sprintf("Sync worker %d %s at %d", s.WorkerID, s.Stage, s.Height))
+		default:
+			r.add(sectionLotus, name, statusInfo, fmt.Sprintf("Sync worker %d syncing %s from %d to %d, at %d", s.WorkerID, s.Stage, s.BaseHeight, s.TargetHeight, s.Height))
+		}
+	}
+}
+
+// checkToken reports the permissions the node grants the configured token
+func checkToken(r *report, health *lotus.NodeHealth) {
+	switch {
+	case !health.TokenConfigured:
+		r.add(sectionLotus, "token", statusWarn, "No token configured; only read methods are available")
+	case health.PermissionsError != "":
+		r.add(sectionLotus, "token", statusFail, fmt.Sprintf("Token check failed: %s", health.PermissionsError))
+	case health.HasPermission("admin"):
+		r.add(sectionLotus, "token", statusWarn, fmt.Sprintf("Token grants %s; thctl does not need admin, prefer a write token", strings.Join(health.Permissions, ", ")))
+	case !health.HasPermission("write"):
+		r.add(sectionLotus, "token", statusWarn, fmt.Sprintf("Token grants %s; pushing messages needs write", strings.Join(health.Permissions, ", ")))
+	default:
+		r.add(sectionLotus, "token", statusOK, fmt.Sprintf("Token grants %s", strings.Join(health.Permissions, ", ")))
+	}
+}
+
+func printReport(r *report) {
+	fmt.Println("🔍 Running diagnostic checks...")
+
+	sections := []struct{ name, title string }{
+		{sectionConfig, "🔧 Checking configuration..."},
+		{sectionLotus, "🪷 Checking Lotus node..."},
+		{sectionStorage, "🪣 Checking storage providers..."},
+	}
+	for _, section := range sections {
+		printed := false
+		for _, c := range r.Checks {
+			if c.Section != section.name {
+				continue
+			}
+			if !printed {
+				fmt.Println()
+				fmt.Println(section.title)
+				printed = true
+			}
+			fmt.Printf("%s %s\n", statusIcon(c.Status), c.Message)
+		}
 	}
 
 	fmt.Println()
 	fmt.Println("📊 Diagnostic Summary:")
-	if len(missingLotusConfig) > 0 {
-		fmt.Println("⚠️  The following Lotus configuration values are missing:")
-		for _, value := range missingLotusConfig {
-			fmt.Printf("   - %s\n", value)
+	if r.failed("config file") {
+		fmt.Println("   Please create a .thctl.env file in either:")
+		fmt.Println("   - Current directory: .thctl.env")
+		if home, err := os.UserHomeDir(); err == nil {
+			fmt.Printf("   - Home directory: %s\n", filepath.Join(home, ".thctl.env"))
+		}
+		fmt.Println()
+		fmt.Println("   The .thctl.env file should contain:")
+		fmt.Println("   LOTUS_API_URL=http://your-lotus-node:1234/rpc/v0    # Required for fil commands")
+		fmt.Println("   LOTUS_API_TOKEN=your-api-token                      # Required for fil commands")
+		fmt.Println("   THC_API_KEY=your-api-key                           # Optional")
+	}
+
+	failed, warned := 0, 0
+	for _, c := range r.Checks {
+		switch c.Status {
+		case statusFail:
+			failed++
+		case statusWarn:
+			warned++
 		}
-		fmt.Println("   These values are required for using fil commands.")
-		return fmt.Errorf("missing required Lotus configuration values")
 	}
+	switch {
+	case failed > 0:
+		fmt.Printf("❌ %d checks failed, %d warnings\n", failed, warned)
+	case warned > 0:
+		fmt.Printf("⚠️  All checks passed with %d warnings\n", warned)
+	default:
+		fmt.Println("✅ All checks passed!")
+	}
+}
 
-	fmt.Println("✅ All configuration checks passed!")
-	return nil
+// statusIcon returns the emoji shown for a check status
+func statusIcon(status string) string {
+	switch status {
+	case statusOK:
+		return "✅"
+	case statusWarn:
+		return "⚠️ "
+	case statusFail:
+		return "❌"
+	default:
+		return "ℹ️ "
+	}
 }
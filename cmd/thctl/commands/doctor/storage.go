@@ -0,0 +1,154 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/THCloudAI/thctl/internal/config"
+	"github.com/THCloudAI/thctl/internal/storage"
+	"github.com/THCloudAI/thctl/internal/storage/cos"
+	"github.com/THCloudAI/thctl/internal/storage/oss"
+	"github.com/THCloudAI/thctl/internal/storage/s3"
+)
+
+// storageNames are the display names of the storage providers
+var storageNames = map[string]string{
+	"oss":   "OSS",
+	"s3":    "S3",
+	"cos":   "COS",
+	"obs":   "OBS",
+	"minio": "MinIO",
+}
+
+const (
+	// maxClockSkew is the clock skew in seconds beyond which the skew is reported
+	maxClockSkew = 30
+	// maxSignatureSkew is the clock skew in seconds beyond which providers reject signed requests
+	maxSignatureSkew = 15 * 60
+)
+
+// newStorageProvider creates the client of a storage profile
+func newStorageProvider(profile config.StorageProfile, cfg *storage.Config) (storage.Provider, error) {
+	switch profile.Provider {
+	case "oss":
+		return oss.NewClient(cfg)
+	case "cos":
+		return cos.NewClient(cfg)
+	case "s3", "obs", "minio":
+		return s3.NewClient(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported storage provider: %s", profile.Provider)
+	}
+}
+
+// checkStorage probes every configured storage profile
+func checkStorage(ctx context.Context, r *report, cfg *config.Config, timeout time.Duration) {
+	if len(cfg.Storage.Profiles) == 0 {
+		r.add(sectionStorage, "storage", statusInfo, "No storage provider configured (optional)")
+		return
+	}
+	for _, profile := range cfg.Storage.Profiles {
+		checkStorageProfile(ctx, r, profile, timeout)
+	}
+}
+
+// checkStorageProfile checks the credentials, bucket and permissions of a storage profile
+func checkStorageProfile(ctx context.Context, r *report, profile config.StorageProfile, timeout time.Duration) {
+	name := storageNames[profile.Provider]
+
+	var missing []string
+	if profile.Bucket == "" {
+		missing = append(missing, "bucket")
+	}
+	if profile.AccessKey == "" || profile.SecretKey == "" {
+		missing = append(missing, "access key")
+	}
+	if profile.Endpoint == "" && profile.Provider != "cos" {
+		missing = append(missing, "endpoint")
+	}
+	if profile.Region == "" && profile.Provider == "cos" {
+		missing = append(missing, "region")
+	}
+	if len(missing) > 0 {
+		r.add(sectionStorage, profile.Provider+" config", statusFail, fmt.Sprintf("%s profile is incomplete, missing %s", name, strings.Join(missing, ", ")))
+		return
+	}
+
+	cfg := &storage.Config{
+		Region:     profile.Region,
+		AccessKey:  profile.AccessKey,
+		SecretKey:  profile.SecretKey,
+		Endpoint:   profile.Endpoint,
+		BucketName: profile.Bucket,
+	}
+	provider, err := newStorageProvider(profile, cfg)
+	if err != nil {
+		r.add(sectionStorage, profile.Provider+" config", statusFail, fmt.Sprintf("Failed to create %s client: %v", name, err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	probe := storage.Probe(ctx, provider, cfg)
+	if r.Storage == nil {
+		r.Storage = make(map[string]*storage.ProbeReport)
+	}
+	r.Storage[profile.Provider] = probe
+
+	skew := math.Abs(probe.ClockSkewSeconds)
+	switch {
+	case probe.ClockError != "":
+		r.add(sectionStorage, profile.Provider+" clock", statusWarn, fmt.Sprintf("%s: cannot measure clock skew: %s", name, probe.ClockError))
+	case skew >= maxSignatureSkew:
+		r.add(sectionStorage, profile.Provider+" clock", statusFail, fmt.Sprintf("%s: local clock is %.0fs off the provider, signed requests are rejected", name, probe.ClockSkewSeconds))
+	case skew >= maxClockSkew:
+		r.add(sectionStorage, profile.Provider+" clock", statusWarn, fmt.Sprintf("%s: local clock is %.0fs off the provider", name, probe.ClockSkewSeconds))
+	default:
+		r.add(sectionStorage, profile.Provider+" clock", statusOK, fmt.Sprintf("%s: clock skew %.1fs", name, probe.ClockSkewSeconds))
+	}
+
+	switch {
+	case !probe.Authenticated:
+		r.add(sectionStorage, profile.Provider+" credentials", statusFail, fmt.Sprintf("%s: credentials check failed at %s: %s", name, probe.Endpoint, probe.AuthError))
+		return
+	case probe.ListBucketsError != "":
+		r.add(sectionStorage, profile.Provider+" credentials", statusOK, fmt.Sprintf("%s: credentials accepted, but buckets cannot be listed: %s", name, probe.ListBucketsError))
+	default:
+		r.add(sectionStorage, profile.Provider+" credentials", statusOK, fmt.Sprintf("%s: credentials accepted", name))
+	}
+
+	switch {
+	case probe.BucketFound && !probe.RegionMatches():
+		r.add(sectionStorage, profile.Provider+" bucket", statusFail, fmt.Sprintf("%s: bucket %s is in region %s, not the configured %s", name, probe.Bucket, probe.BucketRegion, probe.Region))
+	case probe.BucketFound:
+		r.add(sectionStorage, profile.Provider+" bucket", statusOK, fmt.Sprintf("%s: bucket %s found in region %s", name, probe.Bucket, valueOrDash(probe.BucketRegion)))
+	case len(probe.Operations) == 0:
+		r.add(sectionStorage, profile.Provider+" bucket", statusFail, fmt.Sprintf("%s: bucket %s not found: %s", name, probe.Bucket, probe.BucketError))
+		return
+	default:
+		r.add(sectionStorage, profile.Provider+" bucket", statusWarn, fmt.Sprintf("%s: cannot read the location of bucket %s: %s", name, probe.Bucket, probe.BucketError))
+	}
+
+	for _, op := range probe.Operations {
+		if op.OK {
+			r.add(sectionStorage, profile.Provider+" "+op.Name, statusOK, fmt.Sprintf("%s: %s permitted (%.1f ms)", name, op.Name, op.LatencyMs))
+		} else {
+			r.add(sectionStorage, profile.Provider+" "+op.Name, statusFail, fmt.Sprintf("%s: %s failed: %s", name, op.Name, op.Error))
+		}
+	}
+	if probe.ProbeKey != "" && !probe.Cleaned {
+		r.add(sectionStorage, profile.Provider+" cleanup", statusWarn, fmt.Sprintf("%s: probe object %s was left in bucket %s, delete it by hand", name, probe.ProbeKey, probe.Bucket))
+	}
+}
+
+// valueOrDash returns the value or "-" when it is empty
+func valueOrDash(v string) string {
+	if v == "" {
+		return "-"
+	}
+	return v
+}
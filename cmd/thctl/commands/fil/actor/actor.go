@@ -0,0 +1,242 @@
+package actor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/cmd/thctl/commands/fil/miner"
+	"github.com/THCloudAI/thctl/internal/address"
+	"github.com/THCloudAI/thctl/internal/lotus"
+	"github.com/THCloudAI/thctl/internal/units"
+	"github.com/THCloudAI/thctl/pkg/framework/output"
+)
+
+// maxListItems is the longest list of state values shown in full in table output
+const maxListItems = 10
+
+// filFields and byteFields name the state fields of built-in actors holding FIL
+// amounts and byte sizes, so the state table shows them in display units
+var (
+	filFields = map[string]bool{
+		"InitialBalance":                true,
+		"ToSend":                        true,
+		"TotalClientLockedCollateral":   true,
+		"TotalProviderLockedCollateral": true,
+		"TotalClientStorageFee":         true,
+		"TotalPledgeCollateral":         true,
+		"ThisEpochPledgeCollateral":     true,
+		"ThisEpochReward":               true,
+		"TotalStoragePowerReward":       true,
+		"SimpleTotal":                   true,
+		"BaselineTotal":                 true,
+	}
+	byteFields = map[string]bool{
+		"TotalRawBytePower":        true,
+		"TotalBytesCommitted":      true,
+		"TotalQualityAdjPower":     true,
+		"TotalQABytesCommitted":    true,
+		"ThisEpochRawBytePower":    true,
+		"ThisEpochQualityAdjPower": true,
+	}
+)
+
+// NewActorCmd creates a new actor command
+func NewActorCmd() *cobra.Command {
+	var raw bool
+
+	cmd := &cobra.Command{
+		Use:   "actor [address]",
+		Short: "Inspect any actor and its state",
+		Long: `Inspect any actor and its state.
+
+The address may be an ID, robust, f410 or Ethereum 0x address. Its actor type is found
+by matching the actor code CID against the built-in actors of the current network
+version, and its state is read with StateReadState. Multisig vesting and EVM contract
+state are decoded; other actors show their state fields.
+
+Miners are shown with the same view as 'thctl fil miner', unless --raw is given.
+
+Examples:
+  # Inspect a multisig wallet
+  thctl fil actor f080
+
+  # Inspect an EVM contract by its Ethereum address
+  thctl fil actor 0x52963ef50e27e06d72d59fcb4f3c2a687be3cfef
+
+  # Show the raw state of a miner as JSON
+  thctl fil actor f01234 --raw -o json`,
+		Args: cobra.MatchAll(cobra.ExactArgs(1), address.AddressArgs(0)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := lotus.NewFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create Lotus client: %v", err)
+			}
+
+			info, err := client.GetActorInfo(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+
+			format, _ := cmd.Flags().GetString("output")
+			if info.Type == lotus.ActorMiner && !raw {
+				return miner.ShowMinerInfo(cmd.Context(), client, info.ID, format, false, false)
+			}
+
+			switch format {
+			case "json":
+				return output.JSON(info)
+			case "yaml":
+				return output.YAML(info)
+			case "table":
+				printActorTable(info)
+			default:
+				return fmt.Errorf("unsupported output format: %s", format)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&raw, "raw", false, "Show the state of miners instead of the miner view")
+	cmd.Flags().StringP("output", "o", "table", "Output format: json, yaml, or table")
+
+	return cmd
+}
+
+func printActorTable(info *lotus.ActorInfo) {
+	fmt.Printf("\n🎭 Actor %s (%s)\n", info.Address, info.Type)
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Attribute", "Value"})
+	t.AppendRow(table.Row{"ID", info.ID})
+	t.AppendRow(table.Row{"Robust Address", valueOrDash(info.Robust)})
+	if info.DelegatedAddress != "" {
+		t.AppendRow(table.Row{"Delegated Address", info.DelegatedAddress})
+	}
+	t.AppendRow(table.Row{"Ethereum Address", valueOrDash(info.EthAddress)})
+	t.AppendRow(table.Row{"Actor Type", info.Type})
+	t.AppendRow(table.Row{"Code CID", info.Code})
+	t.AppendRow(table.Row{"Network Version", info.NetworkVersion})
+	t.AppendRow(table.Row{"Height", info.Height})
+	t.AppendRow(table.Row{"Nonce", info.Nonce})
+	t.AppendRow(table.Row{"Balance", info.Balance.String()})
+	fmt.Println(t.Render())
+
+	switch {
+	case info.Multisig != nil:
+		printMultisigTable(info.Multisig)
+	case info.EVM != nil:
+		printEVMTable(info.EVM)
+	case info.Type == lotus.ActorPlaceholder:
+		fmt.Println("\nℹ️ Placeholder actors hold funds sent to an f410 address before an account or contract is deployed there; they have no state.")
+	case len(info.State) > 0:
+		printStateTable(info.State)
+	}
+}
+
+func printMultisigTable(msig *lotus.MultisigState) {
+	fmt.Println("\n👥 Multisig:")
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Attribute", "Value"})
+	t.AppendRow(table.Row{"Threshold", fmt.Sprintf("%d of %d signers", msig.NumApprovalsThreshold, len(msig.Signers))})
+	for i, signer := range msig.Signers {
+		t.AppendRow(table.Row{fmt.Sprintf("Signer %d", i+1), signer})
+	}
+	t.AppendRow(table.Row{"Next Transaction ID", msig.NextTxnID})
+	if msig.UnlockDuration > 0 {
+		t.AppendRow(table.Row{"Initial Balance", msig.InitialBalance.String()})
+		t.AppendRow(table.Row{"Vesting Start Epoch", msig.StartEpoch})
+		t.AppendRow(table.Row{"Vesting Duration", fmt.Sprintf("%d epochs", msig.UnlockDuration)})
+	}
+	t.AppendRow(table.Row{"Locked Balance", msig.LockedBalance.String()})
+	t.AppendRow(table.Row{"Available Balance", msig.AvailableBalance.String()})
+	fmt.Println(t.Render())
+}
+
+func printEVMTable(evm *lotus.EVMState) {
+	fmt.Println("\n📜 EVM Contract:")
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Attribute", "Value"})
+	t.AppendRow(table.Row{"Bytecode CID", evm.Bytecode})
+	t.AppendRow(table.Row{"Bytecode Hash", evm.BytecodeHash})
+	t.AppendRow(table.Row{"Contract State", evm.ContractState})
+	t.AppendRow(table.Row{"Nonce", evm.Nonce})
+	t.AppendRow(table.Row{"Self-Destructed", evm.Tombstoned})
+	fmt.Println(t.Render())
+}
+
+func printStateTable(state map[string]interface{}) {
+	fmt.Println("\n🗂️ State:")
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Field", "Value"})
+	for _, row := range stateRows("", state) {
+		t.AppendRow(row)
+	}
+	fmt.Println(t.Render())
+}
+
+// stateRows flattens a decoded actor state into one row per field, naming nested
+// fields by their path and showing CID links by their CID
+func stateRows(prefix string, value interface{}) []table.Row {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if cid, ok := v["/"].(string); ok && len(v) == 1 {
+			return []table.Row{{prefix, cid}}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		var rows []table.Row
+		for _, key := range keys {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			rows = append(rows, stateRows(path, v[key])...)
+		}
+		if len(rows) == 0 {
+			rows = append(rows, table.Row{prefix, "-"})
+		}
+		return rows
+	case []interface{}:
+		if len(v) > maxListItems {
+			return []table.Row{{prefix, fmt.Sprintf("%d items", len(v))}}
+		}
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+		return []table.Row{{prefix, valueOrDash(strings.Join(values, ", "))}}
+	case string:
+		return []table.Row{{prefix, formatStateValue(prefix, v)}}
+	case nil:
+		return []table.Row{{prefix, "-"}}
+	default:
+		return []table.Row{{prefix, fmt.Sprint(v)}}
+	}
+}
+
+// formatStateValue shows known FIL amounts and byte sizes in display units
+func formatStateValue(path, value string) string {
+	field := path[strings.LastIndex(path, ".")+1:]
+	switch {
+	case filFields[field]:
+		return units.FILFromAtto(value).String()
+	case byteFields[field]:
+		return units.BytesFromString(value).String()
+	}
+	return value
+}
+
+// valueOrDash returns "-" for empty values
+func valueOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,304 @@
+package address
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	filaddr "github.com/THCloudAI/thctl/internal/address"
+	"github.com/THCloudAI/thctl/internal/lotus"
+	"github.com/THCloudAI/thctl/pkg/framework/output"
+)
+
+// ValidationResult reports whether an address is valid
+type ValidationResult struct {
+	Address string `json:"address" yaml:"address"`
+	Valid   bool   `json:"valid" yaml:"valid"`
+	Error   string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// LookupResult holds the ID and robust forms of an actor address
+type LookupResult struct {
+	Address string `json:"address" yaml:"address"`
+	ID      string `json:"id" yaml:"id"`
+	Robust  string `json:"robust,omitempty" yaml:"robust,omitempty"`
+}
+
+// NewAddressCmd creates a new address command
+func NewAddressCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "address",
+		Short: "Parse, validate and convert Filecoin addresses",
+		Long: `Parse, validate and convert Filecoin addresses.
+
+Addresses may use the mainnet (f) or testnet (t) prefix. Ethereum 0x addresses are
+accepted wherever an address is expected and map to their f410 address, or to the
+ID address for masked ID addresses (0xff0000...).
+
+Examples:
+  # Show the protocol, payload and checksum of an address
+  thctl fil address parse f410fkkld55ioe7qg24wvt7fu6pbknb56ht7pt4zamxa
+
+  # Check that miner addresses are well formed
+  thctl fil address validate --miner f01234 f02345
+
+  # Convert an Ethereum address to its f410 address
+  thctl fil address convert 0x52963ef50e27e06d72d59fcb4f3c2a687be3cfef
+
+  # Find the ID address of an account
+  thctl fil address lookup f1abjxfbp274xpdqcpuaykwkfb43omjotacm2p3za`,
+	}
+
+	cmd.AddCommand(
+		newParseCmd(),
+		newValidateCmd(),
+		newConvertCmd(),
+		newLookupCmd(),
+	)
+
+	return cmd
+}
+
+func newParseCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "parse [address]...",
+		Short: "Show the protocol, network, payload and checksum of addresses",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			infos := make([]*filaddr.Info, 0, len(args))
+			for _, arg := range args {
+				info, err := filaddr.Inspect(arg)
+				if err != nil {
+					return err
+				}
+				infos = append(infos, info)
+			}
+
+			format, _ := cmd.Flags().GetString("output")
+			switch format {
+			case "json":
+				return output.JSON(infos)
+			case "yaml":
+				return output.YAML(infos)
+			case "table":
+				for _, info := range infos {
+					printInfoTable(info)
+				}
+			default:
+				return fmt.Errorf("unsupported output format: %s", format)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("output", "o", "table", "Output format: json, yaml, or table")
+
+	return cmd
+}
+
+func newValidateCmd() *cobra.Command {
+	var miner bool
+
+	cmd := &cobra.Command{
+		Use:   "validate [address]...",
+		Short: "Check that addresses are well formed and their checksums match",
+		Long: `Check that addresses are well formed and their checksums match.
+
+The command fails if any address is invalid. With --miner only ID (f0) and actor (f2)
+addresses, which can identify a miner, are accepted.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			validate := filaddr.Validate
+			if miner {
+				validate = filaddr.ValidateMiner
+			}
+
+			results := make([]ValidationResult, 0, len(args))
+			invalid := 0
+			for _, arg := range args {
+				result := ValidationResult{Address: arg, Valid: true}
+				if err := validate(arg); err != nil {
+					result.Valid = false
+					result.Error = err.Error()
+					invalid++
+				}
+				results = append(results, result)
+			}
+
+			format, _ := cmd.Flags().GetString("output")
+			switch format {
+			case "json":
+				if err := output.JSON(results); err != nil {
+					return err
+				}
+			case "yaml":
+				if err := output.YAML(results); err != nil {
+					return err
+				}
+			case "table":
+				for _, result := range results {
+					if result.Valid {
+						fmt.Printf("✅ %s\n", result.Address)
+					} else {
+						fmt.Printf("❌ %s\n", result.Error)
+					}
+				}
+			default:
+				return fmt.Errorf("unsupported output format: %s", format)
+			}
+
+			if invalid > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d of %d addresses are invalid", invalid, len(results))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&miner, "miner", false, "Only accept addresses that can identify a miner (f0 or f2)")
+	cmd.Flags().StringP("output", "o", "table", "Output format: json, yaml, or table")
+
+	return cmd
+}
+
+func newConvertCmd() *cobra.Command {
+	var (
+		to      string
+		testnet bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "convert [address]",
+		Short: "Convert between Filecoin and Ethereum addresses",
+		Long: `Convert between Filecoin and Ethereum addresses.
+
+By default Ethereum addresses are converted to Filecoin addresses and f410 or ID
+addresses to their checksummed Ethereum form. Use --to to choose the target explicitly.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			addr, err := filaddr.Parse(args[0])
+			if err != nil {
+				return err
+			}
+
+			if to == "" {
+				to = "eth"
+				if strings.HasPrefix(strings.ToLower(args[0]), "0x") {
+					to = "fil"
+				}
+			}
+
+			switch to {
+			case "eth":
+				eth, err := filaddr.ToEthAddress(addr)
+				if err != nil {
+					return err
+				}
+				fmt.Println(eth)
+			case "fil":
+				if testnet {
+					fmt.Println(address.TestnetPrefix + addr.String()[1:])
+				} else {
+					fmt.Println(address.MainnetPrefix + addr.String()[1:])
+				}
+			default:
+				return fmt.Errorf("unsupported target: %s (use fil or eth)", to)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&to, "to", "", "Target format: fil or eth")
+	cmd.Flags().BoolVar(&testnet, "testnet", false, "Use the testnet (t) prefix for Filecoin addresses")
+
+	return cmd
+}
+
+func newLookupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lookup [address]",
+		Short: "Resolve an address to its ID and robust forms on chain",
+		Long: `Resolve an address to its ID and robust forms on chain.
+
+Robust addresses (f1, f2, f3, f4) are resolved with StateLookupID and ID addresses
+with StateLookupRobustAddress. Actors created without a robust address, such as
+built-in actors, only have an ID address.`,
+		Args: cobra.MatchAll(cobra.ExactArgs(1), filaddr.AddressArgs(0)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			addr, _ := filaddr.Parse(args[0])
+
+			client, err := lotus.NewFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create Lotus client: %v", err)
+			}
+
+			// Detect the network first so the input prints with the node's prefix
+			if _, err := client.Network(ctx); err != nil {
+				return err
+			}
+
+			result := LookupResult{Address: args[0]}
+			if addr.Protocol() == address.ID {
+				result.ID = addr.String()
+				if result.Robust, err = client.LookupRobustAddress(ctx, result.ID); err != nil {
+					return err
+				}
+			} else {
+				result.Robust = addr.String()
+				if result.ID, err = client.LookupID(ctx, result.Robust); err != nil {
+					return err
+				}
+			}
+
+			format, _ := cmd.Flags().GetString("output")
+			switch format {
+			case "json":
+				return output.JSON(result)
+			case "yaml":
+				return output.YAML(result)
+			case "table":
+				t := table.NewWriter()
+				t.AppendRow(table.Row{"🆔 ID", result.ID})
+				t.AppendRow(table.Row{"🔑 Robust", valueOrDash(result.Robust)})
+				fmt.Println(t.Render())
+			default:
+				return fmt.Errorf("unsupported output format: %s", format)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("output", "o", "table", "Output format: json, yaml, or table")
+
+	return cmd
+}
+
+func printInfoTable(info *filaddr.Info) {
+	t := table.NewWriter()
+	t.SetTitle(info.Input)
+	t.AppendRow(table.Row{"🏷️ Address", info.Address})
+	t.AppendRow(table.Row{"🧪 Testnet", info.Testnet})
+	t.AppendRow(table.Row{"🌐 Network", info.Network})
+	t.AppendRow(table.Row{"🔢 Protocol", fmt.Sprintf("%d (%s)", info.Protocol, info.ProtocolName)})
+	if info.ActorID != nil {
+		t.AppendRow(table.Row{"🆔 Actor ID", *info.ActorID})
+	}
+	if info.Namespace != nil {
+		t.AppendRow(table.Row{"📂 Namespace", *info.Namespace})
+	}
+	t.AppendRow(table.Row{"📦 Payload", valueOrDash(info.Payload)})
+	t.AppendRow(table.Row{"✅ Checksum", valueOrDash(info.Checksum)})
+	t.AppendRow(table.Row{"🦊 Ethereum", valueOrDash(info.EthAddress)})
+	fmt.Println(t.Render())
+}
+
+// valueOrDash returns "-" for empty values
+func valueOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -3,9 +3,17 @@ package fil
 import (
 	"fmt"
 	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/cmd/thctl/commands/fil/actor"
+	"github.com/THCloudAI/thctl/cmd/thctl/commands/fil/address"
 	"github.com/THCloudAI/thctl/cmd/thctl/commands/fil/miner"
+	"github.com/THCloudAI/thctl/cmd/thctl/commands/fil/mpool"
+	"github.com/THCloudAI/thctl/cmd/thctl/commands/fil/msig"
+	"github.com/THCloudAI/thctl/cmd/thctl/commands/fil/network"
+	"github.com/THCloudAI/thctl/cmd/thctl/commands/fil/sealing"
 	"github.com/THCloudAI/thctl/cmd/thctl/commands/fil/sectors"
+	"github.com/THCloudAI/thctl/cmd/thctl/commands/fil/storage"
 	"github.com/THCloudAI/thctl/internal/lotus"
+	"github.com/THCloudAI/thctl/internal/units"
 )
 
 func NewFilCmd() *cobra.Command {
@@ -38,6 +46,13 @@ func NewFilCmd() *cobra.Command {
 	// Add subcommands
 	minerCmd := miner.NewMinerCmd()
 	sectorsCmd := sectors.NewSectorsCmd()
+	networkCmd := network.NewNetworkCmd()
+	addressCmd := address.NewAddressCmd()
+	actorCmd := actor.NewActorCmd()
+	msigCmd := msig.NewMsigCmd()
+	mpoolCmd := mpool.NewMpoolCmd()
+	sealingCmd := sealing.NewSealingCmd()
+	storageCmd := storage.NewStorageCmd()
 
 	// Set custom help template for all commands to not show global flags
 	helpTemplate := `{{.Long | trimTrailingWhitespaces}}
@@ -59,16 +74,20 @@ Flags:
 
 	// Apply template to fil command and all subcommands
 	cmd.SetHelpTemplate(helpTemplate)
-	for _, subcmd := range []*cobra.Command{minerCmd, sectorsCmd} {
+	for _, subcmd := range []*cobra.Command{minerCmd, sectorsCmd, networkCmd, addressCmd, actorCmd, msigCmd, mpoolCmd, sealingCmd, storageCmd} {
 		subcmd.SetHelpTemplate(helpTemplate)
 	}
 
-	cmd.AddCommand(sectorsCmd, minerCmd)
+	cmd.AddCommand(sectorsCmd, minerCmd, networkCmd, addressCmd, actorCmd, msigCmd, mpoolCmd, sealingCmd, storageCmd)
 
 	// Add persistent flags for API configuration
 	cmd.PersistentFlags().String("api-url", "", "Lotus API URL (overrides config)")
 	cmd.PersistentFlags().String("auth-token", "", "Lotus API token (overrides config)")
 	cmd.PersistentFlags().StringP("output", "o", "", "Output format: json, yaml, or table (default \"json\")")
 
+	// Add persistent flags for how amounts and sizes are shown in table output
+	cmd.PersistentFlags().Var(&units.UnitsFlag{}, "units", "Units of table output: fil, millifil, microfil, nanofil, attofil or auto, and iec or si for sizes (e.g. \"auto,si\")")
+	cmd.PersistentFlags().Var(&units.PrecisionFlag{}, "precision", "Number of decimals shown for FIL amounts")
+
 	return cmd
 }
@@ -0,0 +1,147 @@
+package blocks
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/internal/address"
+	"github.com/THCloudAI/thctl/internal/cache"
+	"github.com/THCloudAI/thctl/internal/lotus"
+	"github.com/THCloudAI/thctl/pkg/framework/output"
+)
+
+// NewBlocksCmd creates a new blocks command
+func NewBlocksCmd() *cobra.Command {
+	var (
+		from    uint64
+		to      uint64
+		epochs  uint64
+		noCache bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "blocks [miner_id]",
+		Short: "Get miner block mining history",
+		Long: `Walk the chain over a height range and report the blocks mined by a Filecoin storage provider, including:
+- Blocks mined and total win count
+- Block rewards
+- Expected wins from the miner's quality adjusted power share, sampled over the range
+- Luck (actual wins / expected wins)
+
+Finalized epochs are cached locally so repeated ranges don't re-walk the chain.
+
+Examples:
+  # Blocks mined over the last day
+  thctl fil miner blocks f01234
+
+  # Blocks mined over a fixed height range
+  thctl fil miner blocks f01234 --from 4000000 --to 4028800`,
+		Args: cobra.MatchAll(cobra.ExactArgs(1), address.MinerArgs(0)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			minerID := args[0]
+
+			client, err := lotus.NewFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create Lotus client: %v", err)
+			}
+
+			if to == 0 {
+				head, err := client.GetChainHead(cmd.Context())
+				if err != nil {
+					return err
+				}
+				to = head.Height
+			}
+			if !cmd.Flags().Changed("from") {
+				if epochs > to {
+					from = 0
+				} else {
+					from = to - epochs + 1
+				}
+			}
+
+			var store *cache.Store
+			if !noCache {
+				store = client.CacheStore(cmd.Context(), "chain")
+			}
+
+			history, err := client.GetBlockHistory(cmd.Context(), minerID, from, to, store)
+			if err != nil {
+				return fmt.Errorf("failed to get block history: %w", err)
+			}
+
+			resp := &lotus.Response{
+				Version:   "1.0",
+				Timestamp: time.Now().Unix(),
+				Status:    "success",
+				Data:      history,
+				Endpoints: client.ServedBy(),
+			}
+
+			format, _ := cmd.Flags().GetString("output")
+			switch format {
+			case "json":
+				return output.JSON(resp)
+			case "yaml":
+				return output.YAML(resp)
+			case "table":
+				printBlocksTable(history)
+			default:
+				return fmt.Errorf("unsupported output format: %s", format)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().Uint64Var(&from, "from", 0, "First height of the range (default: --epochs before --to)")
+	cmd.Flags().Uint64Var(&to, "to", 0, "Last height of the range (default: chain head)")
+	cmd.Flags().Uint64Var(&epochs, "epochs", 2880, "Number of epochs to scan when --from is not set")
+	cmd.Flags().BoolVar(&noCache, "no-cache", false, "Do not read or write the local chain cache")
+	cmd.Flags().StringP("output", "o", "json", "Output format: json, yaml, or table")
+
+	return cmd
+}
+
+func printBlocksTable(history *lotus.BlockHistory) {
+	fmt.Printf("\n⛏️ Block Mining History for %s (heights %d - %d)\n", history.MinerID, history.FromHeight, history.ToHeight)
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Attribute", "Value"})
+	t.AppendRow(table.Row{"Epochs Scanned", history.Epochs})
+	t.AppendRow(table.Row{"Null Rounds", history.NullRounds})
+	t.AppendRow(table.Row{"Blocks Mined", history.BlocksMined})
+	t.AppendRow(table.Row{"Win Count", history.WinCount})
+	t.AppendRow(table.Row{"Total Rewards", history.TotalRewards.String()})
+	t.AppendRow(table.Row{"Average Power Share (QAP)", fmt.Sprintf("%.4f%%", history.PowerShare*100)})
+	t.AppendRow(table.Row{"Expected Wins", fmt.Sprintf("%.2f", history.ExpectedWins)})
+	t.AppendRow(table.Row{"Luck", fmt.Sprintf("%.2f%%", history.Luck*100)})
+	fmt.Println(t.Render())
+
+	if len(history.Warnings) > 0 {
+		fmt.Println("\n⚠️ Warnings:")
+		for _, warning := range history.Warnings {
+			fmt.Printf("  - %s\n", warning)
+		}
+	}
+
+	if len(history.Blocks) == 0 {
+		return
+	}
+
+	fmt.Println("\n🧱 Blocks:")
+	t = table.NewWriter()
+	t.AppendHeader(table.Row{"Height", "Time", "Win Count", "Reward", "CID"})
+	for _, b := range history.Blocks {
+		t.AppendRow(table.Row{
+			b.Height,
+			time.Unix(b.Timestamp, 0).Format(time.RFC3339),
+			b.WinCount,
+			b.Reward.String(),
+			b.Cid,
+		})
+	}
+	fmt.Println(t.Render())
+}
@@ -0,0 +1,308 @@
+package compare
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/internal/address"
+	"github.com/THCloudAI/thctl/internal/lotus"
+	"github.com/THCloudAI/thctl/internal/units"
+	"github.com/THCloudAI/thctl/pkg/framework/output"
+)
+
+const (
+	// outlierDeviation is how far from the median of the compared miners a value is an
+	// outlier, as a fraction of the median
+	outlierDeviation = 0.5
+	// minOutlierMiners is the number of miners needed for a median to point out outliers
+	minOutlierMiners = 3
+	// tebibyte is the number of bytes in a TiB
+	tebibyte = 1 << 40
+)
+
+// minerComparison is the compared view of a single miner. Outliers lists the fields that
+// are far from the median of the compared miners.
+type minerComparison struct {
+	RawBytePower        units.Bytes `json:"rawBytePower"`
+	QualityAdjPower     units.Bytes `json:"qualityAdjPower"`
+	NetworkShare        float64     `json:"networkShare"`
+	LiveSectors         uint64      `json:"liveSectors"`
+	ActiveSectors       uint64      `json:"activeSectors"`
+	FaultySectors       uint64      `json:"faultySectors"`
+	RecoveringSectors   uint64      `json:"recoveringSectors"`
+	FaultRatio          float64     `json:"faultRatio"`
+	Balance             units.FIL   `json:"balance"`
+	AvailableBalance    units.FIL   `json:"availableBalance"`
+	VestingFunds        units.FIL   `json:"vestingFunds"`
+	InitialPledge       units.FIL   `json:"initialPledge"`
+	PledgePerTiB        units.FIL   `json:"pledgePerTiB"`
+	WorkerBalance       units.FIL   `json:"workerBalance"`
+	QualityAdjPowerRank uint64      `json:"qualityAdjPowerRank,omitempty"`
+	Outliers            []string    `json:"outliers,omitempty"`
+}
+
+// compareRow is a row of the comparison table
+type compareRow struct {
+	key     string
+	name    string
+	value   func(m *minerComparison) float64
+	format  func(m *minerComparison) string
+	outlier bool
+}
+
+// compareRows lists the rows of the comparison table in display order
+var compareRows = []compareRow{
+	{"rawBytePower", "Raw Power", func(m *minerComparison) float64 { return toFloat(m.RawBytePower.Int()) },
+		func(m *minerComparison) string { return m.RawBytePower.String() }, true},
+	{"qualityAdjPower", "QA Power", func(m *minerComparison) float64 { return toFloat(m.QualityAdjPower.Int()) },
+		func(m *minerComparison) string { return m.QualityAdjPower.String() }, true},
+	{"networkShare", "Network Share", func(m *minerComparison) float64 { return m.NetworkShare },
+		func(m *minerComparison) string { return fmt.Sprintf("%.4f%%", m.NetworkShare*100) }, true},
+	{"liveSectors", "Live Sectors", func(m *minerComparison) float64 { return float64(m.LiveSectors) },
+		func(m *minerComparison) string { return fmt.Sprint(m.LiveSectors) }, true},
+	{"activeSectors", "Active Sectors", func(m *minerComparison) float64 { return float64(m.ActiveSectors) },
+		func(m *minerComparison) string { return fmt.Sprint(m.ActiveSectors) }, true},
+	{"faultySectors", "Faulty Sectors", func(m *minerComparison) float64 { return float64(m.FaultySectors) },
+		func(m *minerComparison) string { return fmt.Sprint(m.FaultySectors) }, true},
+	{"recoveringSectors", "Recovering Sectors", func(m *minerComparison) float64 { return float64(m.RecoveringSectors) },
+		func(m *minerComparison) string { return fmt.Sprint(m.RecoveringSectors) }, true},
+	{"faultRatio", "Fault Ratio", func(m *minerComparison) float64 { return m.FaultRatio },
+		func(m *minerComparison) string { return fmt.Sprintf("%.2f%%", m.FaultRatio*100) }, true},
+	{"balance", "Balance", func(m *minerComparison) float64 { return toFloat(m.Balance.Atto()) },
+		func(m *minerComparison) string { return m.Balance.String() }, true},
+	{"availableBalance", "Available Balance", func(m *minerComparison) float64 { return toFloat(m.AvailableBalance.Atto()) },
+		func(m *minerComparison) string { return m.AvailableBalance.String() }, true},
+	{"vestingFunds", "Vesting Funds", func(m *minerComparison) float64 { return toFloat(m.VestingFunds.Atto()) },
+		func(m *minerComparison) string { return m.VestingFunds.String() }, true},
+	{"initialPledge", "Initial Pledge", func(m *minerComparison) float64 { return toFloat(m.InitialPledge.Atto()) },
+		func(m *minerComparison) string { return m.InitialPledge.String() }, true},
+	{"pledgePerTiB", "Pledge per QA TiB", func(m *minerComparison) float64 { return toFloat(m.PledgePerTiB.Atto()) },
+		func(m *minerComparison) string { return m.PledgePerTiB.String() }, true},
+	{"workerBalance", "Worker Balance", func(m *minerComparison) float64 { return toFloat(m.WorkerBalance.Atto()) },
+		func(m *minerComparison) string { return m.WorkerBalance.String() }, true},
+	{"qualityAdjPowerRank", "QA Power Rank", func(m *minerComparison) float64 { return float64(m.QualityAdjPowerRank) },
+		func(m *minerComparison) string { return rankOrDash(m.QualityAdjPowerRank) }, false},
+}
+
+// NewCompareCmd creates a new compare command
+func NewCompareCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "compare [miner_id...]",
+		Short: "Compare several miners side by side",
+		Long: `Fetch the information of several Filecoin storage providers concurrently and show them
+side by side, including:
+- Raw and quality adjusted power and network share
+- Live, active, faulty and recovering sectors and the fault ratio
+- Balances, vesting funds, initial pledge and pledge per TiB of QA power
+- Network power rank, from the cached ranking
+
+With three or more miners, values more than 50% away from the median of the compared
+miners are marked as outliers. JSON output is keyed by miner ID.
+
+Examples:
+  # Compare a fleet of miners
+  thctl fil miner compare f01234 f05678 f09012
+
+  # Export the comparison as JSON
+  thctl fil miner compare f01234 f05678 -o json`,
+		Args: cobra.MatchAll(cobra.MinimumNArgs(2), address.MinersArgs(0)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			minerIDs := unique(args)
+
+			client, err := lotus.NewFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create Lotus client: %v", err)
+			}
+
+			infos, err := client.GetMinerInfos(cmd.Context(), minerIDs)
+			if err != nil {
+				return err
+			}
+
+			ranking, err := lotus.LoadPowerRanking(client.CacheStore(cmd.Context(), "network"))
+			if err != nil {
+				return fmt.Errorf("failed to load power ranking: %w", err)
+			}
+
+			comparisons := make([]*minerComparison, len(infos))
+			for i, info := range infos {
+				info.ApplyRanking(ranking)
+				comparisons[i] = newComparison(info)
+			}
+			markOutliers(comparisons)
+
+			format, _ := cmd.Flags().GetString("output")
+			switch format {
+			case "json", "yaml":
+				byMiner := make(map[string]*minerComparison, len(minerIDs))
+				for i, minerID := range minerIDs {
+					byMiner[minerID] = comparisons[i]
+				}
+				resp := &lotus.Response{
+					Version:   "1.0",
+					Timestamp: time.Now().Unix(),
+					Status:    "success",
+					Data:      byMiner,
+					Endpoints: client.ServedBy(),
+				}
+				if format == "json" {
+					return output.JSON(resp)
+				}
+				return output.YAML(resp)
+			case "table":
+				printCompareTable(minerIDs, comparisons)
+			default:
+				return fmt.Errorf("unsupported output format: %s", format)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("output", "o", "table", "Output format: json, yaml, or table")
+
+	return cmd
+}
+
+// newComparison derives the compared values of a miner from its information
+func newComparison(info *lotus.MinerInfo) *minerComparison {
+	m := &minerComparison{
+		RawBytePower:        info.Miner.RawBytePower,
+		QualityAdjPower:     info.Miner.QualityAdjPower,
+		LiveSectors:         info.Miner.Sectors.Live,
+		ActiveSectors:       info.Miner.Sectors.Active,
+		FaultySectors:       info.Miner.Sectors.Faulty,
+		RecoveringSectors:   info.Miner.Sectors.Recovering,
+		Balance:             info.Balance,
+		AvailableBalance:    info.Miner.AvailableBalance,
+		VestingFunds:        info.Miner.VestingFunds,
+		InitialPledge:       info.Miner.InitialPledgeRequirement,
+		WorkerBalance:       info.Miner.Worker.Balance,
+		QualityAdjPowerRank: info.Miner.QualityAdjPowerRank,
+	}
+
+	if network := info.Miner.NetworkQualityAdjPower.Int(); network.Sign() > 0 {
+		m.NetworkShare, _ = new(big.Rat).SetFrac(m.QualityAdjPower.Int(), network).Float64()
+	}
+	if m.LiveSectors > 0 {
+		m.FaultRatio = float64(m.FaultySectors) / float64(m.LiveSectors)
+	}
+	if qap := m.QualityAdjPower.Int(); qap.Sign() > 0 {
+		pledge := new(big.Int).Mul(m.InitialPledge.Atto(), big.NewInt(tebibyte))
+		m.PledgePerTiB = units.NewFIL(pledge.Div(pledge, qap))
+	}
+	return m
+}
+
+// markOutliers records the fields of each miner far from the median of all miners
+func markOutliers(comparisons []*minerComparison) {
+	if len(comparisons) < minOutlierMiners {
+		return
+	}
+	for _, row := range compareRows {
+		if !row.outlier {
+			continue
+		}
+		values := make([]float64, len(comparisons))
+		for i, m := range comparisons {
+			values[i] = row.value(m)
+		}
+		median := medianOf(values)
+		for i, m := range comparisons {
+			if isOutlier(values[i], median) {
+				m.Outliers = append(m.Outliers, row.key)
+			}
+		}
+	}
+}
+
+// isOutlier reports whether v is more than outlierDeviation away from the median, or
+// non-zero when the median is zero
+func isOutlier(v, median float64) bool {
+	if median == 0 {
+		return v != 0
+	}
+	return math.Abs(v-median) > outlierDeviation*math.Abs(median)
+}
+
+// medianOf returns the median of the values
+func medianOf(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+func printCompareTable(minerIDs []string, comparisons []*minerComparison) {
+	fmt.Printf("\n⚖️  Comparison of %d miners\n", len(minerIDs))
+
+	t := table.NewWriter()
+	header := table.Row{"Metric"}
+	for _, minerID := range minerIDs {
+		header = append(header, minerID)
+	}
+	t.AppendHeader(header)
+
+	outliers := 0
+	for _, row := range compareRows {
+		cells := table.Row{row.name}
+		for _, m := range comparisons {
+			cell := row.format(m)
+			if contains(m.Outliers, row.key) {
+				cell += " ⚠️"
+				outliers++
+			}
+			cells = append(cells, cell)
+		}
+		t.AppendRow(cells)
+	}
+	fmt.Println(t.Render())
+
+	if outliers > 0 {
+		fmt.Println("\n⚠️ Marked values are more than 50% away from the median of the compared miners")
+	}
+}
+
+// unique returns the miner IDs without duplicates, keeping their order
+func unique(minerIDs []string) []string {
+	seen := make(map[string]bool, len(minerIDs))
+	result := make([]string, 0, len(minerIDs))
+	for _, minerID := range minerIDs {
+		if !seen[minerID] {
+			seen[minerID] = true
+			result = append(result, minerID)
+		}
+	}
+	return result
+}
+
+// contains reports whether keys holds key
+func contains(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// toFloat converts a big integer to a float for comparing magnitudes
+func toFloat(v *big.Int) float64 {
+	f, _ := new(big.Float).SetInt(v).Float64()
+	return f
+}
+
+// rankOrDash formats a rank, "-" when it is unknown
+func rankOrDash(rank uint64) string {
+	if rank == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("#%d", rank)
+}
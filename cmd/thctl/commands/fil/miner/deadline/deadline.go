@@ -1,84 +1,139 @@
 package deadline
 
 import (
-	"context"
 	"fmt"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/internal/address"
 	"github.com/THCloudAI/thctl/internal/lotus"
 	"github.com/THCloudAI/thctl/pkg/framework/output"
 )
 
 // NewDeadlineCmd creates a new deadline command
 func NewDeadlineCmd() *cobra.Command {
+	var (
+		scanMessages bool
+		partitions   bool
+	)
+
 	cmd := &cobra.Command{
 		Use:   "deadline [miner_id]",
 		Short: "Get miner deadline information",
 		Long: `Get detailed deadline information about a Filecoin storage provider (miner), including:
 - Current proving period
 - Current deadline
-- Sectors due for proving
-- Upcoming deadlines
-- Proving window details`,
-		Args: cobra.ExactArgs(1),
+- Live, active, faulty, recovering and skipped sectors per deadline and partition
+- Time until each proving window opens
+- Whether the last WindowPoSt of each deadline was submitted
+
+Skipped sectors and the epoch of the last PoSt are taken from the miner's
+SubmitWindowedPoSt messages, which are only scanned with --messages.`,
+		Args: cobra.MatchAll(cobra.ExactArgs(1), address.MinerArgs(0)),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			minerID := args[0]
-			
+
 			// Create Lotus client
-			client := lotus.NewClient(lotus.Config{})
-			ctx := context.Background()
-			
-			// Get proving deadline info
-			deadline, err := client.GetMinerProvingDeadline(ctx, minerID)
+			client, err := lotus.NewFromEnv()
 			if err != nil {
-				return fmt.Errorf("failed to get proving deadline: %w", err)
+				return fmt.Errorf("failed to create Lotus client: %v", err)
 			}
-			
-			// Get deadlines info
-			deadlines, err := client.GetMinerDeadlines(ctx, minerID)
+
+			schedule, err := client.GetDeadlineSchedule(cmd.Context(), minerID, scanMessages)
 			if err != nil {
 				return fmt.Errorf("failed to get deadlines: %w", err)
 			}
-			
-			// Combine information
-			info := map[string]interface{}{
-				"MinerID":         minerID,
-				"ProvingDeadline": deadline,
-				"Deadlines":       deadlines,
-			}
-			
+
 			// Format output based on the selected format
 			format, _ := cmd.Flags().GetString("output")
 			switch format {
 			case "json":
-				return output.JSON(info)
+				return output.JSON(schedule)
 			case "yaml":
-				return output.YAML(info)
+				return output.YAML(schedule)
 			default:
-				fmt.Printf("Miner Deadline Information for %s:\n\n", minerID)
-				
-				if deadline != nil {
-					fmt.Println("Current Proving Period:")
-					if epoch, ok := deadline["CurrentEpoch"].(float64); ok {
-						fmt.Printf("  Current Epoch: %d\n", int64(epoch))
-					}
-					if index, ok := deadline["Index"].(float64); ok {
-						fmt.Printf("  Deadline Index: %d\n", int64(index))
-					}
-					// Add more deadline details here
-				}
-				
-				fmt.Println("\nDeadline Schedule:")
-				if deadlines != nil {
-					// Format and display deadline schedule
-					// This would need to be implemented based on the actual data structure
-				}
+				printDeadlineTable(schedule, partitions)
 			}
-			
+
 			return nil
 		},
 	}
-	
+
+	cmd.Flags().BoolVar(&scanMessages, "messages", false, "Scan the last proving period's WindowPoSt messages for skipped sectors")
+	cmd.Flags().BoolVar(&partitions, "partitions", false, "Show every partition in table output")
 	cmd.Flags().StringP("output", "o", "table", "Output format: json, yaml, or table")
-	
+
 	return cmd
 }
+
+func printDeadlineTable(schedule *lotus.DeadlineSchedule, partitions bool) {
+	fmt.Printf("Miner Deadline Information for %s:\n\n", schedule.MinerID)
+
+	fmt.Println("Current Proving Period:")
+	fmt.Printf("  Current Epoch: %d\n", schedule.CurrentEpoch)
+	fmt.Printf("  Period Start: %d\n", schedule.PeriodStart)
+	fmt.Printf("  Deadline Index: %d\n", schedule.CurrentDeadline)
+
+	fmt.Println("\nDeadline Schedule:")
+	t := table.NewWriter()
+	header := table.Row{"Deadline", "Opens", "Partitions", "Live", "Active", "Faulty", "Recovering", "Skipped", "Last PoSt"}
+	if partitions {
+		header = append(table.Row{"Partition"}, header[1:]...)
+		header = append(table.Row{"Deadline"}, header...)
+	}
+	t.AppendHeader(header)
+
+	for _, dl := range schedule.Deadlines {
+		skipped := "-"
+		if schedule.MessagesScanned {
+			skipped = fmt.Sprintf("%d", dl.Skipped)
+		}
+		lastPoSt := dl.PoStStatus
+		if dl.LastPoStEpoch > 0 {
+			lastPoSt = fmt.Sprintf("%s @%d", dl.PoStStatus, dl.LastPoStEpoch)
+		}
+
+		row := table.Row{
+			dl.Index,
+			formatOpens(dl),
+			fmt.Sprintf("%d/%d", dl.ProvenPartitions, len(dl.Partitions)),
+			dl.Live, dl.Active, dl.Faulty, dl.Recovering, skipped, lastPoSt,
+		}
+		if partitions {
+			row = append(table.Row{dl.Index, "all"}, row[1:]...)
+		}
+		t.AppendRow(row)
+
+		if !partitions {
+			continue
+		}
+		for _, p := range dl.Partitions {
+			pSkipped := "-"
+			if schedule.MessagesScanned {
+				pSkipped = fmt.Sprintf("%d", p.Skipped)
+			}
+			proven := ""
+			if p.Proven {
+				proven = "proven"
+			}
+			t.AppendRow(table.Row{"", p.Index, "", "", p.Live, p.Active, p.Faulty, p.Recovering, pSkipped, proven})
+		}
+	}
+	fmt.Println(t.Render())
+
+	if len(schedule.Warnings) > 0 {
+		fmt.Println("\n⚠️ Warnings:")
+		for _, warning := range schedule.Warnings {
+			fmt.Printf("  - %s\n", warning)
+		}
+	}
+}
+
+// formatOpens describes when a deadline's proving window opens
+func formatOpens(dl lotus.DeadlineStatus) string {
+	if dl.IsOpen {
+		return "open now"
+	}
+	return fmt.Sprintf("in %s (epoch %d)", time.Duration(dl.OpensInSeconds)*time.Second, dl.Open)
+}
@@ -0,0 +1,160 @@
+package deals
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/internal/address"
+	"github.com/THCloudAI/thctl/internal/lotus"
+	"github.com/THCloudAI/thctl/pkg/framework/output"
+)
+
+// NewDealsCmd creates a new deals command
+func NewDealsCmd() *cobra.Command {
+	var (
+		expiringDays int64
+		scanMarket   bool
+		all          bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "deals [miner_id]",
+		Short: "List the market deals of a miner and its clients' DataCap",
+		Long: `List the market deals of a Filecoin storage provider, including:
+- Active, expiring and pending deals
+- Verified and unverified bytes of the active deals
+- Deal count, bytes and storage fees per client
+- The remaining DataCap of each verified client
+
+Deals are found through the deal IDs of the miner's sectors. Sectors activated with
+ProveCommitSectors3 don't list their deals; use --scan-market to read every deal of
+the market actor instead, which downloads the whole deal table on mainnet.
+
+Table output lists the expiring and pending deals, or every deal with --all.
+
+Examples:
+  # Show deals and clients, with deals ending in the next 30 days as expiring
+  thctl fil miner deals f01234
+
+  # Find deals of sectors that don't list them and export them as JSON
+  thctl fil miner deals f01234 --scan-market -o json`,
+		Args: cobra.MatchAll(cobra.ExactArgs(1), address.MinerArgs(0)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := lotus.NewFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create Lotus client: %v", err)
+			}
+
+			network, err := client.Network(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			deals, err := client.GetMinerDeals(cmd.Context(), args[0], expiringDays*network.EpochsPerDay(), scanMarket)
+			if err != nil {
+				return fmt.Errorf("failed to get miner deals: %w", err)
+			}
+
+			resp := &lotus.Response{
+				Version:   "1.0",
+				Timestamp: time.Now().Unix(),
+				Status:    "success",
+				Data:      deals,
+				Endpoints: client.ServedBy(),
+			}
+
+			format, _ := cmd.Flags().GetString("output")
+			switch format {
+			case "json":
+				return output.JSON(resp)
+			case "yaml":
+				return output.YAML(resp)
+			case "table":
+				printDealsTable(deals, all)
+			default:
+				return fmt.Errorf("unsupported output format: %s", format)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().Int64Var(&expiringDays, "expiring-days", 30, "Report deals ending within this many days as expiring")
+	cmd.Flags().BoolVar(&scanMarket, "scan-market", false, "Find deals in the whole market deal table instead of the miner's sectors")
+	cmd.Flags().BoolVar(&all, "all", false, "List every deal in table output")
+	cmd.Flags().StringP("output", "o", "table", "Output format: json, yaml, or table")
+
+	return cmd
+}
+
+func printDealsTable(deals *lotus.MinerDeals, all bool) {
+	fmt.Printf("\n🤝 Market Deals of %s at height %d\n", deals.MinerID, deals.Height)
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Attribute", "Value"})
+	t.AppendRow(table.Row{"Active Deals", deals.ActiveDeals})
+	t.AppendRow(table.Row{"Expiring Deals", fmt.Sprintf("%d (before epoch %d)", deals.ExpiringDeals, deals.ExpiringBefore)})
+	t.AppendRow(table.Row{"Pending Deals", deals.PendingDeals})
+	t.AppendRow(table.Row{"Verified Bytes", deals.VerifiedBytes.String()})
+	t.AppendRow(table.Row{"Unverified Bytes", deals.UnverifiedBytes.String()})
+	fmt.Println(t.Render())
+
+	if len(deals.Clients) > 0 {
+		fmt.Println("\n👥 Clients:")
+		t = table.NewWriter()
+		t.AppendHeader(table.Row{"Client", "Deals", "Bytes", "Verified Bytes", "Storage Fees", "DataCap"})
+		for _, client := range deals.Clients {
+			dataCap := "-"
+			if client.DataCap != nil {
+				dataCap = client.DataCap.String()
+			}
+			t.AppendRow(table.Row{
+				client.Client,
+				client.Deals,
+				client.Bytes.String(),
+				client.VerifiedBytes.String(),
+				client.StorageFees.String(),
+				dataCap,
+			})
+		}
+		fmt.Println(t.Render())
+	}
+
+	listed := make([]lotus.MarketDeal, 0)
+	for _, deal := range deals.Deals {
+		if all || deal.Status == lotus.DealStatusExpiring || deal.Status == lotus.DealStatusPending {
+			listed = append(listed, deal)
+		}
+	}
+	if len(listed) == 0 {
+		if len(deals.Deals) == 0 && !deals.MarketScanned {
+			fmt.Println("\n📭 No deals found in the miner's sectors; try --scan-market")
+		}
+		return
+	}
+	sort.SliceStable(listed, func(i, j int) bool { return listed[i].EndEpoch < listed[j].EndEpoch })
+
+	fmt.Println("\n📦 Deals:")
+	t = table.NewWriter()
+	t.AppendHeader(table.Row{"Deal ID", "Sector", "Client", "Piece Size", "Verified", "Start", "End", "Ends At", "Status"})
+	for _, deal := range listed {
+		sector := "-"
+		if deal.SectorNumber != 0 {
+			sector = fmt.Sprintf("%d", deal.SectorNumber)
+		}
+		t.AppendRow(table.Row{
+			deal.DealID,
+			sector,
+			deal.Client,
+			deal.PieceSize.String(),
+			deal.Verified,
+			deal.StartEpoch,
+			deal.EndEpoch,
+			time.Unix(deal.EndTimestamp, 0).UTC().Format(time.RFC3339),
+			deal.Status,
+		})
+	}
+	fmt.Println(t.Render())
+}
@@ -0,0 +1,321 @@
+package history
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/internal/address"
+	"github.com/THCloudAI/thctl/internal/history"
+	"github.com/THCloudAI/thctl/internal/lotus"
+	"github.com/THCloudAI/thctl/pkg/framework/output"
+)
+
+// sparkBars are the bars of the trend sparklines, lowest first
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// maxSparkWidth is the number of bars a sparkline is reduced to
+const maxSparkWidth = 40
+
+// defaultColumns are the metrics listed per snapshot in table output
+var defaultColumns = []string{"rawBytePower", "qualityAdjPower", "balance", "availableBalance", "initialPledge", "liveSectors", "faultySectors"}
+
+// minerHistory is the metric history returned in JSON and YAML output
+type minerHistory struct {
+	MinerID string          `json:"minerId"`
+	Since   int64           `json:"since"`
+	Points  []history.Point `json:"points"`
+}
+
+// NewHistoryCmd creates a new history command
+func NewHistoryCmd() *cobra.Command {
+	var (
+		since   string
+		columns []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "history [miner_id]",
+		Short: "Show the power, balances and sectors of a miner over time",
+		Long: `Show the snapshots of a Filecoin storage provider kept in the local history, including:
+- A trend line with the first and last value of each metric
+- Power, balances and sector counts of every snapshot
+
+Snapshots are saved by 'thctl fil miner <miner_id> --record', or by every
+'thctl fil miner' run when THCTL_HISTORY=true is set. They are kept in
+history.db under the thctl config directory; no Lotus node is needed to read them.
+Snapshots are kept under the miner's ID, so its ID and robust address show the same history.
+
+Metrics: ` + metricKeys() + `
+
+Examples:
+  # Trends over the last 30 days
+  thctl fil miner history f01234
+
+  # Balances over the last week
+  thctl fil miner history f01234 --since 7d --metrics balance,availableBalance,vestingFunds`,
+		Args: cobra.MatchAll(cobra.ExactArgs(1), address.MinerArgs(0)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			minerID := args[0]
+
+			age, err := history.ParseAge(since)
+			if err != nil {
+				return err
+			}
+			metrics, err := selectMetrics(columns)
+			if err != nil {
+				return err
+			}
+
+			store, err := history.Open()
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			now := time.Now()
+			snapshots, err := store.List(minerID, now.Add(-age), now)
+			if err != nil {
+				return fmt.Errorf("failed to read miner history: %w", err)
+			}
+
+			format, _ := cmd.Flags().GetString("output")
+			switch format {
+			case "json", "yaml":
+				resp := &lotus.Response{
+					Version:   "1.0",
+					Timestamp: now.Unix(),
+					Status:    "success",
+					Data: &minerHistory{
+						MinerID: minerID,
+						Since:   now.Add(-age).Unix(),
+						Points:  history.Points(snapshots),
+					},
+				}
+				if format == "json" {
+					return output.JSON(resp)
+				}
+				return output.YAML(resp)
+			case "table":
+				if len(snapshots) == 0 {
+					fmt.Printf("\n📭 No snapshots of %s in the last %s; record some with 'thctl fil miner %s --record'\n", minerID, since, minerID)
+					return nil
+				}
+				printHistoryTable(minerID, snapshots, metrics)
+			default:
+				return fmt.Errorf("unsupported output format: %s", format)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "30d", "How far back to look, such as 12h, 7d or 2w")
+	cmd.Flags().StringSliceVar(&columns, "metrics", defaultColumns, "Metrics listed per snapshot in table output")
+	cmd.Flags().StringP("output", "o", "table", "Output format: json, yaml, or table")
+
+	return cmd
+}
+
+// NewDiffCmd creates a new diff command
+func NewDiffCmd() *cobra.Command {
+	var since string
+
+	cmd := &cobra.Command{
+		Use:   "diff [miner_id]",
+		Short: "Show what changed for a miner between two history snapshots",
+		Long: `Compare the latest snapshot of a Filecoin storage provider in the local history with
+the last one taken before --since, and list the power, balances, sector counts and
+addresses that changed. When there is no snapshot that old, the oldest one is used.
+
+Examples:
+  # Changes over the last week
+  thctl fil miner diff f01234 --since 7d
+
+  # Changes since yesterday as JSON
+  thctl fil miner diff f01234 --since 24h -o json`,
+		Args: cobra.MatchAll(cobra.ExactArgs(1), address.MinerArgs(0)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			minerID := args[0]
+
+			age, err := history.ParseAge(since)
+			if err != nil {
+				return err
+			}
+
+			store, err := history.Open()
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			to, err := store.Latest(minerID)
+			if err != nil {
+				return fmt.Errorf("failed to read miner history: %w", err)
+			}
+			from, err := store.At(minerID, time.Now().Add(-age))
+			if err != nil {
+				return fmt.Errorf("failed to read miner history: %w", err)
+			}
+			if to == nil || from.Timestamp == to.Timestamp {
+				return fmt.Errorf("at least two snapshots of %s are needed; record them with 'thctl fil miner %s --record'", minerID, minerID)
+			}
+
+			diff := history.Compare(minerID, from, to)
+
+			resp := &lotus.Response{
+				Version:   "1.0",
+				Timestamp: time.Now().Unix(),
+				Status:    "success",
+				Data:      diff,
+			}
+
+			format, _ := cmd.Flags().GetString("output")
+			switch format {
+			case "json":
+				return output.JSON(resp)
+			case "yaml":
+				return output.YAML(resp)
+			case "table":
+				printDiffTable(diff)
+			default:
+				return fmt.Errorf("unsupported output format: %s", format)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "7d", "Compare with the last snapshot before this long ago, such as 24h, 7d or 2w")
+	cmd.Flags().StringP("output", "o", "table", "Output format: json, yaml, or table")
+
+	return cmd
+}
+
+func printHistoryTable(minerID string, snapshots []history.Snapshot, metrics []history.Metric) {
+	first, last := &snapshots[0], &snapshots[len(snapshots)-1]
+	fmt.Printf("\n📈 Trends of %s from %s to %s (%d snapshots)\n",
+		minerID, formatTime(first.Timestamp), formatTime(last.Timestamp), len(snapshots))
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Metric", "First", "Last", "Change", "Trend"})
+	for _, m := range history.Metrics {
+		values := make([]*big.Int, len(snapshots))
+		for i := range snapshots {
+			values[i] = m.Value(&snapshots[i])
+		}
+		change := new(big.Int).Sub(values[len(values)-1], values[0])
+		sign := ""
+		if change.Sign() > 0 {
+			sign = "+"
+		}
+		t.AppendRow(table.Row{m.Name, m.Format(values[0]), m.Format(values[len(values)-1]), sign + m.Format(change), sparkline(values)})
+	}
+	fmt.Println(t.Render())
+
+	fmt.Println("\n🗂️  Snapshots:")
+	t = table.NewWriter()
+	header := table.Row{"Time"}
+	for _, m := range metrics {
+		header = append(header, m.Name)
+	}
+	t.AppendHeader(header)
+	for i := range snapshots {
+		row := table.Row{formatTime(snapshots[i].Timestamp)}
+		for _, m := range metrics {
+			row = append(row, m.Format(m.Value(&snapshots[i])))
+		}
+		t.AppendRow(row)
+	}
+	fmt.Println(t.Render())
+}
+
+func printDiffTable(diff *history.Diff) {
+	fmt.Printf("\n🔀 Changes of %s from %s to %s\n", diff.MinerID, formatTime(diff.From), formatTime(diff.To))
+	if len(diff.Changes) == 0 {
+		fmt.Println("✅ Nothing changed")
+		return
+	}
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Field", "Before", "After", "Change"})
+	for _, change := range diff.Changes {
+		before, after, delta := change.Format()
+		t.AppendRow(table.Row{change.Field, valueOrDash(before), valueOrDash(after), delta})
+	}
+	fmt.Println(t.Render())
+}
+
+// selectMetrics returns the metrics with the given keys
+func selectMetrics(keys []string) ([]history.Metric, error) {
+	metrics := make([]history.Metric, 0, len(keys))
+	for _, key := range keys {
+		found := false
+		for _, m := range history.Metrics {
+			if strings.EqualFold(m.Key, key) {
+				metrics = append(metrics, m)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown metric %q, expected one of: %s", key, metricKeys())
+		}
+	}
+	return metrics, nil
+}
+
+// metricKeys lists the keys of the tracked metrics
+func metricKeys() string {
+	keys := make([]string, 0, len(history.Metrics))
+	for _, m := range history.Metrics {
+		keys = append(keys, m.Key)
+	}
+	return strings.Join(keys, ", ")
+}
+
+// sparkline draws the values as bars scaled between their minimum and maximum, averaging
+// neighbouring values when there are more than maxSparkWidth
+func sparkline(values []*big.Int) string {
+	width := len(values)
+	if width > maxSparkWidth {
+		width = maxSparkWidth
+	}
+	points := make([]float64, width)
+	for i := range points {
+		start, end := i*len(values)/width, (i+1)*len(values)/width
+		sum := new(big.Float)
+		for _, v := range values[start:end] {
+			sum.Add(sum, new(big.Float).SetInt(v))
+		}
+		points[i], _ = new(big.Float).Quo(sum, big.NewFloat(float64(end-start))).Float64()
+	}
+
+	lo, hi := points[0], points[0]
+	for _, p := range points {
+		lo, hi = min(lo, p), max(hi, p)
+	}
+	var b strings.Builder
+	for _, p := range points {
+		bar := 0
+		if hi > lo {
+			bar = int((p - lo) / (hi - lo) * float64(len(sparkBars)-1))
+		}
+		b.WriteRune(sparkBars[bar])
+	}
+	return b.String()
+}
+
+// formatTime formats a unix timestamp in local time
+func formatTime(ts int64) string {
+	return time.Unix(ts, 0).Format("2006-01-02 15:04")
+}
+
+// valueOrDash returns the value or "-" when it is empty
+func valueOrDash(v string) string {
+	if v == "" {
+		return "-"
+	}
+	return v
+}
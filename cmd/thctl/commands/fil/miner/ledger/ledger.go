@@ -0,0 +1,262 @@
+package ledger
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/internal/address"
+	"github.com/THCloudAI/thctl/internal/cache"
+	"github.com/THCloudAI/thctl/internal/lotus"
+	"github.com/THCloudAI/thctl/internal/units"
+	"github.com/THCloudAI/thctl/pkg/framework/output"
+)
+
+// NewLedgerCmd creates a new ledger command
+func NewLedgerCmd() *cobra.Command {
+	var (
+		from    string
+		to      string
+		daily   bool
+		noCache bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "ledger [miner_id]",
+		Short: "Export the daily fund flows of a miner",
+		Long: `Walk the chain messages and receipts of a Filecoin storage provider and its owner,
+worker and control addresses, and classify its fund flows per UTC day:
+- Block rewards, deposits and withdrawals
+- Vesting releases and pledge locks and unlocks
+- Penalties and fault fees
+- Gas burned and tips paid by the owner, worker and control addresses
+
+Rewards, deposits, withdrawals and gas come from the chain messages. Penalties and
+fault fees are the funds the miner burned in the execution traces of the messages sent
+to it and of its deadline cron, and vesting releases come from its vesting table.
+Pledge changes are derived from the miner state at the start and end of each day, as
+are the balance changes none of the flows explain, listed as unexplained.
+
+The reconciliation adds the flows read from the chain to the opening balance and vesting
+funds and compares them with the closing ones, which match the fields shown by
+'thctl fil miner'. Its differences are the unexplained balance changes, such as the gas
+rewards of the miner's blocks, and the fees paid from the vesting funds.
+
+CSV output lists the line items, or the daily totals with --daily. Amounts are signed
+and in FIL.
+
+Examples:
+  # Daily totals of the last week
+  thctl fil miner ledger f01234
+
+  # Line items of a month as CSV
+  thctl fil miner ledger f01234 --from 2024-06-01 --to 2024-06-30 -o csv > june.csv
+
+  # Daily totals of a month as CSV
+  thctl fil miner ledger f01234 --from 2024-06-01 --to 2024-06-30 -o csv --daily`,
+		Args: cobra.MatchAll(cobra.ExactArgs(1), address.MinerArgs(0)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			today := time.Now().UTC().Truncate(24 * time.Hour)
+			fromDate := today.AddDate(0, 0, -7)
+			toDate := today.AddDate(0, 0, -1)
+			var err error
+			if from != "" {
+				if fromDate, err = time.Parse(lotus.LedgerDateFormat, from); err != nil {
+					return fmt.Errorf("invalid --from date %q: expected YYYY-MM-DD", from)
+				}
+			}
+			if to != "" {
+				if toDate, err = time.Parse(lotus.LedgerDateFormat, to); err != nil {
+					return fmt.Errorf("invalid --to date %q: expected YYYY-MM-DD", to)
+				}
+			}
+
+			client, err := lotus.NewFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create Lotus client: %v", err)
+			}
+
+			var store *cache.Store
+			if !noCache {
+				store = client.CacheStore(cmd.Context(), "chain")
+			}
+
+			ledger, err := client.GetMinerLedger(cmd.Context(), args[0], fromDate, toDate, store)
+			if err != nil {
+				return fmt.Errorf("failed to get miner ledger: %w", err)
+			}
+
+			resp := &lotus.Response{
+				Version:   "1.0",
+				Timestamp: time.Now().Unix(),
+				Status:    "success",
+				Data:      ledger,
+				Endpoints: client.ServedBy(),
+			}
+
+			format, _ := cmd.Flags().GetString("output")
+			switch format {
+			case "json":
+				return output.JSON(resp)
+			case "yaml":
+				return output.YAML(resp)
+			case "csv":
+				if daily {
+					return writeDaysCSV(ledger)
+				}
+				return writeEntriesCSV(ledger)
+			case "table":
+				printLedgerTable(ledger)
+			default:
+				return fmt.Errorf("unsupported output format: %s", format)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "First UTC day of the ledger, YYYY-MM-DD (default: 7 days ago)")
+	cmd.Flags().StringVar(&to, "to", "", "Last UTC day of the ledger, YYYY-MM-DD (default: yesterday)")
+	cmd.Flags().BoolVar(&daily, "daily", false, "Export the daily totals instead of the line items in CSV output")
+	cmd.Flags().BoolVar(&noCache, "no-cache", false, "Do not read or write the local chain cache")
+	cmd.Flags().StringP("output", "o", "table", "Output format: json, yaml, csv, or table")
+
+	return cmd
+}
+
+// writeEntriesCSV writes the line items of the ledger to stdout
+func writeEntriesCSV(ledger *lotus.MinerLedger) error {
+	w := csv.NewWriter(os.Stdout)
+	if err := w.Write([]string{"date", "height", "timestamp", "account", "address", "category", "amount", "cid", "derived"}); err != nil {
+		return err
+	}
+	for _, entry := range ledger.Entries {
+		if err := w.Write([]string{
+			entry.Date,
+			strconv.FormatInt(entry.Height, 10),
+			time.Unix(entry.Timestamp, 0).UTC().Format(time.RFC3339),
+			entry.Account,
+			entry.Address,
+			entry.Category,
+			exactFIL(entry.Amount),
+			entry.Cid,
+			strconv.FormatBool(entry.Derived),
+		}); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// writeDaysCSV writes the daily totals and miner funds of the ledger to stdout
+func writeDaysCSV(ledger *lotus.MinerLedger) error {
+	w := csv.NewWriter(os.Stdout)
+	header := []string{"date", "start_height", "end_height"}
+	header = append(header, lotus.LedgerCategories...)
+	header = append(header,
+		"opening_balance", "closing_balance",
+		"opening_vesting_funds", "closing_vesting_funds",
+		"opening_initial_pledge", "closing_initial_pledge",
+	)
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, day := range ledger.Days {
+		row := []string{day.Date, strconv.FormatInt(day.StartHeight, 10), strconv.FormatInt(day.EndHeight, 10)}
+		for _, category := range lotus.LedgerCategories {
+			row = append(row, exactFIL(day.Totals[category]))
+		}
+		row = append(row,
+			exactFIL(day.Opening.Balance), exactFIL(day.Closing.Balance),
+			exactFIL(day.Opening.VestingFunds), exactFIL(day.Closing.VestingFunds),
+			exactFIL(day.Opening.InitialPledge), exactFIL(day.Closing.InitialPledge),
+		)
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// exactFIL formats an amount in FIL without rounding
+func exactFIL(f units.FIL) string {
+	return new(big.Rat).SetFrac(f.Atto(), big.NewInt(1e18)).FloatString(18)
+}
+
+func printLedgerTable(ledger *lotus.MinerLedger) {
+	fmt.Printf("\n📒 Ledger of %s from %s to %s (epochs %d to %d)\n",
+		ledger.MinerID, ledger.From, ledger.To, ledger.FromHeight, ledger.ToHeight)
+
+	addrs := make([]string, 0, len(ledger.Accounts))
+	for addr := range ledger.Accounts {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Account", "Address"})
+	for _, addr := range addrs {
+		t.AppendRow(table.Row{ledger.Accounts[addr], addr})
+	}
+	fmt.Println(t.Render())
+
+	fmt.Println("\n📅 Daily Totals:")
+	t = table.NewWriter()
+	t.AppendHeader(table.Row{"Date", "Rewards", "Deposits", "Withdrawals", "Vesting Release", "Pledge", "Fees", "Unexplained", "Gas", "Closing Balance"})
+	for _, day := range ledger.Days {
+		t.AppendRow(table.Row{
+			day.Date,
+			day.Totals[lotus.LedgerBlockReward].String(),
+			day.Totals[lotus.LedgerDeposit].String(),
+			day.Totals[lotus.LedgerWithdrawal].String(),
+			day.Totals[lotus.LedgerVestingRelease].String(),
+			sum(day.Totals, lotus.LedgerPledgeLock, lotus.LedgerPledgeUnlock).String(),
+			sum(day.Totals, lotus.LedgerPenalty, lotus.LedgerFaultFee).String(),
+			day.Totals[lotus.LedgerUnexplained].String(),
+			formatSmall(sum(day.Totals, lotus.LedgerGasBurn, lotus.LedgerGasTip)),
+			day.Closing.Balance.String(),
+		})
+	}
+	fmt.Println(t.Render())
+
+	fmt.Println("\n💰 Totals:")
+	t = table.NewWriter()
+	t.AppendHeader(table.Row{"Category", "Amount"})
+	for _, category := range lotus.LedgerCategories {
+		if amount, ok := ledger.Totals[category]; ok {
+			t.AppendRow(table.Row{category, formatSmall(amount)})
+		}
+	}
+	fmt.Println(t.Render())
+
+	fmt.Println("\n⚖️  Reconciliation:")
+	t = table.NewWriter()
+	t.AppendHeader(table.Row{"Field", "Opening", "Flows", "Closing", "Difference"})
+	for _, r := range ledger.Reconciliation {
+		t.AppendRow(table.Row{r.Field, r.Opening.String(), r.Flows.String(), r.Closing.String(), formatSmall(r.Difference)})
+	}
+	fmt.Println(t.Render())
+}
+
+// sum adds up the totals of the given categories
+func sum(totals map[string]units.FIL, categories ...string) units.FIL {
+	var total units.FIL
+	for _, category := range categories {
+		total = total.Add(totals[category])
+	}
+	return total
+}
+
+// formatSmall formats an amount in the largest unit in which it is at least one, so gas
+// costs don't round to zero
+func formatSmall(amount units.FIL) string {
+	display := units.CurrentDisplay()
+	display.AutoFIL = true
+	return display.FIL(amount)
+}
@@ -0,0 +1,62 @@
+package message
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/internal/address"
+	"github.com/THCloudAI/thctl/internal/lotus"
+	"github.com/THCloudAI/thctl/internal/units"
+)
+
+// NewChangeBeneficiaryCmd creates a new change-beneficiary command
+func NewChangeBeneficiaryCmd() *cobra.Command {
+	var (
+		quota      string
+		expiration int64
+		confirm    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "change-beneficiary [miner_id] [beneficiary]",
+		Short: "Build a message changing the miner's beneficiary",
+		Long: `Build an unsigned ChangeBeneficiary message with nonce and gas estimates filled in.
+The owner proposes the new beneficiary with a quota and an expiration epoch, then the new
+beneficiary accepts by sending the same message, built with --confirm. Setting the owner
+back as beneficiary takes effect immediately and needs no quota or expiration.
+
+The message is only written out for offline signing; it is never pushed.
+
+Examples:
+  # Propose a beneficiary with a 1000 FIL quota
+  thctl fil miner change-beneficiary f01234 f1beneficiary... --quota 1000 --expiration 5000000
+
+  # Accept the proposal as the new beneficiary
+  thctl fil miner change-beneficiary f01234 f1beneficiary... --quota 1000 --expiration 5000000 --confirm`,
+		Args: cobra.MatchAll(cobra.ExactArgs(2), address.MinerArgs(0), address.AddressArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			amount, err := units.ParseFIL(quota)
+			if err != nil {
+				return err
+			}
+
+			client, err := lotus.NewFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create Lotus client: %v", err)
+			}
+
+			msg, err := client.BuildChangeBeneficiaryMessage(cmd.Context(), args[0], args[1], amount.Atto(), expiration, confirm)
+			if err != nil {
+				return fmt.Errorf("failed to build beneficiary change message: %w", err)
+			}
+			return WriteMessage(cmd, client, msg)
+		},
+	}
+
+	cmd.Flags().StringVar(&quota, "quota", "0", "Amount of FIL the beneficiary may withdraw")
+	cmd.Flags().Int64Var(&expiration, "expiration", 0, "Epoch at which the beneficiary term expires")
+	cmd.Flags().BoolVar(&confirm, "confirm", false, "Build the acceptance sent by the new beneficiary")
+	AddOutFlag(cmd)
+
+	return cmd
+}
@@ -0,0 +1,46 @@
+package message
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/internal/lotus"
+)
+
+// AddOutFlag adds the flag selecting the file the unsigned message is written to
+func AddOutFlag(cmd *cobra.Command) {
+	cmd.Flags().String("out", "", "Write the unsigned message JSON to this file instead of stdout")
+}
+
+// WriteMessage fills in the nonce and gas estimates of an unsigned message and writes it
+// as JSON to the file given by --out, or to stdout. The message is never pushed; it has
+// to be signed offline and pushed separately.
+func WriteMessage(cmd *cobra.Command, client *lotus.Client, msg *lotus.UnsignedMessage) error {
+	if err := client.PrepareMessage(cmd.Context(), msg); err != nil {
+		return err
+	}
+	return WritePrepared(cmd, msg)
+}
+
+// WritePrepared writes an unsigned message whose nonce and gas are already set, such as
+// a replacement for a pending message, the same way as WriteMessage
+func WritePrepared(cmd *cobra.Command, msg *lotus.UnsignedMessage) error {
+	data, err := json.MarshalIndent(msg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %v", err)
+	}
+
+	out, _ := cmd.Flags().GetString("out")
+	if out == "" {
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if err := os.WriteFile(out, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write message: %v", err)
+	}
+	fmt.Printf("✅ Unsigned message from %s (nonce %d, method %d) written to %s\n", msg.From, msg.Nonce, msg.Method, out)
+	return nil
+}
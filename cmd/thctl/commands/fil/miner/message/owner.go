@@ -0,0 +1,42 @@
+package message
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/internal/address"
+	"github.com/THCloudAI/thctl/internal/lotus"
+)
+
+// NewSetOwnerCmd creates a new set-owner command
+func NewSetOwnerCmd() *cobra.Command {
+	var confirm bool
+
+	cmd := &cobra.Command{
+		Use:   "set-owner [miner_id] [new_owner]",
+		Short: "Build a message changing the miner's owner",
+		Long: `Build an unsigned ChangeOwnerAddress message with nonce and gas estimates filled in.
+Changing the owner takes two messages: the current owner proposes the new owner, then the
+new owner confirms by sending the same message, built with --confirm.
+
+The message is only written out for offline signing; it is never pushed.`,
+		Args: cobra.MatchAll(cobra.ExactArgs(2), address.MinerArgs(0), address.AddressArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := lotus.NewFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create Lotus client: %v", err)
+			}
+
+			msg, err := client.BuildChangeOwnerMessage(cmd.Context(), args[0], args[1], confirm)
+			if err != nil {
+				return fmt.Errorf("failed to build owner change message: %w", err)
+			}
+			return WriteMessage(cmd, client, msg)
+		},
+	}
+
+	cmd.Flags().BoolVar(&confirm, "confirm", false, "Build the confirmation sent by the new owner")
+	AddOutFlag(cmd)
+
+	return cmd
+}
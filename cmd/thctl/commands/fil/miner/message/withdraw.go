@@ -0,0 +1,67 @@
+package message
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/internal/address"
+	"github.com/THCloudAI/thctl/internal/lotus"
+	"github.com/THCloudAI/thctl/internal/units"
+)
+
+// NewWithdrawCmd creates a new withdraw command
+func NewWithdrawCmd() *cobra.Command {
+	var from string
+
+	cmd := &cobra.Command{
+		Use:   "withdraw [miner_id] [amount]",
+		Short: "Build a message withdrawing the miner's available balance",
+		Long: `Build an unsigned WithdrawBalance message with nonce and gas estimates filled in.
+Without an amount, the whole available balance is withdrawn. The message is sent from
+the owner unless --from selects the beneficiary.
+
+The message is only written out for offline signing; it is never pushed.
+
+Examples:
+  # Withdraw everything available
+  thctl fil miner withdraw f01234 --out withdraw.json
+
+  # Withdraw 100 FIL as the beneficiary
+  thctl fil miner withdraw f01234 100 --from f3beneficiary...`,
+		Args: cobra.MatchAll(cobra.RangeArgs(1, 2), address.MinerArgs(0)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			minerID := args[0]
+			if from != "" {
+				if err := address.Validate(from); err != nil {
+					return err
+				}
+			}
+
+			var amount *big.Int
+			if len(args) > 1 {
+				requested, err := units.ParseFIL(args[1])
+				if err != nil {
+					return err
+				}
+				amount = requested.Atto()
+			}
+
+			client, err := lotus.NewFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create Lotus client: %v", err)
+			}
+
+			msg, err := client.BuildWithdrawMessage(cmd.Context(), minerID, amount, from)
+			if err != nil {
+				return fmt.Errorf("failed to build withdraw message: %w", err)
+			}
+			return WriteMessage(cmd, client, msg)
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "Sender address, the owner or the beneficiary (default: owner)")
+	AddOutFlag(cmd)
+
+	return cmd
+}
@@ -0,0 +1,88 @@
+package message
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/internal/address"
+	"github.com/THCloudAI/thctl/internal/lotus"
+)
+
+// NewSetWorkerCmd creates a new set-worker command
+func NewSetWorkerCmd() *cobra.Command {
+	var confirm bool
+
+	cmd := &cobra.Command{
+		Use:   "set-worker [miner_id] [new_worker]",
+		Short: "Build a message changing the miner's worker",
+		Long: `Build an unsigned ChangeWorkerAddress message from the owner with nonce and gas
+estimates filled in. The current control addresses are kept. Once the worker change epoch
+has been reached, the owner confirms the change with a message built with --confirm.
+
+The message is only written out for offline signing; it is never pushed.
+
+Examples:
+  # Propose a new worker
+  thctl fil miner set-worker f01234 f3newworker... --out worker.json
+
+  # Confirm the pending worker change
+  thctl fil miner set-worker f01234 --confirm --out confirm.json`,
+		Args: cobra.MatchAll(cobra.RangeArgs(1, 2), address.MinerArgs(0), address.AddressArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if confirm != (len(args) == 1) {
+				return fmt.Errorf("a new worker address is required, unless confirming with --confirm")
+			}
+
+			client, err := lotus.NewFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create Lotus client: %v", err)
+			}
+
+			var msg *lotus.UnsignedMessage
+			if confirm {
+				msg, err = client.BuildConfirmWorkerMessage(cmd.Context(), args[0])
+			} else {
+				msg, err = client.BuildChangeWorkerMessage(cmd.Context(), args[0], args[1])
+			}
+			if err != nil {
+				return fmt.Errorf("failed to build worker change message: %w", err)
+			}
+			return WriteMessage(cmd, client, msg)
+		},
+	}
+
+	cmd.Flags().BoolVar(&confirm, "confirm", false, "Build the confirmation of the pending worker change")
+	AddOutFlag(cmd)
+
+	return cmd
+}
+
+// NewSetControlCmd creates a new set-control command
+func NewSetControlCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set-control [miner_id] [address...]",
+		Short: "Build a message replacing the miner's control addresses",
+		Long: `Build an unsigned ChangeWorkerAddress message from the owner that keeps the current
+worker and replaces the control addresses with the given list. Passing no addresses
+removes all control addresses.
+
+The message is only written out for offline signing; it is never pushed.`,
+		Args: cobra.MatchAll(cobra.MinimumNArgs(1), address.MinerArgs(0), address.AddressArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := lotus.NewFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create Lotus client: %v", err)
+			}
+
+			msg, err := client.BuildChangeControlMessage(cmd.Context(), args[0], args[1:])
+			if err != nil {
+				return fmt.Errorf("failed to build control address change message: %w", err)
+			}
+			return WriteMessage(cmd, client, msg)
+		},
+	}
+
+	AddOutFlag(cmd)
+
+	return cmd
+}
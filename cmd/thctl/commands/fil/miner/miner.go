@@ -1,6 +1,7 @@
 package miner
 
 import (
+    "context"
     "encoding/json"
     "fmt"
     "math/big"
@@ -8,6 +9,18 @@ import (
     "time"
     "github.com/jedib0t/go-pretty/v6/table"
     "github.com/spf13/cobra"
+    "github.com/THCloudAI/thctl/cmd/thctl/commands/fil/miner/blocks"
+    "github.com/THCloudAI/thctl/cmd/thctl/commands/fil/miner/compare"
+    "github.com/THCloudAI/thctl/cmd/thctl/commands/fil/miner/deadline"
+    "github.com/THCloudAI/thctl/cmd/thctl/commands/fil/miner/deals"
+    "github.com/THCloudAI/thctl/cmd/thctl/commands/fil/miner/history"
+    "github.com/THCloudAI/thctl/cmd/thctl/commands/fil/miner/ledger"
+    "github.com/THCloudAI/thctl/cmd/thctl/commands/fil/miner/message"
+    "github.com/THCloudAI/thctl/cmd/thctl/commands/fil/miner/netcheck"
+    "github.com/THCloudAI/thctl/cmd/thctl/commands/fil/miner/power"
+    "github.com/THCloudAI/thctl/internal/address"
+    "github.com/THCloudAI/thctl/internal/config"
+    historystore "github.com/THCloudAI/thctl/internal/history"
     "github.com/THCloudAI/thctl/internal/lotus"
     "gopkg.in/yaml.v3"
 )
@@ -16,59 +29,120 @@ func NewMinerCmd() *cobra.Command {
     cmd := &cobra.Command{
         Use:   "miner [minerID]",
         Short: "Get miner information",
-        Args:  cobra.ExactArgs(1),
+        Args:  cobra.MatchAll(cobra.ExactArgs(1), address.MinerArgs(0)),
         RunE: func(cmd *cobra.Command, args []string) error {
-            minerID := args[0]
             output, _ := cmd.Flags().GetString("output")
+            rank, _ := cmd.Flags().GetBool("rank")
+            record, _ := cmd.Flags().GetBool("record")
 
             client, err := lotus.NewFromEnv()
             if err != nil {
                 return fmt.Errorf("❌ failed to create Lotus client: %v", err)
             }
 
-            info, err := client.GetComprehensiveMinerInfo(cmd.Context(), minerID)
-            if err != nil {
-                return fmt.Errorf("❌ error getting miner info: %v", err)
-            }
+            return ShowMinerInfo(cmd.Context(), client, args[0], output, rank, record)
+        },
+    }
 
-            // If any required fields are missing, return an error
-            if info.Miner.Owner.Address == "" || info.Miner.Worker.Address == "" {
-                return fmt.Errorf("❌ failed to get required miner information")
-            }
+    cmd.Flags().StringP("output", "o", "json", "Output format: json, yaml, or table")
+    cmd.Flags().Bool("rank", false, "Compute the network power ranking if the cached one is stale")
+    cmd.Flags().Bool("record", false, "Save a snapshot to the local history (always on with THCTL_HISTORY=true)")
 
-            // Create standardized response
-            resp := &lotus.Response{
-                Version:   "1.0",
-                Timestamp: time.Now().Unix(),
-                Status:    "success",
-                Data:     info,
-            }
+    cmd.AddCommand(
+        blocks.NewBlocksCmd(),
+        compare.NewCompareCmd(),
+        deadline.NewDeadlineCmd(),
+        deals.NewDealsCmd(),
+        history.NewHistoryCmd(),
+        history.NewDiffCmd(),
+        ledger.NewLedgerCmd(),
+        netcheck.NewNetcheckCmd(),
+        power.NewPowerCmd(),
+        message.NewWithdrawCmd(),
+        message.NewSetOwnerCmd(),
+        message.NewSetWorkerCmd(),
+        message.NewSetControlCmd(),
+        message.NewChangeBeneficiaryCmd(),
+    )
 
-            switch output {
-            case "json":
-                jsonBytes, err := json.MarshalIndent(resp, "", "  ")
-                if err != nil {
-                    return fmt.Errorf("❌ error marshaling JSON: %v", err)
-                }
-                fmt.Println(string(jsonBytes))
-            case "yaml":
-                yamlBytes, err := yaml.Marshal(resp)
-                if err != nil {
-                    return fmt.Errorf("❌ error marshaling YAML: %v", err)
-                }
-                fmt.Println(string(yamlBytes))
-            case "table":
-                printMinerInfoTable(minerID, info)
-            default:
-                return fmt.Errorf("❌ unsupported output format: %s", output)
-            }
+    return cmd
+}
 
-            return nil
-        },
+// ShowMinerInfo prints the comprehensive information of a miner in the given output format.
+// The network power ranking is computed when rank is set, otherwise the cached one is used.
+// A snapshot is saved to the local history when record is set or history is enabled in the config.
+func ShowMinerInfo(ctx context.Context, client *lotus.Client, minerID, output string, rank, record bool) error {
+    info, err := client.GetComprehensiveMinerInfo(ctx, minerID)
+    if err != nil {
+        return fmt.Errorf("❌ error getting miner info: %v", err)
     }
 
-    cmd.Flags().StringP("output", "o", "json", "Output format: json, yaml, or table")
-    return cmd
+    // Fill in the network power ranks, computing the ranking only when asked to
+    store := client.CacheStore(ctx, "network")
+    var ranking *lotus.PowerRanking
+    if rank {
+        ranking, err = client.GetPowerRanking(ctx, store, lotus.DefaultRankingMaxAge)
+    } else {
+        ranking, err = lotus.LoadPowerRanking(store)
+    }
+    if err != nil {
+        return fmt.Errorf("❌ error getting power ranking: %v", err)
+    }
+    info.ApplyRanking(ranking)
+
+    // If any required fields are missing, return an error
+    if info.Miner.Owner.Address == "" || info.Miner.Worker.Address == "" {
+        return fmt.Errorf("❌ failed to get required miner information")
+    }
+
+    if cfg, err := config.Load(); err == nil && cfg.History.Enabled {
+        record = true
+    }
+    if record {
+        if err := recordSnapshot(info); err != nil {
+            return fmt.Errorf("❌ error recording miner history: %v", err)
+        }
+    }
+
+    // Create standardized response
+    resp := &lotus.Response{
+        Version:   "1.0",
+        Timestamp: time.Now().Unix(),
+        Status:    "success",
+        Data:     info,
+        Endpoints: client.ServedBy(),
+    }
+
+    switch output {
+    case "json":
+        jsonBytes, err := json.MarshalIndent(resp, "", "  ")
+        if err != nil {
+            return fmt.Errorf("❌ error marshaling JSON: %v", err)
+        }
+        fmt.Println(string(jsonBytes))
+    case "yaml":
+        yamlBytes, err := yaml.Marshal(resp)
+        if err != nil {
+            return fmt.Errorf("❌ error marshaling YAML: %v", err)
+        }
+        fmt.Println(string(yamlBytes))
+    case "table":
+        printMinerInfoTable(minerID, info)
+    default:
+        return fmt.Errorf("❌ unsupported output format: %s", output)
+    }
+
+    return nil
+}
+
+// recordSnapshot saves the miner information to the local history under the miner's ID
+func recordSnapshot(info *lotus.MinerInfo) error {
+    store, err := historystore.Open()
+    if err != nil {
+        return err
+    }
+    defer store.Close()
+    return store.Save(info, time.Now())
 }
 
 func printMinerInfoTable(minerID string, info *lotus.MinerInfo) {
@@ -82,11 +156,11 @@ func printMinerInfoTable(minerID string, info *lotus.MinerInfo) {
     t.AppendRow(table.Row{"ID", info.ID})
     t.AppendRow(table.Row{"Robust Address", info.Robust})
     t.AppendRow(table.Row{"Actor Type", info.Actor})
-    t.AppendRow(table.Row{"Balance", formatFIL(info.Balance)})
+    t.AppendRow(table.Row{"Balance", info.Balance.String()})
     t.AppendRow(table.Row{"Create Height", fmt.Sprintf("%d", info.CreateHeight)})
-    t.AppendRow(table.Row{"Create Time", time.Unix(info.CreateTimestamp, 0).Format(time.RFC3339)})
+    t.AppendRow(table.Row{"Create Time", formatTimestamp(info.CreateTimestamp)})
     t.AppendRow(table.Row{"Last Seen Height", fmt.Sprintf("%d", info.LastSeenHeight)})
-    t.AppendRow(table.Row{"Last Seen Time", time.Unix(info.LastSeenTimestamp, 0).Format(time.RFC3339)})
+    t.AppendRow(table.Row{"Last Seen Time", formatTimestamp(info.LastSeenTimestamp)})
     t.AppendRow(table.Row{"Message Count", fmt.Sprintf("%d", info.MessageCount)})
     t.AppendRow(table.Row{"Transfer Count", fmt.Sprintf("%d", info.TransferCount)})
     t.AppendRow(table.Row{"Token Transfer Count", fmt.Sprintf("%d", info.TokenTransferCount)})
@@ -96,24 +170,65 @@ func printMinerInfoTable(minerID string, info *lotus.MinerInfo) {
     // Address Information
     fmt.Println("\n📫 Address Information:")
     t = table.NewWriter()
-    t.AppendHeader(table.Row{"Role", "Address", "Balance"})
-    t.AppendRow(table.Row{"Owner", info.Miner.Owner.Address, formatFIL(info.Miner.Owner.Balance)})
-    t.AppendRow(table.Row{"Worker", info.Miner.Worker.Address, formatFIL(info.Miner.Worker.Balance)})
-    t.AppendRow(table.Row{"Beneficiary", info.Miner.Beneficiary.Address, formatFIL(info.Miner.Beneficiary.Balance)})
+    t.AppendHeader(table.Row{"Role", "ID", "Robust Address", "Balance"})
+    t.AppendRow(addressRow("Owner", info.Miner.Owner))
+    t.AppendRow(addressRow("Worker", info.Miner.Worker))
+    t.AppendRow(addressRow("Beneficiary", info.Miner.Beneficiary))
     for i, ctrl := range info.Miner.ControlAddresses {
-        t.AppendRow(table.Row{fmt.Sprintf("Control %d", i+1), ctrl.Address, formatFIL(ctrl.Balance)})
+        t.AppendRow(addressRow(fmt.Sprintf("Control %d", i+1), ctrl))
     }
     fmt.Println(t.Render())
 
+    // Beneficiary term, only meaningful when the beneficiary is not the owner
+    term := info.Miner.BeneficiaryTerm
+    if term.Expiration != 0 || term.Quota.Sign() > 0 {
+        fmt.Println("\n🎯 Beneficiary Term:")
+        t = table.NewWriter()
+        t.AppendHeader(table.Row{"Attribute", "Value"})
+        t.AppendRow(table.Row{"Quota", term.Quota.String()})
+        t.AppendRow(table.Row{"Used Quota", term.UsedQuota.String()})
+        t.AppendRow(table.Row{"Remaining Quota", term.RemainingQuota.String()})
+        t.AppendRow(table.Row{"Expiration", formatEpoch(term.Expiration, term.ExpirationTimestamp)})
+        t.AppendRow(table.Row{"Expired", term.Expired})
+        fmt.Println(t.Render())
+    }
+
+    // Pending owner, worker and beneficiary changes
+    if len(info.Miner.PendingChanges) > 0 {
+        fmt.Println("\n⏳ Pending Changes:")
+        t = table.NewWriter()
+        t.AppendHeader(table.Row{"Role", "Current", "New", "Takes Effect", "Action"})
+        for _, change := range info.Miner.PendingChanges {
+            effective := "on confirmation"
+            if change.EffectiveEpoch != 0 {
+                effective = formatEpoch(change.EffectiveEpoch, change.EffectiveTimestamp)
+            }
+            newValue := change.New
+            if change.NewQuota != nil {
+                newValue = fmt.Sprintf("%s (quota %s until epoch %d)", change.New, change.NewQuota, change.NewExpiration)
+            }
+            t.AppendRow(table.Row{change.Role, change.Current, newValue, effective, change.Action})
+        }
+        fmt.Println(t.Render())
+    }
+
+    // Warnings
+    if len(info.Warnings) > 0 {
+        fmt.Println("\n⚠️ Warnings:")
+        for _, warning := range info.Warnings {
+            fmt.Printf("  - %s\n", warning)
+        }
+    }
+
     // Power Statistics
     fmt.Println("\n💪 Power Statistics:")
     t = table.NewWriter()
     t.AppendHeader(table.Row{"Attribute", "Value"})
-    t.AppendRow(table.Row{"Raw Power", formatBytes(info.Miner.RawBytePower)})
-    t.AppendRow(table.Row{"Quality Adjusted Power", formatBytes(info.Miner.QualityAdjPower)})
-    t.AppendRow(table.Row{"Network Raw Power", formatBytes(info.Miner.NetworkRawBytePower)})
-    t.AppendRow(table.Row{"Network Quality Power", formatBytes(info.Miner.NetworkQualityAdjPower)})
-    t.AppendRow(table.Row{"Network Power Share", fmt.Sprintf("%.4f%%", calculatePowerShare(info.Miner.RawBytePower, info.Miner.NetworkRawBytePower)*100)})
+    t.AppendRow(table.Row{"Raw Power", info.Miner.RawBytePower.String()})
+    t.AppendRow(table.Row{"Quality Adjusted Power", info.Miner.QualityAdjPower.String()})
+    t.AppendRow(table.Row{"Network Raw Power", info.Miner.NetworkRawBytePower.String()})
+    t.AppendRow(table.Row{"Network Quality Power", info.Miner.NetworkQualityAdjPower.String()})
+    t.AppendRow(table.Row{"Network Power Share", fmt.Sprintf("%.4f%%", calculatePowerShare(info.Miner.RawBytePower.Int(), info.Miner.NetworkRawBytePower.Int())*100)})
     t.AppendRow(table.Row{"Raw Power Rank", fmt.Sprintf("%d", info.Miner.RawBytePowerRank)})
     t.AppendRow(table.Row{"Quality Power Rank", fmt.Sprintf("%d", info.Miner.QualityAdjPowerRank)})
     fmt.Println(t.Render())
@@ -122,13 +237,13 @@ func printMinerInfoTable(minerID string, info *lotus.MinerInfo) {
     fmt.Println("\n💰 Financial Information:")
     t = table.NewWriter()
     t.AppendHeader(table.Row{"Attribute", "Value"})
-    t.AppendRow(table.Row{"Available Balance", formatFIL(info.Miner.AvailableBalance)})
-    t.AppendRow(table.Row{"Initial Pledge", formatFIL(info.Miner.InitialPledgeRequirement)})
-    t.AppendRow(table.Row{"Vesting Funds", formatFIL(info.Miner.VestingFunds)})
-    t.AppendRow(table.Row{"Pre-Commit Deposits", formatFIL(info.Miner.PreCommitDeposits)})
-    t.AppendRow(table.Row{"Total Rewards", formatFIL(info.Miner.TotalRewards)})
-    t.AppendRow(table.Row{"Sector Pledge Balance", formatFIL(info.Miner.SectorPledgeBalance)})
-    t.AppendRow(table.Row{"Pledge Balance", formatFIL(info.Miner.PledgeBalance)})
+    t.AppendRow(table.Row{"Available Balance", info.Miner.AvailableBalance.String()})
+    t.AppendRow(table.Row{"Initial Pledge", info.Miner.InitialPledgeRequirement.String()})
+    t.AppendRow(table.Row{"Vesting Funds", info.Miner.VestingFunds.String()})
+    t.AppendRow(table.Row{"Pre-Commit Deposits", info.Miner.PreCommitDeposits.String()})
+    t.AppendRow(table.Row{"Total Rewards", info.Miner.TotalRewards.String()})
+    t.AppendRow(table.Row{"Sector Pledge Balance", info.Miner.SectorPledgeBalance.String()})
+    t.AppendRow(table.Row{"Pledge Balance", info.Miner.PledgeBalance.String()})
     fmt.Println(t.Render())
 
     // Sector Statistics
@@ -152,64 +267,42 @@ func printMinerInfoTable(minerID string, info *lotus.MinerInfo) {
     fmt.Println(strings.Repeat("-", 50))
 }
 
-func calculatePowerShare(power, networkPower string) float64 {
-    // Convert string to big.Int
-    p := new(big.Int)
-    p.SetString(power, 10)
-
-    // Convert string to big.Int
-    np := new(big.Int)
-    np.SetString(networkPower, 10)
-
-    // Calculate power share
-    share := new(big.Float).SetInt(p)
-    share.Quo(share, new(big.Float).SetInt(np))
-
-    // Return power share as float64
-    result, _ := share.Float64()
-    return result
+// formatEpoch formats an epoch with its estimated time and how far away it is
+func formatEpoch(epoch, ts int64) string {
+    when := time.Unix(ts, 0)
+    until := time.Until(when).Round(time.Minute)
+    if until >= 0 {
+        return fmt.Sprintf("epoch %d (%s, in %s)", epoch, when.Format(time.RFC3339), until)
+    }
+    return fmt.Sprintf("epoch %d (%s, %s ago)", epoch, when.Format(time.RFC3339), -until)
 }
 
-// formatBytes formats bytes into human readable format
-func formatBytes(bytes string) string {
-    // Convert string to big.Int
-    b := new(big.Int)
-    b.SetString(bytes, 10)
-
-    // Define units
-    units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
-    unitSize := new(big.Int).SetInt64(1024)
-    unit := 0
-    zero := new(big.Int).SetInt64(0)
-    threshold := new(big.Int).SetInt64(1024)
-
-    // Find appropriate unit
-    value := new(big.Int).Set(b)
-    for value.Cmp(threshold) >= 0 && unit < len(units)-1 {
-        value.Div(value, unitSize)
-        unit++
+// formatTimestamp formats a unix timestamp, showing "unknown" when it is not set
+func formatTimestamp(ts int64) string {
+    if ts == 0 {
+        return "unknown"
     }
+    return time.Unix(ts, 0).Format(time.RFC3339)
+}
 
-    if value.Cmp(zero) == 0 {
-        return "0 B"
+// addressRow builds an address table row, falling back to the raw address
+// when the ID form could not be resolved
+func addressRow(role string, addr lotus.AddressInfo) table.Row {
+    id := addr.ID
+    if id == "" {
+        id = addr.Address
     }
-
-    return fmt.Sprintf("%s %s", value.String(), units[unit])
+    return table.Row{role, id, addr.Robust, addr.Balance.String()}
 }
 
-// formatAttoFil formats attoFIL to FIL with proper decimal places
-func formatFIL(attoFil string) string {
-    // Convert string to big.Int
-    atto := new(big.Int)
-    atto.SetString(attoFil, 10)
-
-    // Define FIL = 10^18 attoFIL
-    fil := new(big.Int).SetInt64(1000000000000000000)
-
-    // Calculate FIL amount
-    filAmount := new(big.Float).SetInt(atto)
-    filAmount.Quo(filAmount, new(big.Float).SetInt(fil))
+func calculatePowerShare(power, networkPower *big.Int) float64 {
+    if networkPower.Sign() == 0 {
+        return 0
+    }
+    share := new(big.Float).SetInt(power)
+    share.Quo(share, new(big.Float).SetInt(networkPower))
 
-    // Format with 6 decimal places
-    return fmt.Sprintf("%.6f FIL", filAmount)
-}
\ No newline at end of file
+    // Return power share as float64
+    result, _ := share.Float64()
+    return result
+}
@@ -0,0 +1,174 @@
+package netcheck
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/internal/address"
+	"github.com/THCloudAI/thctl/internal/lotus"
+	"github.com/THCloudAI/thctl/internal/netcheck"
+	"github.com/THCloudAI/thctl/pkg/framework/output"
+)
+
+// NewNetcheckCmd creates a new netcheck command
+func NewNetcheckCmd() *cobra.Command {
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "netcheck [miner_id]",
+		Short: "Check that the multiaddrs of a miner are reachable",
+		Long: `Dial every multiaddr a Filecoin storage provider advertises on chain and check that
+the peer answering is the one registered for the miner:
+- TCP connection and its latency
+- libp2p noise handshake, which authenticates the on-chain peer ID
+- QUIC v1 connection, whose TLS handshake authenticates the on-chain peer ID
+- libp2p identify, with the agent version and supported protocols
+
+Websocket and relay multiaddrs are listed but not dialed.
+
+Examples:
+  # Check the multiaddrs of a miner
+  thctl fil miner netcheck f01234
+
+  # Allow slow peers more time
+  thctl fil miner netcheck f01234 --timeout 30s -o json`,
+		Args: cobra.MatchAll(cobra.ExactArgs(1), address.MinerArgs(0)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			minerID := args[0]
+
+			client, err := lotus.NewFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create Lotus client: %v", err)
+			}
+
+			minerPeer, err := client.GetMinerPeer(cmd.Context(), minerID)
+			if err != nil {
+				return fmt.Errorf("failed to get miner peer info: %w", err)
+			}
+
+			checker, err := netcheck.New(timeout)
+			if err != nil {
+				return err
+			}
+			results, err := checker.Check(cmd.Context(), minerPeer.PeerID, minerPeer.Multiaddrs)
+			if err != nil {
+				return err
+			}
+
+			report := &netcheck.Report{
+				MinerID: minerID,
+				PeerID:  minerPeer.PeerID,
+				Results: results,
+			}
+			for _, result := range results {
+				if result.Status == netcheck.StatusOK || result.Status == netcheck.StatusConnected {
+					report.Reachable++
+				}
+			}
+
+			resp := &lotus.Response{
+				Version:   "1.0",
+				Timestamp: time.Now().Unix(),
+				Status:    "success",
+				Data:      report,
+				Endpoints: client.ServedBy(),
+			}
+
+			format, _ := cmd.Flags().GetString("output")
+			switch format {
+			case "json":
+				return output.JSON(resp)
+			case "yaml":
+				return output.YAML(resp)
+			case "table":
+				printNetcheckTable(report)
+			default:
+				return fmt.Errorf("unsupported output format: %s", format)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&timeout, "timeout", 10*time.Second, "Time allowed per multiaddr to connect, handshake and identify")
+	cmd.Flags().StringP("output", "o", "table", "Output format: json, yaml, or table")
+
+	return cmd
+}
+
+func printNetcheckTable(report *netcheck.Report) {
+	fmt.Printf("\n🌐 Network check of %s\n", report.MinerID)
+	fmt.Printf("Peer ID: %s\n", valueOrDash(report.PeerID))
+
+	if len(report.Results) == 0 {
+		fmt.Println("⚠️ The miner advertises no multiaddrs on chain, so clients cannot reach it")
+		return
+	}
+	if report.PeerID == "" {
+		fmt.Println("⚠️ The miner has no peer ID on chain; only connections are checked")
+	}
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Multiaddr", "Transport", "Status", "Dial", "Handshake", "Agent", "Detail"})
+	for _, r := range report.Results {
+		detail := r.Error
+		if r.Status == netcheck.StatusPeerMismatch {
+			detail = fmt.Sprintf("answered by %s, %s", r.RemotePeerID, r.Error)
+		}
+		t.AppendRow(table.Row{
+			r.Multiaddr,
+			r.Transport,
+			statusLabel(r.Status),
+			latency(r.DialLatencyMs, r.Status != netcheck.StatusUnreachable && r.Status != netcheck.StatusSkipped),
+			latency(r.HandshakeLatencyMs, r.Status == netcheck.StatusOK),
+			valueOrDash(r.AgentVersion),
+			valueOrDash(detail),
+		})
+	}
+	fmt.Println(t.Render())
+
+	for _, r := range report.Results {
+		if len(r.Protocols) > 0 {
+			fmt.Printf("\n📡 Protocols of %s:\n  %s\n", r.Multiaddr, strings.Join(r.Protocols, "\n  "))
+			break
+		}
+	}
+
+	if report.Reachable == 0 {
+		fmt.Println("\n❌ None of the multiaddrs could be reached")
+	} else {
+		fmt.Printf("\n✅ %d of %d multiaddrs reachable\n", report.Reachable, len(report.Results))
+	}
+}
+
+// statusLabel returns the status with an emoji for table output
+func statusLabel(status string) string {
+	switch status {
+	case netcheck.StatusOK:
+		return "✅ ok"
+	case netcheck.StatusConnected:
+		return "🔌 connected"
+	case netcheck.StatusSkipped:
+		return "⏭️ skipped"
+	default:
+		return "❌ " + strings.ReplaceAll(status, "_", " ")
+	}
+}
+
+// latency formats a latency in milliseconds, "-" when it was not measured
+func latency(ms float64, measured bool) string {
+	if !measured {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f ms", ms)
+}
+
+// valueOrDash returns the value or "-" when it is empty
+func valueOrDash(v string) string {
+	if v == "" {
+		return "-"
+	}
+	return v
+}
@@ -1,15 +1,20 @@
 package power
 
 import (
-	"context"
 	"fmt"
+	"math/big"
+
 	"github.com/spf13/cobra"
-	"github.com/thcloudai/thctl/internal/lotus"
-	"github.com/thcloudai/thctl/pkg/framework/output"
+	"github.com/THCloudAI/thctl/internal/address"
+	"github.com/THCloudAI/thctl/internal/lotus"
+	"github.com/THCloudAI/thctl/internal/units"
+	"github.com/THCloudAI/thctl/pkg/framework/output"
 )
 
 // NewPowerCmd creates a new power command
 func NewPowerCmd() *cobra.Command {
+	var rank bool
+
 	cmd := &cobra.Command{
 		Use:   "power [miner_id]",
 		Short: "Get miner power information",
@@ -18,21 +23,41 @@ func NewPowerCmd() *cobra.Command {
 - Quality adjusted power
 - Network total power
 - Relative power percentage
-- Power growth over time`,
-		Args: cobra.ExactArgs(1),
+- Network power rank`,
+		Args: cobra.MatchAll(cobra.ExactArgs(1), address.MinerArgs(0)),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			minerID := args[0]
-			
+			ctx := cmd.Context()
+
 			// Create Lotus client
-			client := lotus.NewClient(lotus.Config{})
-			
+			client, err := lotus.NewFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create Lotus client: %v", err)
+			}
+
 			// Get power info
-			ctx := context.Background()
 			info, err := client.GetMinerPower(ctx, minerID)
 			if err != nil {
 				return fmt.Errorf("failed to get miner power: %w", err)
 			}
-			
+
+			// Look up the network rank, computing the ranking only when asked to
+			var ranking *lotus.PowerRanking
+			store := client.CacheStore(ctx, "network")
+			if rank {
+				ranking, err = client.GetPowerRanking(ctx, store, lotus.DefaultRankingMaxAge)
+			} else {
+				ranking, err = lotus.LoadPowerRanking(store)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get power ranking: %w", err)
+			}
+			if ranking != nil {
+				if entry := ranking.Rank(minerID); entry != nil {
+					info["Rank"] = entry
+				}
+			}
+
 			// Format output based on the selected format
 			format, _ := cmd.Flags().GetString("output")
 			switch format {
@@ -42,40 +67,55 @@ func NewPowerCmd() *cobra.Command {
 				return output.YAML(info)
 			default:
 				fmt.Printf("Miner Power Information for %s:\n\n", minerID)
-				
-				if rawPower, ok := info["MinerPower"].(map[string]interface{}); ok {
-					fmt.Println("Raw Power:")
-					fmt.Printf("  Raw Byte Power: %v\n", rawPower["RawBytePower"])
-					fmt.Printf("  Quality Adjusted Power: %v\n", rawPower["QualityAdjPower"])
-				}
-				
-				if totalPower, ok := info["TotalPower"].(map[string]interface{}); ok {
-					fmt.Println("\nNetwork Total Power:")
-					fmt.Printf("  Total Raw Byte Power: %v\n", totalPower["RawBytePower"])
-					fmt.Printf("  Total Quality Adjusted Power: %v\n", totalPower["QualityAdjPower"])
-				}
-				
+
+				minerPower, _ := info["MinerPower"].(map[string]interface{})
+				totalPower, _ := info["TotalPower"].(map[string]interface{})
+
+				minerRaw, _ := minerPower["RawBytePower"].(string)
+				minerQA, _ := minerPower["QualityAdjPower"].(string)
+				totalRaw, _ := totalPower["RawBytePower"].(string)
+				totalQA, _ := totalPower["QualityAdjPower"].(string)
+
+				fmt.Println("Raw Power:")
+				fmt.Printf("  Raw Byte Power: %s\n", units.BytesFromString(minerRaw))
+				fmt.Printf("  Quality Adjusted Power: %s\n", units.BytesFromString(minerQA))
+
+				fmt.Println("\nNetwork Total Power:")
+				fmt.Printf("  Total Raw Byte Power: %s\n", units.BytesFromString(totalRaw))
+				fmt.Printf("  Total Quality Adjusted Power: %s\n", units.BytesFromString(totalQA))
+
 				// Calculate and display percentage of network power
-				if minerRaw, ok := info["MinerPower"].(map[string]interface{})["RawBytePower"].(string); ok {
-					if totalRaw, ok := info["TotalPower"].(map[string]interface{})["RawBytePower"].(string); ok {
-						// Note: In real implementation, you'd need to properly parse these values
-						fmt.Printf("\nNetwork Power Share: %.4f%%\n", calculatePowerShare(minerRaw, totalRaw))
-					}
+				fmt.Printf("\nNetwork Power Share: %.4f%%\n", calculatePowerShare(minerRaw, totalRaw))
+
+				if entry, ok := info["Rank"].(*lotus.MinerPowerRank); ok {
+					fmt.Printf("\nNetwork Rank (as of height %d):\n", ranking.Height)
+					fmt.Printf("  Raw Byte Power Rank: %d of %d\n", entry.RawBytePowerRank, len(ranking.Miners))
+					fmt.Printf("  Quality Adjusted Power Rank: %d of %d\n", entry.QualityAdjPowerRank, len(ranking.Miners))
+				} else if ranking == nil {
+					fmt.Println("\nNetwork Rank: not computed (use --rank)")
 				}
 			}
-			
+
 			return nil
 		},
 	}
-	
+
+	cmd.Flags().BoolVar(&rank, "rank", false, "Compute the network power ranking if the cached one is stale")
 	cmd.Flags().StringP("output", "o", "table", "Output format: json, yaml, or table")
-	
+
 	return cmd
 }
 
 // calculatePowerShare calculates the percentage of network power
-// Note: This is a placeholder implementation. In real code, you'd need to properly parse the values
 func calculatePowerShare(minerPower, totalPower string) float64 {
-	// Implement proper calculation logic here
-	return 0.0
+	miner, ok := new(big.Float).SetString(minerPower)
+	if !ok {
+		return 0.0
+	}
+	total, ok := new(big.Float).SetString(totalPower)
+	if !ok || total.Sign() == 0 {
+		return 0.0
+	}
+	share, _ := new(big.Float).Quo(miner, total).Float64()
+	return share * 100
 }
@@ -0,0 +1,255 @@
+package mpool
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/cmd/thctl/commands/fil/miner/message"
+	"github.com/THCloudAI/thctl/internal/address"
+	"github.com/THCloudAI/thctl/internal/cache"
+	"github.com/THCloudAI/thctl/internal/lotus"
+	"github.com/THCloudAI/thctl/internal/units"
+	"github.com/THCloudAI/thctl/pkg/framework/output"
+)
+
+// NewMpoolCmd creates a new mpool command
+func NewMpoolCmd() *cobra.Command {
+	var (
+		stuckOnly bool
+		noCache   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "mpool [miner_id]",
+		Short: "Monitor the pending messages of a miner's worker and control addresses",
+		Long: `List the messages of a miner's worker and control addresses waiting in the message pool.
+
+For each address the on-chain nonce is compared with the pending nonces to find gaps,
+which hold up every later message. Each message's gas premium is compared with the
+current base fee and the premiums estimated for inclusion within 1, 5 and 20 epochs.
+Messages that cannot be included soon, or have been pending for more than 20 epochs,
+are reported as stuck; 'thctl fil mpool replace' builds a replacement for them.
+
+The pool does not record when messages arrive, so their age is counted from the first
+time this command saw them pending.
+
+Examples:
+  # Show the pending messages of a miner
+  thctl fil mpool f01234 -o table
+
+  # Show only stuck messages as JSON
+  thctl fil mpool f01234 --stuck -o json`,
+		Args: cobra.MatchAll(cobra.ExactArgs(1), address.MinerArgs(0)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := lotus.NewFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create Lotus client: %v", err)
+			}
+
+			var store *cache.Store
+			if !noCache {
+				store = client.CacheStore(cmd.Context(), "mpool")
+			}
+
+			status, err := client.GetMpoolStatus(cmd.Context(), args[0], store)
+			if err != nil {
+				return fmt.Errorf("failed to get message pool status: %w", err)
+			}
+			if stuckOnly {
+				for i := range status.Senders {
+					status.Senders[i].Messages = onlyStuck(status.Senders[i].Messages)
+				}
+			}
+
+			resp := &lotus.Response{
+				Version:   "1.0",
+				Timestamp: time.Now().Unix(),
+				Status:    "success",
+				Data:      status,
+				Endpoints: client.ServedBy(),
+			}
+
+			format, _ := cmd.Flags().GetString("output")
+			switch format {
+			case "json":
+				return output.JSON(resp)
+			case "yaml":
+				return output.YAML(resp)
+			case "table":
+				printMpoolTable(status)
+			default:
+				return fmt.Errorf("unsupported output format: %s", format)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&stuckOnly, "stuck", false, "Only show stuck messages")
+	cmd.Flags().BoolVar(&noCache, "no-cache", false, "Do not track when messages were first seen")
+	cmd.Flags().StringP("output", "o", "table", "Output format: json, yaml, or table")
+
+	cmd.AddCommand(newReplaceCmd())
+
+	return cmd
+}
+
+func newReplaceCmd() *cobra.Command {
+	var premium, feeCap string
+
+	cmd := &cobra.Command{
+		Use:   "replace [address] [nonce]",
+		Short: "Build a replace-by-fee message for a stuck pending message",
+		Long: `Build an unsigned replacement for a pending message, with the same nonce, recipient,
+method and params but a higher gas premium. The pool only accepts a replacement whose
+premium is at least 25% higher, so that is the minimum.
+
+Without --premium the higher of that minimum and the premium estimated for inclusion
+within 5 epochs is used. Without --fee-cap the old fee cap is kept unless the new premium
+plus twice the current base fee needs more.
+
+The message is only written out for offline signing; it is never pushed.
+
+Examples:
+  # Replace the stuck message with nonce 1042 of a worker
+  thctl fil mpool replace f1worker... 1042 --out replace.json
+
+  # Replace it with a given premium and fee cap
+  thctl fil mpool replace f0200 1042 --premium "250000 attoFIL" --fee-cap "2 nanoFIL"`,
+		Args: cobra.MatchAll(cobra.ExactArgs(2), address.AddressArgsAt(0)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			nonce, err := strconv.ParseUint(args[1], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid nonce: %s", args[1])
+			}
+			premiumAtto, err := parseOptionalFIL(premium)
+			if err != nil {
+				return fmt.Errorf("invalid --premium: %v", err)
+			}
+			feeCapAtto, err := parseOptionalFIL(feeCap)
+			if err != nil {
+				return fmt.Errorf("invalid --fee-cap: %v", err)
+			}
+
+			client, err := lotus.NewFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create Lotus client: %v", err)
+			}
+
+			msg, err := client.BuildReplaceMessage(cmd.Context(), args[0], nonce, premiumAtto, feeCapAtto)
+			if err != nil {
+				return fmt.Errorf("failed to build replacement: %w", err)
+			}
+			return message.WritePrepared(cmd, msg)
+		},
+	}
+
+	cmd.Flags().StringVar(&premium, "premium", "", "Gas premium per gas unit (default: estimated)")
+	cmd.Flags().StringVar(&feeCap, "fee-cap", "", "Gas fee cap per gas unit (default: estimated)")
+	message.AddOutFlag(cmd)
+
+	return cmd
+}
+
+func printMpoolTable(status *lotus.MpoolStatus) {
+	fmt.Printf("\n📨 Message Pool for %s at height %d\n", status.MinerID, status.Height)
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Attribute", "Value"})
+	t.AppendRow(table.Row{"Base Fee", formatGasPrice(status.BaseFee)})
+	for _, estimate := range status.PremiumEstimates {
+		t.AppendRow(table.Row{fmt.Sprintf("Premium for %d epoch(s)", estimate.Epochs), formatGasPrice(estimate.Premium)})
+	}
+	t.AppendRow(table.Row{"Stuck Messages", status.StuckCount})
+	fmt.Println(t.Render())
+
+	for _, sender := range status.Senders {
+		fmt.Printf("\n👷 %s %s (%s), on-chain nonce %d:\n", sender.Role, sender.Address, valueOrDash(sender.KeyAddress), sender.OnChainNonce)
+		if len(sender.NonceGaps) > 0 {
+			gaps := make([]string, 0, len(sender.NonceGaps))
+			for _, gap := range sender.NonceGaps {
+				gaps = append(gaps, strconv.FormatUint(gap, 10))
+			}
+			fmt.Printf("⚠️ Missing nonces: %s\n", strings.Join(gaps, ", "))
+		}
+		if len(sender.Messages) == 0 {
+			fmt.Println("📭 No pending messages")
+			continue
+		}
+
+		t = table.NewWriter()
+		t.AppendHeader(table.Row{"Nonce", "Method", "To", "Gas Premium", "Gas Fee Cap", "Age", "Inclusion", "Stuck"})
+		for _, msg := range sender.Messages {
+			stuck := ""
+			if msg.Stuck {
+				stuck = "⚠️"
+			}
+			t.AppendRow(table.Row{
+				msg.Nonce,
+				msg.MethodName,
+				msg.To,
+				formatGasPrice(msg.GasPremium),
+				formatGasPrice(msg.GasFeeCap),
+				formatAge(msg),
+				msg.Inclusion,
+				stuck,
+			})
+		}
+		fmt.Println(t.Render())
+	}
+
+	if status.StuckCount > 0 {
+		fmt.Println("\n💡 Build a replacement for a stuck message with: thctl fil mpool replace [address] [nonce]")
+	}
+}
+
+// onlyStuck returns the stuck messages of a sender
+func onlyStuck(messages []lotus.PendingMessage) []lotus.PendingMessage {
+	stuck := make([]lotus.PendingMessage, 0, len(messages))
+	for _, msg := range messages {
+		if msg.Stuck {
+			stuck = append(stuck, msg)
+		}
+	}
+	return stuck
+}
+
+// parseOptionalFIL parses an amount flag, returning nil when it is not set
+func parseOptionalFIL(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	amount, err := units.ParseFIL(s)
+	if err != nil {
+		return nil, err
+	}
+	return amount.Atto(), nil
+}
+
+// formatGasPrice formats a price per gas unit. These are far below one FIL, so they are
+// always shown in the largest unit in which they are at least one.
+func formatGasPrice(price units.FIL) string {
+	display := units.CurrentDisplay()
+	display.AutoFIL = true
+	return display.FIL(price)
+}
+
+// formatAge formats how long a message has been pending, "-" when it is not tracked
+func formatAge(msg lotus.PendingMessage) string {
+	if msg.FirstSeen == 0 {
+		return "-"
+	}
+	return (time.Duration(msg.AgeSeconds) * time.Second).String()
+}
+
+// valueOrDash returns "-" for empty values
+func valueOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
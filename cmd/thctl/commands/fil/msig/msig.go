@@ -0,0 +1,225 @@
+package msig
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/cmd/thctl/commands/fil/miner/message"
+	"github.com/THCloudAI/thctl/internal/address"
+	"github.com/THCloudAI/thctl/internal/lotus"
+	"github.com/THCloudAI/thctl/pkg/framework/output"
+)
+
+// NewMsigCmd creates a new msig command
+func NewMsigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "msig",
+		Short: "Inspect multisig wallets and build their proposals",
+		Long: `Inspect multisig wallets and build proposal, approval and cancellation messages.
+
+Messages are built unsigned with nonce and gas estimates filled in, for a signer of the
+multisig to sign offline with 'thctl wallet sign'. They are never pushed.
+
+Examples:
+  # Show signers, vesting and pending transactions
+  thctl fil msig inspect f080
+
+  # Propose withdrawing 100 FIL from a miner owned by the multisig
+  thctl fil msig propose withdraw f080 f01234 100 --from f1signer... --out propose.json
+
+  # Approve transaction 5 as another signer
+  thctl fil msig approve f080 5 --from f1other... --out approve.json`,
+	}
+
+	cmd.AddCommand(
+		newInspectCmd(),
+		newProposeCmd(),
+		newApproveCmd(),
+		newCancelCmd(),
+	)
+
+	return cmd
+}
+
+func newInspectCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "inspect [msig]",
+		Short: "Show the signers, balance, vesting and pending transactions of a multisig",
+		Args:  cobra.MatchAll(cobra.ExactArgs(1), address.AddressArgs(0)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := lotus.NewFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create Lotus client: %v", err)
+			}
+
+			info, err := client.GetMultisig(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+
+			format, _ := cmd.Flags().GetString("output")
+			switch format {
+			case "json":
+				return output.JSON(info)
+			case "yaml":
+				return output.YAML(info)
+			case "table":
+				printMultisigTable(info)
+			default:
+				return fmt.Errorf("unsupported output format: %s", format)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("output", "o", "table", "Output format: json, yaml, or table")
+
+	return cmd
+}
+
+func newApproveCmd() *cobra.Command {
+	var from string
+
+	cmd := &cobra.Command{
+		Use:   "approve [msig] [txn_id]",
+		Short: "Build a message approving a pending transaction",
+		Long: `Build an unsigned Approve message for a pending multisig transaction. The transaction
+is executed once the approvals reach the threshold. The proposal hash is included so the
+approval cannot apply to a different transaction with the same ID.
+
+The message is only written out for offline signing; it is never pushed.`,
+		Args: cobra.MatchAll(cobra.ExactArgs(2), address.AddressArgsAt(0)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTxnCmd(cmd, args, from, false)
+		},
+	}
+
+	addFromFlag(cmd, &from)
+	message.AddOutFlag(cmd)
+
+	return cmd
+}
+
+func newCancelCmd() *cobra.Command {
+	var from string
+
+	cmd := &cobra.Command{
+		Use:   "cancel [msig] [txn_id]",
+		Short: "Build a message cancelling a pending transaction",
+		Long: `Build an unsigned Cancel message for a pending multisig transaction. Only the signer
+who proposed the transaction can cancel it.
+
+The message is only written out for offline signing; it is never pushed.`,
+		Args: cobra.MatchAll(cobra.ExactArgs(2), address.AddressArgsAt(0)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTxnCmd(cmd, args, from, true)
+		},
+	}
+
+	addFromFlag(cmd, &from)
+	message.AddOutFlag(cmd)
+
+	return cmd
+}
+
+// runTxnCmd builds an Approve or Cancel message for the transaction given in args
+func runTxnCmd(cmd *cobra.Command, args []string, from string, cancel bool) error {
+	if err := address.Validate(from); err != nil {
+		return fmt.Errorf("invalid --from: %v", err)
+	}
+	txnID, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil || txnID < 0 {
+		return fmt.Errorf("invalid transaction ID: %s", args[1])
+	}
+
+	client, err := lotus.NewFromEnv()
+	if err != nil {
+		return fmt.Errorf("failed to create Lotus client: %v", err)
+	}
+
+	var msg *lotus.UnsignedMessage
+	if cancel {
+		msg, err = client.BuildCancelMessage(cmd.Context(), args[0], from, txnID)
+	} else {
+		msg, err = client.BuildApproveMessage(cmd.Context(), args[0], from, txnID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to build message: %w", err)
+	}
+	return message.WriteMessage(cmd, client, msg)
+}
+
+// addFromFlag adds the flag selecting the signer sending the message
+func addFromFlag(cmd *cobra.Command, from *string) {
+	cmd.Flags().StringVar(from, "from", "", "Signer of the multisig sending the message (required)")
+	cmd.MarkFlagRequired("from")
+}
+
+func printMultisigTable(info *lotus.MultisigInfo) {
+	fmt.Printf("\n👥 Multisig %s\n", info.Address)
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Attribute", "Value"})
+	t.AppendRow(table.Row{"ID", info.ID})
+	t.AppendRow(table.Row{"Robust Address", valueOrDash(info.Robust)})
+	t.AppendRow(table.Row{"Threshold", fmt.Sprintf("%d of %d signers", info.NumApprovalsThreshold, len(info.Signers))})
+	for i, signer := range info.Signers {
+		t.AppendRow(table.Row{fmt.Sprintf("Signer %d", i+1), signer})
+	}
+	t.AppendRow(table.Row{"Balance", info.Balance.String()})
+	t.AppendRow(table.Row{"Locked Balance", info.LockedBalance.String()})
+	t.AppendRow(table.Row{"Available Balance", info.AvailableBalance.String()})
+	t.AppendRow(table.Row{"Next Transaction ID", info.NextTxnID})
+	fmt.Println(t.Render())
+
+	if len(info.Vesting) > 0 {
+		fmt.Printf("\n🔒 Vesting (%s over %d epochs from epoch %d, fully unlocked %s):\n",
+			info.InitialBalance, info.UnlockDuration, info.StartEpoch, formatTimestamp(info.UnlockTimestamp))
+		t = table.NewWriter()
+		t.AppendHeader(table.Row{"Epoch", "Time", "Locked"})
+		for _, step := range info.Vesting {
+			t.AppendRow(table.Row{step.Epoch, formatTimestamp(step.Timestamp), step.Locked.String()})
+		}
+		fmt.Println(t.Render())
+	}
+
+	if len(info.Pending) == 0 {
+		fmt.Println("\n📭 No pending transactions")
+		return
+	}
+
+	fmt.Println("\n📬 Pending Transactions:")
+	t = table.NewWriter()
+	t.AppendHeader(table.Row{"ID", "To", "Value", "Method", "Description", "Approvals"})
+	for _, txn := range info.Pending {
+		method := txn.MethodName
+		if method == "" {
+			method = fmt.Sprintf("Method %d", txn.Method)
+		}
+		t.AppendRow(table.Row{
+			txn.ID,
+			txn.To,
+			txn.Value.String(),
+			method,
+			valueOrDash(txn.Description),
+			fmt.Sprintf("%d/%d", len(txn.Approved), info.NumApprovalsThreshold),
+		})
+	}
+	fmt.Println(t.Render())
+}
+
+// formatTimestamp formats a unix timestamp in UTC
+func formatTimestamp(ts int64) string {
+	return time.Unix(ts, 0).UTC().Format(time.RFC3339)
+}
+
+// valueOrDash returns "-" for empty values
+func valueOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,202 @@
+package msig
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/cmd/thctl/commands/fil/miner/message"
+	"github.com/THCloudAI/thctl/internal/address"
+	"github.com/THCloudAI/thctl/internal/lotus"
+	"github.com/THCloudAI/thctl/internal/units"
+)
+
+// innerBuilder builds the message the multisig given in args[0] proposes
+type innerBuilder func(ctx context.Context, client *lotus.Client, args []string) (*lotus.UnsignedMessage, error)
+
+func newProposeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "propose",
+		Short: "Build a message proposing a transaction from a multisig",
+		Long: `Build an unsigned Propose message from a signer of a multisig. The proposal counts as
+the proposer's approval; the other signers approve it with 'thctl fil msig approve' until
+the threshold is reached and the transaction is executed.
+
+The miner operations are the same as under 'thctl fil miner', sent by the multisig as the
+miner's owner or beneficiary.`,
+	}
+
+	cmd.AddCommand(
+		newProposeSendCmd(),
+		newProposeWithdrawCmd(),
+		newProposeSetOwnerCmd(),
+		newProposeSetWorkerCmd(),
+		newProposeSetControlCmd(),
+		newProposeChangeBeneficiaryCmd(),
+	)
+
+	return cmd
+}
+
+// newProposalCmd creates a propose subcommand wrapping the message built by build in a proposal
+func newProposalCmd(cmd *cobra.Command, build innerBuilder) *cobra.Command {
+	var from string
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := address.Validate(from); err != nil {
+			return fmt.Errorf("invalid --from: %v", err)
+		}
+
+		client, err := lotus.NewFromEnv()
+		if err != nil {
+			return fmt.Errorf("failed to create Lotus client: %v", err)
+		}
+
+		inner, err := build(cmd.Context(), client, args)
+		if err != nil {
+			return fmt.Errorf("failed to build proposed message: %w", err)
+		}
+		msg, err := client.BuildProposeMessage(cmd.Context(), args[0], from, inner)
+		if err != nil {
+			return fmt.Errorf("failed to build proposal: %w", err)
+		}
+		return message.WriteMessage(cmd, client, msg)
+	}
+
+	addFromFlag(cmd, &from)
+	message.AddOutFlag(cmd)
+
+	return cmd
+}
+
+func newProposeSendCmd() *cobra.Command {
+	return newProposalCmd(&cobra.Command{
+		Use:   "send [msig] [to] [amount]",
+		Short: "Propose sending FIL from the multisig",
+		Args:  cobra.MatchAll(cobra.ExactArgs(3), address.AddressArgsAt(0, 1)),
+	}, func(ctx context.Context, client *lotus.Client, args []string) (*lotus.UnsignedMessage, error) {
+		amount, err := units.ParseFIL(args[2])
+		if err != nil {
+			return nil, err
+		}
+		return client.BuildSendMessage(ctx, args[0], args[1], amount.Atto())
+	})
+}
+
+func newProposeWithdrawCmd() *cobra.Command {
+	return newProposalCmd(&cobra.Command{
+		Use:   "withdraw [msig] [miner_id] [amount]",
+		Short: "Propose withdrawing the available balance of a miner",
+		Long: `Propose withdrawing from a miner whose owner or beneficiary is the multisig. Without an
+amount, the whole available balance is withdrawn at the time the proposal is built.
+
+Example:
+  thctl fil msig propose withdraw f080 f01234 100 --from f1signer... --out propose.json`,
+		Args: cobra.MatchAll(cobra.RangeArgs(2, 3), address.AddressArgsAt(0), address.MinerArgs(1)),
+	}, func(ctx context.Context, client *lotus.Client, args []string) (*lotus.UnsignedMessage, error) {
+		var amount *big.Int
+		if len(args) > 2 {
+			requested, err := units.ParseFIL(args[2])
+			if err != nil {
+				return nil, err
+			}
+			amount = requested.Atto()
+		}
+		return client.BuildWithdrawMessage(ctx, args[1], amount, args[0])
+	})
+}
+
+func newProposeSetOwnerCmd() *cobra.Command {
+	var confirm bool
+
+	cmd := newProposalCmd(&cobra.Command{
+		Use:   "set-owner [msig] [miner_id] [new_owner]",
+		Short: "Propose changing the owner of a miner",
+		Long: `Propose changing the owner of a miner owned by the multisig. When the multisig is the new
+owner, use --confirm without a new owner to propose accepting the change.`,
+		Args: cobra.MatchAll(cobra.RangeArgs(2, 3), address.AddressArgsAt(0, 2), address.MinerArgs(1)),
+	}, func(ctx context.Context, client *lotus.Client, args []string) (*lotus.UnsignedMessage, error) {
+		if confirm != (len(args) == 2) {
+			return nil, fmt.Errorf("a new owner address is required, unless confirming with --confirm")
+		}
+		if confirm {
+			return client.BuildChangeOwnerMessage(ctx, args[1], args[0], true)
+		}
+		return client.BuildChangeOwnerMessage(ctx, args[1], args[2], false)
+	})
+
+	cmd.Flags().BoolVar(&confirm, "confirm", false, "Propose accepting the owner change as the new owner")
+
+	return cmd
+}
+
+func newProposeSetWorkerCmd() *cobra.Command {
+	var confirm bool
+
+	cmd := newProposalCmd(&cobra.Command{
+		Use:   "set-worker [msig] [miner_id] [new_worker]",
+		Short: "Propose changing the worker of a miner",
+		Long: `Propose changing the worker of a miner owned by the multisig, keeping the control
+addresses. Once the worker change epoch has been reached, propose confirming it with --confirm.`,
+		Args: cobra.MatchAll(cobra.RangeArgs(2, 3), address.AddressArgsAt(0, 2), address.MinerArgs(1)),
+	}, func(ctx context.Context, client *lotus.Client, args []string) (*lotus.UnsignedMessage, error) {
+		if confirm != (len(args) == 2) {
+			return nil, fmt.Errorf("a new worker address is required, unless confirming with --confirm")
+		}
+		if confirm {
+			return client.BuildConfirmWorkerMessage(ctx, args[1])
+		}
+		return client.BuildChangeWorkerMessage(ctx, args[1], args[2])
+	})
+
+	cmd.Flags().BoolVar(&confirm, "confirm", false, "Propose confirming the pending worker change")
+
+	return cmd
+}
+
+func newProposeSetControlCmd() *cobra.Command {
+	return newProposalCmd(&cobra.Command{
+		Use:   "set-control [msig] [miner_id] [address...]",
+		Short: "Propose replacing the control addresses of a miner",
+		Args:  cobra.MatchAll(cobra.MinimumNArgs(2), address.AddressArgsAt(0), address.MinerArgs(1), address.AddressArgs(2)),
+	}, func(ctx context.Context, client *lotus.Client, args []string) (*lotus.UnsignedMessage, error) {
+		return client.BuildChangeControlMessage(ctx, args[1], args[2:])
+	})
+}
+
+func newProposeChangeBeneficiaryCmd() *cobra.Command {
+	var (
+		quota      string
+		expiration int64
+		confirm    bool
+	)
+
+	cmd := newProposalCmd(&cobra.Command{
+		Use:   "change-beneficiary [msig] [miner_id] [beneficiary]",
+		Short: "Propose changing the beneficiary of a miner",
+		Long: `Propose changing the beneficiary of a miner owned by the multisig. When the multisig is
+the new beneficiary, use --confirm without a beneficiary to propose accepting the change
+with the same quota and expiration.`,
+		Args: cobra.MatchAll(cobra.RangeArgs(2, 3), address.AddressArgsAt(0, 2), address.MinerArgs(1)),
+	}, func(ctx context.Context, client *lotus.Client, args []string) (*lotus.UnsignedMessage, error) {
+		if confirm != (len(args) == 2) {
+			return nil, fmt.Errorf("a beneficiary address is required, unless confirming with --confirm")
+		}
+		amount, err := units.ParseFIL(quota)
+		if err != nil {
+			return nil, err
+		}
+		beneficiary := args[0]
+		if !confirm {
+			beneficiary = args[2]
+		}
+		return client.BuildChangeBeneficiaryMessage(ctx, args[1], beneficiary, amount.Atto(), expiration, confirm)
+	})
+
+	cmd.Flags().StringVar(&quota, "quota", "0", "Amount the beneficiary may withdraw")
+	cmd.Flags().Int64Var(&expiration, "expiration", 0, "Epoch at which the beneficiary term ends")
+	cmd.Flags().BoolVar(&confirm, "confirm", false, "Propose accepting the beneficiary change as the new beneficiary")
+
+	return cmd
+}
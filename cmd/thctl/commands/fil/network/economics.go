@@ -0,0 +1,183 @@
+package network
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/internal/lotus"
+	"github.com/THCloudAI/thctl/internal/units"
+	"github.com/THCloudAI/thctl/pkg/framework/output"
+)
+
+// NewEconomicsCmd creates a new economics command
+func NewEconomicsCmd() *cobra.Command {
+	var (
+		opts       lotus.EconomicsOptions
+		add        string
+		verified   bool
+		sectorSize string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "economics",
+		Short: "Show the initial pledge, rewards and gas of adding sectors",
+		Long: `Show what adding sectors costs and earns at the current height:
+- Initial pledge per 32GiB and 64GiB sector, committed capacity (CC) and verified
+- Expected block rewards per TiB of quality adjusted power per day
+- Circulating supply, network power and base fee
+- Gas used per sector by the pre-commit and prove-commit messages of recent tipsets
+
+Pledge and rewards are computed from the smoothed estimates of the reward and power
+actors, the way the miner actor computes pledge at pre-commit. Both depend on the
+network and change every epoch.
+
+With --add, a what-if calculation shows the sectors, pledge, gas and expected rewards
+of adding that much capacity, assuming the current reward rate holds over the whole
+commitment.
+
+Examples:
+  # Show pledge and rewards for sectors committed for 540 days
+  thctl fil network economics -o table
+
+  # What adding 100 TiB of verified deals in 64GiB sectors costs and earns
+  thctl fil network economics --add 100TiB --verified --sector-size 64GiB -o table`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if add != "" {
+				capacity, err := units.ParseBytes(add)
+				if err != nil {
+					return fmt.Errorf("invalid --add: %v", err)
+				}
+				size, err := units.ParseBytes(sectorSize)
+				if err != nil {
+					return fmt.Errorf("invalid --sector-size: %v", err)
+				}
+				if s := size.Int().Uint64(); s != lotus.SectorSize32GiB && s != lotus.SectorSize64GiB {
+					return fmt.Errorf("unsupported sector size: %s (use 32GiB or 64GiB)", sectorSize)
+				}
+				if capacity.Int().Sign() <= 0 {
+					return fmt.Errorf("capacity to add must be positive")
+				}
+				opts.WhatIf = &lotus.WhatIfOptions{
+					Capacity:   capacity.Int(),
+					Verified:   verified,
+					SectorSize: size.Int().Uint64(),
+				}
+			}
+
+			client, err := lotus.NewFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create Lotus client: %v", err)
+			}
+
+			economics, err := client.GetNetworkEconomics(cmd.Context(), opts)
+			if err != nil {
+				return fmt.Errorf("failed to get network economics: %w", err)
+			}
+
+			resp := &lotus.Response{
+				Version:   "1.0",
+				Timestamp: time.Now().Unix(),
+				Status:    "success",
+				Data:      economics,
+				Endpoints: client.ServedBy(),
+			}
+
+			format, _ := cmd.Flags().GetString("output")
+			switch format {
+			case "json":
+				return output.JSON(resp)
+			case "yaml":
+				return output.YAML(resp)
+			case "table":
+				printEconomicsTable(economics)
+			default:
+				return fmt.Errorf("unsupported output format: %s", format)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().Int64Var(&opts.DurationDays, "duration", 540, "Commitment of new sectors in days")
+	cmd.Flags().IntVar(&opts.GasTipsets, "gas-tipsets", 60, "Number of recent tipsets scanned for sector onboarding gas")
+	cmd.Flags().StringVar(&add, "add", "", "Capacity to add in the what-if calculation (e.g. 100TiB)")
+	cmd.Flags().BoolVar(&verified, "verified", false, "Fill the added capacity with verified deals instead of CC")
+	cmd.Flags().StringVar(&sectorSize, "sector-size", "32GiB", "Sector size of the added capacity: 32GiB or 64GiB")
+	cmd.Flags().StringP("output", "o", "json", "Output format: json, yaml, or table")
+
+	return cmd
+}
+
+func printEconomicsTable(e *lotus.NetworkEconomics) {
+	fmt.Printf("\n💰 Network Economics at height %d (network version %d)\n", e.Height, e.NetworkVersion)
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Attribute", "Value"})
+	t.AppendRow(table.Row{"Circulating Supply", e.CirculatingSupply.String()})
+	t.AppendRow(table.Row{"Network Raw Byte Power", e.NetworkRawBytePower.String()})
+	t.AppendRow(table.Row{"Network Quality Adj. Power", e.NetworkQualityAdjPower.String()})
+	t.AppendRow(table.Row{"Baseline Power", e.BaselinePower.String()})
+	t.AppendRow(table.Row{"Block Reward per Epoch", e.RewardPerEpoch.String()})
+	t.AppendRow(table.Row{"Reward per QA TiB per Day", e.RewardPerTiBPerDay.String()})
+	t.AppendRow(table.Row{"Base Fee", formatGasPrice(e.BaseFee)})
+	fmt.Println(t.Render())
+
+	fmt.Printf("\n🧱 New Sectors (committed for %d days):\n", e.DurationDays)
+	t = table.NewWriter()
+	t.AppendHeader(table.Row{"Sector Size", "Type", "QA Power", "Initial Pledge", "Pledge per TiB", "Reward per Day"})
+	for _, sector := range e.Sectors {
+		t.AppendRow(table.Row{
+			sector.SectorSize.String(),
+			sectorType(sector.Verified),
+			sector.QualityAdjPower.String(),
+			sector.InitialPledge.String(),
+			sector.PledgePerTiB.String(),
+			sector.RewardPerDay.String(),
+		})
+	}
+	fmt.Println(t.Render())
+
+	fmt.Printf("\n⛽ Onboarding Gas (last %d tipsets, %d messages):\n", e.Gas.Tipsets, e.Gas.Messages)
+	t = table.NewWriter()
+	t.AppendHeader(table.Row{"Attribute", "Value"})
+	t.AppendRow(table.Row{"Pre-Commit Gas per Sector", fmt.Sprintf("%d (%d sectors)", e.Gas.PreCommitGasPerSector, e.Gas.PreCommitSectors)})
+	t.AppendRow(table.Row{"Prove-Commit Gas per Sector", fmt.Sprintf("%d (%d sectors)", e.Gas.ProveCommitGasPerSector, e.Gas.ProveCommitSectors)})
+	t.AppendRow(table.Row{"Gas Cost per Sector", e.Gas.CostPerSector.String()})
+	fmt.Println(t.Render())
+	if e.Gas.Estimated {
+		fmt.Println("ℹ️ Some onboarding messages did not appear in the scanned tipsets; the miner actor's gas estimates are used for them.")
+	}
+
+	if w := e.WhatIf; w != nil {
+		fmt.Printf("\n🔮 What If: adding %s of %s in %s sectors\n", w.Capacity, sectorType(w.Verified), w.SectorSize)
+		t = table.NewWriter()
+		t.AppendHeader(table.Row{"Attribute", "Value"})
+		t.AppendRow(table.Row{"Sectors", w.Sectors})
+		t.AppendRow(table.Row{"Quality Adjusted Power", w.QualityAdjPower.String()})
+		t.AppendRow(table.Row{"Network Share", fmt.Sprintf("%.6f%%", w.NetworkShare*100)})
+		t.AppendRow(table.Row{"Initial Pledge", w.InitialPledge.String()})
+		t.AppendRow(table.Row{"Gas Cost", w.GasCost.String()})
+		t.AppendRow(table.Row{"Expected Reward per Day", w.RewardPerDay.String()})
+		t.AppendRow(table.Row{fmt.Sprintf("Expected Reward over %d Days", w.DurationDays), w.RewardOverDuration.String()})
+		t.AppendRow(table.Row{"Net Reward / Pledge", fmt.Sprintf("%.2f%%", w.PledgeReturn*100)})
+		fmt.Println(t.Render())
+	}
+}
+
+// sectorType names sectors by whether they hold verified deals
+func sectorType(verified bool) string {
+	if verified {
+		return "verified"
+	}
+	return "CC"
+}
+
+// formatGasPrice formats a price per gas unit. These are far below one FIL, so they are
+// always shown in the largest unit in which they are at least one.
+func formatGasPrice(price units.FIL) string {
+	display := units.CurrentDisplay()
+	display.AutoFIL = true
+	return display.FIL(price)
+}
@@ -0,0 +1,103 @@
+package network
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/internal/lotus"
+	"github.com/THCloudAI/thctl/pkg/framework/output"
+)
+
+// InfoResult represents the detected network and, optionally, its actor code CIDs
+type InfoResult struct {
+	lotus.Network `yaml:",inline"`
+	ActorCodes    map[string]string `json:"actorCodes,omitempty" yaml:"actorCodes,omitempty"`
+}
+
+// NewInfoCmd creates a new info command
+func NewInfoCmd() *cobra.Command {
+	var (
+		actors  bool
+		version uint64
+	)
+
+	cmd := &cobra.Command{
+		Use:   "info",
+		Short: "Show the network the Lotus node follows",
+		Long: `Show the network detected from the Lotus node and its constants: network version,
+genesis time, epoch duration and address prefix. Commands use these to convert epochs
+to times and to print addresses and amounts the way the network expects.
+
+With --actors the code CIDs of the built-in actors are listed as well, for the current
+network version or the one given with --network-version.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := lotus.NewFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create Lotus client: %v", err)
+			}
+
+			network, err := client.Network(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			result := InfoResult{Network: *network}
+			if actors || cmd.Flags().Changed("network-version") {
+				if !cmd.Flags().Changed("network-version") {
+					version = network.Version
+				}
+				if result.ActorCodes, err = client.ActorCodes(cmd.Context(), version); err != nil {
+					return err
+				}
+			}
+
+			format, _ := cmd.Flags().GetString("output")
+			switch format {
+			case "json":
+				return output.JSON(result)
+			case "yaml":
+				return output.YAML(result)
+			case "table":
+				printInfoTable(result, version)
+			default:
+				return fmt.Errorf("unsupported output format: %s", format)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&actors, "actors", false, "List the code CIDs of the built-in actors")
+	cmd.Flags().Uint64Var(&version, "network-version", 0, "Network version to list actor code CIDs for (default: current)")
+	cmd.Flags().StringP("output", "o", "table", "Output format: json, yaml, or table")
+
+	return cmd
+}
+
+func printInfoTable(result InfoResult, version uint64) {
+	fmt.Printf("\n🌐 Network %s\n", result.Name)
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Attribute", "Value"})
+	t.AppendRow(table.Row{"Network Version", result.Version})
+	t.AppendRow(table.Row{"Testnet", result.Testnet})
+	t.AppendRow(table.Row{"Address Prefix", result.AddressPrefix})
+	t.AppendRow(table.Row{"Genesis Time", time.Unix(result.GenesisTimestamp, 0).UTC().Format(time.RFC3339)})
+	t.AppendRow(table.Row{"Epoch Duration", fmt.Sprintf("%ds", result.BlockDelaySecs)})
+	t.AppendRow(table.Row{"Epochs Per Day", result.EpochsPerDay()})
+	fmt.Println(t.Render())
+
+	if len(result.ActorCodes) == 0 {
+		return
+	}
+
+	fmt.Printf("\n🧩 Actor Code CIDs (network version %d):\n", version)
+	t = table.NewWriter()
+	t.AppendHeader(table.Row{"Actor", "Code CID"})
+	for _, name := range lotus.ActorNames(result.ActorCodes) {
+		t.AppendRow(table.Row{name, result.ActorCodes[name]})
+	}
+	fmt.Println(t.Render())
+}
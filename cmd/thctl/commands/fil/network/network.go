@@ -0,0 +1,33 @@
+package network
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// NewNetworkCmd creates a new network command
+func NewNetworkCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "network",
+		Short: "Get Filecoin network information",
+		Long: `Get information about the Filecoin network as a whole.
+
+Examples:
+  # Show the network the Lotus node follows
+  thctl fil network info
+
+  # Show the top 50 miners by quality adjusted power
+  thctl fil network top --n 50
+
+  # Show the initial pledge and expected rewards of new sectors
+  thctl fil network economics -o table`,
+	}
+
+	// Add subcommands
+	cmd.AddCommand(
+		NewInfoCmd(),
+		NewTopCmd(),
+		NewEconomicsCmd(),
+	)
+
+	return cmd
+}
@@ -0,0 +1,163 @@
+package network
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/internal/cache"
+	"github.com/THCloudAI/thctl/internal/lotus"
+	"github.com/THCloudAI/thctl/internal/units"
+	"github.com/THCloudAI/thctl/pkg/framework/output"
+)
+
+// TopResult represents the network power leaderboard
+type TopResult struct {
+	Height                 uint64                 `json:"height"`
+	Timestamp              int64                  `json:"timestamp"`
+	By                     string                 `json:"by"`
+	TotalMiners            int                    `json:"totalMiners"`
+	NetworkRawBytePower    string                 `json:"networkRawBytePower"`
+	NetworkQualityAdjPower string                 `json:"networkQualityAdjPower"`
+	Miners                 []lotus.MinerPowerRank `json:"miners"`
+}
+
+// NewTopCmd creates a new top command
+func NewTopCmd() *cobra.Command {
+	var (
+		n       int
+		by      string
+		maxAge  uint64
+		noCache bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "top",
+		Short: "Show the network power leaderboard",
+		Long: `Rank all miners with power by raw byte or quality adjusted power.
+
+Computing the ranking lists every miner on the network, so the result is cached
+per tipset and reused until it is older than --max-age epochs.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if by != "qap" && by != "raw" {
+				return fmt.Errorf("unsupported ranking: %s (use qap or raw)", by)
+			}
+
+			client, err := lotus.NewFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create Lotus client: %v", err)
+			}
+
+			var store *cache.Store
+			if !noCache {
+				store = client.CacheStore(cmd.Context(), "network")
+			}
+
+			ranking, err := client.GetPowerRanking(cmd.Context(), store, maxAge)
+			if err != nil {
+				return fmt.Errorf("failed to get power ranking: %w", err)
+			}
+
+			result := TopResult{
+				Height:                 ranking.Height,
+				Timestamp:              ranking.Timestamp,
+				By:                     by,
+				TotalMiners:            len(ranking.Miners),
+				NetworkRawBytePower:    ranking.NetworkRawBytePower,
+				NetworkQualityAdjPower: ranking.NetworkQualityAdjPower,
+				Miners:                 topMiners(ranking.Miners, by, n),
+			}
+
+			resp := &lotus.Response{
+				Version:   "1.0",
+				Timestamp: time.Now().Unix(),
+				Status:    "success",
+				Data:      result,
+				Endpoints: client.ServedBy(),
+			}
+
+			format, _ := cmd.Flags().GetString("output")
+			switch format {
+			case "json":
+				return output.JSON(resp)
+			case "yaml":
+				return output.YAML(resp)
+			case "table":
+				printTopTable(result)
+			default:
+				return fmt.Errorf("unsupported output format: %s", format)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&n, "n", 50, "Number of miners to show")
+	cmd.Flags().StringVar(&by, "by", "qap", "Rank by quality adjusted (qap) or raw byte (raw) power")
+	cmd.Flags().Uint64Var(&maxAge, "max-age", lotus.DefaultRankingMaxAge, "Recompute the cached ranking when it is older than this many epochs")
+	cmd.Flags().BoolVar(&noCache, "no-cache", false, "Do not read or write the local ranking cache")
+	cmd.Flags().StringP("output", "o", "json", "Output format: json, yaml, or table")
+
+	return cmd
+}
+
+// topMiners returns the n highest ranked miners by the selected power
+func topMiners(miners []lotus.MinerPowerRank, by string, n int) []lotus.MinerPowerRank {
+	sorted := make([]lotus.MinerPowerRank, len(miners))
+	copy(sorted, miners)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if by == "raw" {
+			return sorted[i].RawBytePowerRank < sorted[j].RawBytePowerRank
+		}
+		return sorted[i].QualityAdjPowerRank < sorted[j].QualityAdjPowerRank
+	})
+
+	if n > 0 && n < len(sorted) {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+func printTopTable(result TopResult) {
+	fmt.Printf("\n🏆 Network Power Leaderboard at height %d (%d miners with power)\n", result.Height, result.TotalMiners)
+
+	networkPower := result.NetworkQualityAdjPower
+	if result.By == "raw" {
+		networkPower = result.NetworkRawBytePower
+	}
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Rank", "Miner", "Quality Adjusted Power", "Raw Power", "Share"})
+	for _, m := range result.Miners {
+		rank, power := m.QualityAdjPowerRank, m.QualityAdjPower
+		if result.By == "raw" {
+			rank, power = m.RawBytePowerRank, m.RawBytePower
+		}
+		t.AppendRow(table.Row{
+			rank,
+			m.Miner,
+			units.BytesFromString(m.QualityAdjPower).String(),
+			units.BytesFromString(m.RawBytePower).String(),
+			fmt.Sprintf("%.4f%%", powerShare(power, networkPower)*100),
+		})
+	}
+	fmt.Println(t.Render())
+}
+
+// powerShare returns power as a fraction of networkPower
+func powerShare(power, networkPower string) float64 {
+	p, ok := new(big.Float).SetString(power)
+	if !ok {
+		return 0
+	}
+	np, ok := new(big.Float).SetString(networkPower)
+	if !ok || np.Sign() == 0 {
+		return 0
+	}
+	share, _ := new(big.Float).Quo(p, np).Float64()
+	return share
+}
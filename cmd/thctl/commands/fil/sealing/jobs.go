@@ -0,0 +1,132 @@
+package sealing
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/internal/lotus"
+	"github.com/THCloudAI/thctl/pkg/framework/output"
+)
+
+// NewJobsCmd creates a new jobs command
+func NewJobsCmd() *cobra.Command {
+	var task string
+
+	cmd := &cobra.Command{
+		Use:   "jobs",
+		Short: "Show the sealing jobs of every worker",
+		Long: `Show the sealing jobs running or queued on every worker of the miner, with how long
+they have been running. Running jobs are listed first, oldest first.
+
+Examples:
+  # Show all jobs
+  thctl fil sealing jobs
+
+  # Show only PreCommit1 jobs
+  thctl fil sealing jobs --task PC1`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := lotus.NewStorageMinerFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create lotus-miner client: %v", err)
+			}
+
+			jobs, err := client.GetSealingJobs(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to get sealing jobs: %w", err)
+			}
+			if task != "" {
+				jobs = filterJobs(jobs, task)
+			}
+
+			resp := &lotus.Response{
+				Version:   "1.0",
+				Timestamp: time.Now().Unix(),
+				Status:    "success",
+				Data:      jobs,
+			}
+
+			format, _ := cmd.Flags().GetString("output")
+			switch format {
+			case "json":
+				return output.JSON(resp)
+			case "yaml":
+				return output.YAML(resp)
+			case "table":
+				printJobsTable(jobs)
+			default:
+				return fmt.Errorf("unsupported output format: %s", format)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&task, "task", "", "Only show jobs of a task, by short name (e.g. PC1) or task type")
+	cmd.Flags().StringP("output", "o", "table", "Output format: json, yaml, or table")
+
+	return cmd
+}
+
+// filterJobs keeps the jobs of a task and recounts them
+func filterJobs(jobs *lotus.SealingJobs, task string) *lotus.SealingJobs {
+	filtered := &lotus.SealingJobs{MinerID: jobs.MinerID, Jobs: make([]lotus.SealingJob, 0)}
+	for _, job := range jobs.Jobs {
+		if job.Task != task && job.TaskType != task {
+			continue
+		}
+		switch {
+		case job.State == "running":
+			filtered.Running++
+		case job.State == "prepared" || strings.HasPrefix(job.State, "assigned"):
+			filtered.Queued++
+		}
+		filtered.Jobs = append(filtered.Jobs, job)
+	}
+	return filtered
+}
+
+func printJobsTable(jobs *lotus.SealingJobs) {
+	fmt.Printf("\n⚙️  Sealing jobs of %s: %d running, %d queued\n", jobs.MinerID, jobs.Running, jobs.Queued)
+
+	if len(jobs.Jobs) == 0 {
+		fmt.Println("No sealing jobs")
+		return
+	}
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Sector", "Task", "State", "Worker", "Hostname", "Time"})
+	for _, job := range jobs.Jobs {
+		elapsed := "-"
+		if !job.Start.IsZero() {
+			elapsed = (time.Duration(job.ElapsedSeconds) * time.Second).String()
+		}
+		t.AppendRow(table.Row{
+			job.Sector,
+			job.Task,
+			job.State,
+			shortID(job.WorkerID),
+			valueOrDash(job.Hostname),
+			elapsed,
+		})
+	}
+	fmt.Println(t.Render())
+}
+
+// shortID returns the first characters of a worker UUID, as lotus-miner shows it
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// valueOrDash returns the value or "-" when it is empty
+func valueOrDash(v string) string {
+	if v == "" {
+		return "-"
+	}
+	return v
+}
@@ -0,0 +1,32 @@
+package sealing
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// NewSealingCmd creates a new sealing command
+func NewSealingCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sealing",
+		Short: "Inspect the sealing jobs and workers of a miner",
+		Long: `Inspect the sealing jobs and workers of a miner through its lotus-miner API.
+
+The API is read from LOTUS_MINER_API_URL and LOTUS_MINER_API_TOKEN, or from
+MINER_API_INFO as set for the lotus-miner CLI.
+
+Examples:
+  # Show the jobs running and queued on every worker
+  thctl fil sealing jobs
+
+  # Show the workers with their CPU, GPU and memory use
+  thctl fil sealing workers`,
+	}
+
+	// Add subcommands
+	cmd.AddCommand(
+		NewJobsCmd(),
+		NewWorkersCmd(),
+	)
+
+	return cmd
+}
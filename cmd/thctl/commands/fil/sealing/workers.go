@@ -0,0 +1,132 @@
+package sealing
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/internal/lotus"
+	"github.com/THCloudAI/thctl/internal/units"
+	"github.com/THCloudAI/thctl/pkg/framework/output"
+)
+
+// NewWorkersCmd creates a new workers command
+func NewWorkersCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "workers",
+		Short: "Show the sealing workers and their resources",
+		Long: `Show the sealing workers of the miner with their CPU, GPU and memory use, the
+tasks they accept and the tasks they run.
+
+Memory is the physical memory in use plus the memory reserved by the tasks running
+on the worker, as the lotus-miner scheduler accounts it.
+
+Examples:
+  # Show the workers
+  thctl fil sealing workers
+
+  # Structured result for monitoring
+  thctl fil sealing workers -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := lotus.NewStorageMinerFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create lotus-miner client: %v", err)
+			}
+
+			workers, err := client.GetSealingWorkers(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to get sealing workers: %w", err)
+			}
+
+			resp := &lotus.Response{
+				Version:   "1.0",
+				Timestamp: time.Now().Unix(),
+				Status:    "success",
+				Data:      workers,
+			}
+
+			format, _ := cmd.Flags().GetString("output")
+			switch format {
+			case "json":
+				return output.JSON(resp)
+			case "yaml":
+				return output.YAML(resp)
+			case "table":
+				printWorkersTable(workers)
+			default:
+				return fmt.Errorf("unsupported output format: %s", format)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("output", "o", "table", "Output format: json, yaml, or table")
+
+	return cmd
+}
+
+func printWorkersTable(workers *lotus.SealingWorkers) {
+	fmt.Printf("\n👷 Sealing workers of %s (%d)\n", workers.MinerID, len(workers.Workers))
+
+	if len(workers.Workers) == 0 {
+		fmt.Println("No workers connected")
+		return
+	}
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Worker", "Hostname", "Enabled", "CPU", "GPU", "Memory", "Running", "Accepts"})
+	for _, w := range workers.Workers {
+		enabled := "✅"
+		if !w.Enabled {
+			enabled = "❌"
+		}
+		gpu := "-"
+		if len(w.GPUs) > 0 {
+			gpu = fmt.Sprintf("%.1f / %d", w.GPUsUsed, len(w.GPUs))
+		}
+		t.AppendRow(table.Row{
+			shortID(w.ID),
+			w.Hostname,
+			enabled,
+			fmt.Sprintf("%d / %d", w.CPUsUsed, w.CPUs),
+			gpu,
+			fmt.Sprintf("%s / %s (%.0f%%)", sizeString(w.MemUsed+w.MemReservedMin), sizeString(w.MemPhysical), w.MemUsedPercent()),
+			taskCounts(w.TaskCounts),
+			strings.Join(w.Tasks, " "),
+		})
+	}
+	fmt.Println(t.Render())
+
+	for _, w := range workers.Workers {
+		if len(w.GPUs) > 0 {
+			fmt.Printf("\n🎮 GPUs of %s:\n  %s\n", w.Hostname, strings.Join(w.GPUs, "\n  "))
+		}
+	}
+}
+
+// taskCounts formats the number of running tasks of each type, as "PC1:3 PC2:1"
+func taskCounts(counts map[string]int) string {
+	if len(counts) == 0 {
+		return "-"
+	}
+	tasks := make([]string, 0, len(counts))
+	for task := range counts {
+		tasks = append(tasks, task)
+	}
+	sort.Strings(tasks)
+	parts := make([]string, len(tasks))
+	for i, task := range tasks {
+		parts[i] = fmt.Sprintf("%s:%d", task, counts[task])
+	}
+	return strings.Join(parts, " ")
+}
+
+// sizeString formats a size in bytes with the units of table output
+func sizeString(n uint64) string {
+	return units.NewBytes(new(big.Int).SetUint64(n)).String()
+}
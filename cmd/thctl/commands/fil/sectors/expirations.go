@@ -0,0 +1,91 @@
+package sectors
+
+import (
+	"fmt"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/internal/lotus"
+	"github.com/THCloudAI/thctl/pkg/output"
+)
+
+// NewExpirationsCmd creates a new expirations command
+func NewExpirationsCmd() *cobra.Command {
+	var (
+		minerID string
+		format  string
+		bucket  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "expirations",
+		Short: "Show when sectors expire",
+		Long: `Group the sectors of a miner by the day, week or month in which they expire,
+with the raw and quality adjusted power and the initial pledge expiring in each period.
+
+Examples:
+  # Sector expirations per month
+  thctl fil sectors expirations --miner f01234
+
+  # Sector expirations per week as a table
+  thctl fil sectors expirations --miner f01234 --bucket week -f table`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Create Lotus client
+			client, err := lotus.NewFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create Lotus client: %v", err)
+			}
+
+			calendar, err := client.GetExpirationCalendar(cmd.Context(), minerID, bucket)
+			if err != nil {
+				return fmt.Errorf("failed to get sector expirations: %v", err)
+			}
+
+			if output.Format(format) == output.FormatTable {
+				printExpirationsTable(calendar)
+				return nil
+			}
+
+			// Print output
+			if err := output.Print(calendar, output.Format(format)); err != nil {
+				return fmt.Errorf("failed to print output: %v", err)
+			}
+
+			return nil
+		},
+	}
+
+	// Add flags
+	cmd.Flags().StringVarP(&minerID, "miner", "m", "", "Miner ID (required)")
+	cmd.Flags().StringVarP(&format, "format", "f", "json", "Output format (json|yaml|table)")
+	cmd.Flags().StringVar(&bucket, "bucket", lotus.BucketMonth, "Group expirations by day, week or month")
+
+	// Mark required flags
+	cmd.MarkFlagRequired("miner")
+
+	return cmd
+}
+
+func printExpirationsTable(calendar *lotus.ExpirationCalendar) {
+	fmt.Printf("\n📅 Sector Expirations for %s (current epoch %d)\n", calendar.MinerID, calendar.CurrentEpoch)
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Period", "Epochs", "Sectors", "Raw Power", "QA Power", "Initial Pledge"})
+	for _, b := range calendar.Buckets {
+		t.AppendRow(table.Row{
+			b.Period,
+			fmt.Sprintf("%d - %d", b.StartEpoch, b.EndEpoch),
+			b.Sectors,
+			b.RawBytePower.String(),
+			b.QualityAdjPower.String(),
+			b.InitialPledge.String(),
+		})
+	}
+	t.AppendFooter(table.Row{
+		"Total", "", calendar.TotalSectors,
+		calendar.TotalRawBytePower.String(),
+		calendar.TotalQualityAdjPower.String(),
+		calendar.TotalInitialPledge.String(),
+	})
+	fmt.Println(t.Render())
+}
@@ -0,0 +1,150 @@
+package sectors
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/internal/lotus"
+	"github.com/THCloudAI/thctl/pkg/output"
+)
+
+// NewExtendPlanCmd creates a new extend-plan command
+func NewExtendPlanCmd() *cobra.Command {
+	var (
+		minerID       string
+		format        string
+		within        int64
+		extendDays    int64
+		newExpiration int64
+		maxSectors    uint64
+		outFile       string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "extend-plan",
+		Short: "Plan sector expiration extensions",
+		Long: `Propose ExtendSectorExpiration2 messages for the live, non-faulty sectors that expire
+within the given number of days. The new expiration of each sector is lowered to what the
+miner actor allows: the maximum extension, the sector's maximum lifetime and the terms of
+its verified claims. Sectors that cannot be extended are listed with the reason.
+
+Messages are split to respect the per-message sector and declaration limits and are emitted
+as unsigned message JSON for review. Nothing is signed or pushed; nonce and gas are left empty.
+
+Examples:
+  # Extend sectors expiring in the next 60 days by about 540 days
+  thctl fil sectors extend-plan --miner f01234
+
+  # Extend sectors expiring in the next 30 days to a fixed epoch and save the messages
+  thctl fil sectors extend-plan --miner f01234 --within 30 --new-expiration 5500000 --out extend.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Create Lotus client
+			client, err := lotus.NewFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create Lotus client: %v", err)
+			}
+
+			head, err := client.GetChainHead(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to get chain head: %v", err)
+			}
+			current := int64(head.Height)
+
+			network, err := client.Network(cmd.Context())
+			if err != nil {
+				return err
+			}
+			epochsPerDay := network.EpochsPerDay()
+
+			opts := lotus.ExtensionOptions{
+				ExpiringBefore: current + within*epochsPerDay,
+				NewExpiration:  current + extendDays*epochsPerDay,
+				MaxSectors:     maxSectors,
+			}
+			if cmd.Flags().Changed("new-expiration") {
+				opts.NewExpiration = newExpiration
+			}
+
+			plan, err := client.PlanSectorExtensions(cmd.Context(), minerID, opts)
+			if err != nil {
+				return fmt.Errorf("failed to plan sector extensions: %v", err)
+			}
+
+			if outFile != "" {
+				messages := make([]lotus.UnsignedMessage, 0, len(plan.Messages))
+				for _, msg := range plan.Messages {
+					messages = append(messages, msg.Message)
+				}
+				data, err := json.MarshalIndent(messages, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to encode messages: %v", err)
+				}
+				if err := os.WriteFile(outFile, data, 0644); err != nil {
+					return fmt.Errorf("failed to write messages: %v", err)
+				}
+			}
+
+			if output.Format(format) == output.FormatTable {
+				printExtendPlanTable(plan)
+				return nil
+			}
+
+			// Print output
+			if err := output.Print(plan, output.Format(format)); err != nil {
+				return fmt.Errorf("failed to print output: %v", err)
+			}
+
+			return nil
+		},
+	}
+
+	// Add flags
+	cmd.Flags().StringVarP(&minerID, "miner", "m", "", "Miner ID (required)")
+	cmd.Flags().StringVarP(&format, "format", "f", "json", "Output format (json|yaml|table)")
+	cmd.Flags().Int64Var(&within, "within", 60, "Extend sectors expiring within this many days")
+	cmd.Flags().Int64Var(&extendDays, "extend-days", 540, "Target expiration in days from now")
+	cmd.Flags().Int64Var(&newExpiration, "new-expiration", 0, "Target expiration epoch (overrides --extend-days)")
+	cmd.Flags().Uint64Var(&maxSectors, "max-sectors", 0, "Maximum sectors per message (default: protocol limit)")
+	cmd.Flags().StringVar(&outFile, "out", "", "Write the unsigned messages as a JSON array to this file")
+
+	// Mark required flags
+	cmd.MarkFlagRequired("miner")
+
+	return cmd
+}
+
+func printExtendPlanTable(plan *lotus.ExtensionPlan) {
+	fmt.Printf("\n⏳ Extension Plan for %s (current epoch %d)\n", plan.MinerID, plan.CurrentEpoch)
+	fmt.Printf("  Sectors expiring before epoch %d, extended up to epoch %d\n", plan.ExpiringBefore, plan.NewExpiration)
+	fmt.Printf("  %d sectors in %d messages, %d skipped\n", plan.Sectors, len(plan.Messages), len(plan.Skipped))
+
+	if len(plan.Messages) > 0 {
+		fmt.Println("\n📨 Messages:")
+		t := table.NewWriter()
+		t.AppendHeader(table.Row{"#", "From", "Sectors", "Declarations", "Deadlines", "Params Size"})
+		for i, msg := range plan.Messages {
+			t.AppendRow(table.Row{i, msg.Message.From, msg.Sectors, msg.Declarations, fmt.Sprint(msg.Deadlines), len(msg.Message.Params)})
+		}
+		fmt.Println(t.Render())
+	}
+
+	if len(plan.Skipped) > 0 {
+		fmt.Println("\n⏭️ Skipped Sectors:")
+		t := table.NewWriter()
+		t.AppendHeader(table.Row{"Sector", "Expiration", "Reason"})
+		for _, s := range plan.Skipped {
+			t.AppendRow(table.Row{s.SectorNumber, s.Expiration, s.Reason})
+		}
+		fmt.Println(t.Render())
+	}
+
+	if len(plan.Warnings) > 0 {
+		fmt.Println("\n⚠️ Warnings:")
+		for _, warning := range plan.Warnings {
+			fmt.Printf("  - %s\n", warning)
+		}
+	}
+}
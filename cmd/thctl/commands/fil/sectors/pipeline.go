@@ -0,0 +1,115 @@
+package sectors
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/internal/lotus"
+	"github.com/THCloudAI/thctl/pkg/output"
+)
+
+// NewPipelineCmd creates a new pipeline command
+func NewPipelineCmd() *cobra.Command {
+	var (
+		format string
+		stuck  time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "pipeline",
+		Short: "Show the sectors in the sealing pipeline",
+		Long: `Count the sectors of the miner in each sealing state, and list the sectors still in
+the pipeline with how long they have been in their state. Sectors in a state for
+longer than --stuck, and sectors in a failed state, are flagged.
+
+This reads the lotus-miner API from LOTUS_MINER_API_URL and LOTUS_MINER_API_TOKEN,
+or from MINER_API_INFO, rather than the full node.
+
+Examples:
+  # Show the sealing pipeline
+  thctl fil sectors pipeline -f table
+
+  # Flag sectors in a state for more than 12 hours
+  thctl fil sectors pipeline -f table --stuck 12h`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := lotus.NewStorageMinerFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create lotus-miner client: %v", err)
+			}
+
+			pipeline, err := client.GetSealingPipeline(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to get sealing pipeline: %v", err)
+			}
+
+			if output.Format(format) == output.FormatTable {
+				printPipelineTable(pipeline, stuck)
+				return nil
+			}
+
+			// Print output
+			if err := output.Print(pipeline, output.Format(format)); err != nil {
+				return fmt.Errorf("failed to print output: %v", err)
+			}
+
+			return nil
+		},
+	}
+
+	// Add flags
+	cmd.Flags().StringVarP(&format, "format", "f", "json", "Output format (json|yaml|table)")
+	cmd.Flags().DurationVar(&stuck, "stuck", 24*time.Hour, "Flag sectors in the same state for longer than this")
+
+	return cmd
+}
+
+func printPipelineTable(pipeline *lotus.SealingPipeline, stuck time.Duration) {
+	fmt.Printf("\n🏭 Sealing pipeline of %s (%d sectors)\n", pipeline.MinerID, pipeline.Total)
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Stage", "State", "Sectors"})
+	for _, s := range pipeline.States {
+		state := s.State
+		if s.Stage == lotus.StageFailed {
+			state = "❌ " + state
+		}
+		t.AppendRow(table.Row{s.Stage, state, s.Count})
+	}
+	t.AppendFooter(table.Row{"Total", "", pipeline.Total})
+	fmt.Println(t.Render())
+
+	if len(pipeline.Sectors) == 0 {
+		fmt.Println("\nNo sectors in the sealing pipeline")
+		return
+	}
+
+	flagged := 0
+	t = table.NewWriter()
+	t.AppendHeader(table.Row{"Sector", "Stage", "State", "Deals", "Time in State", "Retries", "Last Error"})
+	for _, s := range pipeline.Sectors {
+		inState := time.Duration(s.TimeInStateSeconds) * time.Second
+		state := s.State
+		switch {
+		case s.Stage == lotus.StageFailed:
+			state = "❌ " + state
+			flagged++
+		case inState > stuck:
+			state = "⚠️ " + state
+			flagged++
+		}
+		lastErr := s.LastError
+		if lastErr == "" {
+			lastErr = "-"
+		}
+		t.AppendRow(table.Row{s.Number, s.Stage, state, s.Deals, inState.String(), s.Retries, lastErr})
+	}
+	fmt.Printf("\n🔧 Sectors in the pipeline (%d)\n", len(pipeline.Sectors))
+	fmt.Println(t.Render())
+
+	if flagged > 0 {
+		fmt.Printf("\n⚠️  %d sectors failed or have been in their state for more than %s\n", flagged, stuck)
+	}
+}
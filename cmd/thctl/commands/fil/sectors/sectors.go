@@ -2,6 +2,7 @@ package sectors
 
 import (
 	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/internal/address"
 )
 
 // NewSectorsCmd creates a new sectors command
@@ -25,7 +26,16 @@ Examples:
   thctl fil sectors penalty --miner f01234 --sector 1
 
   # Query vested funds
-  thctl fil sectors vested --miner f01234`,
+  thctl fil sectors vested --miner f01234
+
+  # Show sector expirations per month
+  thctl fil sectors expirations --miner f01234
+
+  # Plan extensions for sectors expiring in the next 60 days
+  thctl fil sectors extend-plan --miner f01234 --within 60
+
+  # Show the sealing pipeline from the lotus-miner API
+  thctl fil sectors pipeline -f table`,
 	}
 
 	// Add subcommands
@@ -35,7 +45,24 @@ Examples:
 		NewStatusCmd(),
 		NewPenaltyCmd(),
 		NewVestedCmd(),
+		NewExpirationsCmd(),
+		NewExtendPlanCmd(),
+		NewPipelineCmd(),
 	)
 
+	// Reject a malformed --miner before any subcommand contacts Lotus
+	for _, subcmd := range cmd.Commands() {
+		subcmd.PreRunE = validateMinerFlag
+	}
+
 	return cmd
 }
+
+// validateMinerFlag checks that the --miner flag holds a valid miner address
+func validateMinerFlag(cmd *cobra.Command, args []string) error {
+	minerID, err := cmd.Flags().GetString("miner")
+	if err != nil || minerID == "" {
+		return nil
+	}
+	return address.ValidateMiner(minerID)
+}
@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/internal/lotus"
+	"github.com/THCloudAI/thctl/internal/units"
+	"github.com/THCloudAI/thctl/pkg/framework/output"
+)
+
+// fullPercent is the usage above which a path is flagged as nearly full
+const fullPercent = 90
+
+// NewPathsCmd creates a new paths command
+func NewPathsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "paths",
+		Short: "Show the storage paths with their capacity and usage",
+		Long: `Show the sector storage paths attached to the miner, on the miner and on its
+workers, with what they may be used for, their capacity and usage, and the number of
+sealed and unsealed sectors they hold. Paths more than 90% full are flagged.
+
+Examples:
+  # Show the storage paths
+  thctl fil storage paths
+
+  # Structured result for monitoring
+  thctl fil storage paths -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := lotus.NewStorageMinerFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create lotus-miner client: %v", err)
+			}
+
+			paths, err := client.GetStoragePaths(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to get storage paths: %w", err)
+			}
+
+			resp := &lotus.Response{
+				Version:   "1.0",
+				Timestamp: time.Now().Unix(),
+				Status:    "success",
+				Data:      paths,
+			}
+
+			format, _ := cmd.Flags().GetString("output")
+			switch format {
+			case "json":
+				return output.JSON(resp)
+			case "yaml":
+				return output.YAML(resp)
+			case "table":
+				printPathsTable(paths)
+			default:
+				return fmt.Errorf("unsupported output format: %s", format)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("output", "o", "table", "Output format: json, yaml, or table")
+
+	return cmd
+}
+
+func printPathsTable(paths *lotus.StoragePaths) {
+	fmt.Printf("\n💾 Storage paths of %s (%d)\n", paths.MinerID, len(paths.Paths))
+
+	if len(paths.Paths) == 0 {
+		fmt.Println("No storage paths attached")
+		return
+	}
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"ID", "Use", "Location", "Capacity", "Used", "Available", "Usage", "Sealed", "Unsealed", "Weight"})
+	for _, p := range paths.Paths {
+		if p.Error != "" {
+			use := pathUse(p)
+			if len(p.URLs) == 0 {
+				// The path info itself could not be read
+				use = "-"
+			}
+			t.AppendRow(table.Row{shortID(p.ID), use, location(p), "-", "-", "-", "❌ " + p.Error, p.Sealed, p.Unsealed, "-"})
+			continue
+		}
+		usage := fmt.Sprintf("%.1f%%", p.UsedPercent)
+		if p.UsedPercent > fullPercent {
+			usage = "⚠️ " + usage
+		}
+		t.AppendRow(table.Row{
+			shortID(p.ID),
+			pathUse(p),
+			location(p),
+			sizeString(p.Capacity),
+			sizeString(p.Capacity - p.FSAvailable),
+			sizeString(p.Available),
+			usage,
+			p.Sealed,
+			p.Unsealed,
+			p.Weight,
+		})
+	}
+	var totalPercent float64
+	if paths.TotalCapacity > 0 {
+		totalPercent = float64(paths.TotalUsed) * 100 / float64(paths.TotalCapacity)
+	}
+	// Keep the case of the size units in the footer
+	t.Style().Format.Footer = text.FormatDefault
+	t.AppendFooter(table.Row{"Total", "", "", sizeString(paths.TotalCapacity), sizeString(paths.TotalUsed), "", fmt.Sprintf("%.1f%%", totalPercent)})
+	fmt.Println(t.Render())
+}
+
+// pathUse describes what a path may be used for
+func pathUse(p lotus.StoragePath) string {
+	switch {
+	case p.CanSeal && p.CanStore:
+		return "seal+store"
+	case p.CanSeal:
+		return "seal"
+	case p.CanStore:
+		return "store"
+	default:
+		return "readonly"
+	}
+}
+
+// location returns the local path of a path, or the URLs of the node it is attached to
+func location(p lotus.StoragePath) string {
+	if p.LocalPath != "" {
+		return p.LocalPath
+	}
+	if len(p.URLs) == 0 {
+		return "-"
+	}
+	return strings.Join(p.URLs, "\n")
+}
+
+// shortID returns the first characters of a path UUID, as lotus-miner shows it
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// sizeString formats a size in bytes with the units of table output
+func sizeString(n int64) string {
+	return units.NewBytes(big.NewInt(n)).String()
+}
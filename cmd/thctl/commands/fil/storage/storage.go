@@ -0,0 +1,28 @@
+package storage
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// NewStorageCmd creates a new storage command
+func NewStorageCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "storage",
+		Short: "Inspect the sector storage of a miner",
+		Long: `Inspect the sector storage paths of a miner through its lotus-miner API.
+
+The API is read from LOTUS_MINER_API_URL and LOTUS_MINER_API_TOKEN, or from
+MINER_API_INFO as set for the lotus-miner CLI.
+
+Examples:
+  # Show the storage paths with their capacity and usage
+  thctl fil storage paths`,
+	}
+
+	// Add subcommands
+	cmd.AddCommand(
+		NewPathsCmd(),
+	)
+
+	return cmd
+}
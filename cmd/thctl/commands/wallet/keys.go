@@ -0,0 +1,76 @@
+package wallet
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/internal/wallet"
+)
+
+func newNewCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "new [secp256k1|bls]",
+		Short: "Generate a new key",
+		Long:  `Generate a new secp256k1 (default) or BLS key and store it encrypted in the keystore.`,
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			keyType := wallet.KeyTypeSecp256k1
+			if len(args) > 0 {
+				keyType = args[0]
+			}
+
+			ki, err := wallet.GenerateKey(keyType)
+			if err != nil {
+				return err
+			}
+			return storeKey(ki)
+		},
+	}
+}
+
+func newImportCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import [file]",
+		Short: "Import a key",
+		Long: `Import a secp256k1 or BLS key in the format written by lotus wallet export
+(hex encoded) or as plain JSON. The key is read from stdin when no file is given.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var (
+				data []byte
+				err  error
+			)
+			if len(args) > 0 && args[0] != "-" {
+				data, err = os.ReadFile(args[0])
+			} else {
+				data, err = io.ReadAll(os.Stdin)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read key: %v", err)
+			}
+
+			ki, err := wallet.ParseKeyInfo(data)
+			if err != nil {
+				return err
+			}
+			return storeKey(ki)
+		},
+	}
+}
+
+// storeKey encrypts a key into the keystore and prints its address
+func storeKey(ki *wallet.KeyInfo) error {
+	passphrase, err := readPassphrase("Keystore passphrase: ", true)
+	if err != nil {
+		return err
+	}
+
+	addr, err := wallet.OpenKeystore().Put(ki, passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to store key: %v", err)
+	}
+	fmt.Println(addr)
+	return nil
+}
@@ -0,0 +1,42 @@
+package wallet
+
+import (
+	"fmt"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/internal/lotus"
+)
+
+func newPushCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "push [signed.json]",
+		Short: "Push signed messages to Lotus",
+		Long: `Verify the signed messages written by thctl wallet sign and push them to the
+Lotus message pool with MpoolPush, printing the CID of each pushed message.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			messages, _, err := readJSONList[types.SignedMessage](args[0])
+			if err != nil {
+				return err
+			}
+			if err := verifyMessages(messages); err != nil {
+				return fmt.Errorf("refusing to push: %v", err)
+			}
+
+			client, err := lotus.NewFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create Lotus client: %v", err)
+			}
+
+			for i := range messages {
+				cid, err := client.MpoolPush(cmd.Context(), &messages[i])
+				if err != nil {
+					return fmt.Errorf("failed to push message %d: %v", i, err)
+				}
+				fmt.Printf("📤 pushed %s\n", cid)
+			}
+			return nil
+		},
+	}
+}
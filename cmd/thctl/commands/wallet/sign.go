@@ -0,0 +1,118 @@
+package wallet
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/internal/wallet"
+)
+
+func newSignCmd() *cobra.Command {
+	var outFile string
+
+	cmd := &cobra.Command{
+		Use:   "sign [message.json]",
+		Short: "Sign unsigned messages offline",
+		Long: `Sign the unsigned message JSON written by thctl (a single message or an array of
+messages) with the keys of their senders. Nothing is sent to Lotus.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			messages, isList, err := readJSONList[types.Message](args[0])
+			if err != nil {
+				return err
+			}
+
+			ks := wallet.OpenKeystore()
+			for _, msg := range messages {
+				if !ks.Has(msg.From) {
+					return fmt.Errorf("no key for sender %s in %s", msg.From, ks.Dir())
+				}
+			}
+
+			passphrase, err := readPassphrase("Keystore passphrase: ", false)
+			if err != nil {
+				return err
+			}
+
+			keys := make(map[address.Address]*wallet.KeyInfo)
+			signed := make([]*types.SignedMessage, 0, len(messages))
+			for i := range messages {
+				msg := &messages[i]
+				ki, ok := keys[msg.From]
+				if !ok {
+					if ki, err = ks.Get(msg.From, passphrase); err != nil {
+						return err
+					}
+					keys[msg.From] = ki
+				}
+
+				sm, err := wallet.SignMessage(ki, msg)
+				if err != nil {
+					return fmt.Errorf("failed to sign message %d: %v", i, err)
+				}
+				signed = append(signed, sm)
+			}
+
+			var result interface{} = signed
+			if !isList {
+				result = signed[0]
+			}
+			data, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode signed messages: %v", err)
+			}
+
+			if outFile == "" {
+				fmt.Println(string(data))
+				return nil
+			}
+			if err := os.WriteFile(outFile, append(data, '\n'), 0644); err != nil {
+				return fmt.Errorf("failed to write signed messages: %v", err)
+			}
+			fmt.Printf("✅ %d signed messages written to %s\n", len(signed), outFile)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&outFile, "out", "", "Write the signed message JSON to this file instead of stdout")
+
+	return cmd
+}
+
+func newVerifyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "verify [signed.json]",
+		Short: "Verify signed messages",
+		Long:  `Check that each signed message was signed by its sender. Nothing is sent to Lotus.`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			messages, _, err := readJSONList[types.SignedMessage](args[0])
+			if err != nil {
+				return err
+			}
+			return verifyMessages(messages)
+		},
+	}
+}
+
+// verifyMessages prints the verification result of each signed message and fails if any is invalid
+func verifyMessages(messages []types.SignedMessage) error {
+	invalid := 0
+	for i := range messages {
+		sm := &messages[i]
+		if err := wallet.VerifyMessage(sm); err != nil {
+			invalid++
+			fmt.Printf("❌ %s from %s (nonce %d): %v\n", sm.Cid(), sm.Message.From, sm.Message.Nonce, err)
+			continue
+		}
+		fmt.Printf("✅ %s from %s (nonce %d): valid\n", sm.Cid(), sm.Message.From, sm.Message.Nonce)
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d signatures are invalid", invalid, len(messages))
+	}
+	return nil
+}
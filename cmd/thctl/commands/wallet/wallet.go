@@ -0,0 +1,157 @@
+package wallet
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"github.com/THCloudAI/thctl/internal/wallet"
+	"github.com/THCloudAI/thctl/pkg/framework/output"
+	"github.com/THCloudAI/thctl/pkg/framework/template"
+	"golang.org/x/term"
+)
+
+// passphraseEnv is the environment variable holding the keystore passphrase for non-interactive use
+const passphraseEnv = "THCTL_WALLET_PASSPHRASE"
+
+// NewWalletCmd creates a new wallet command
+func NewWalletCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "wallet",
+		Short: "Manage local keys and sign messages offline",
+		Long: `Manage secp256k1 and BLS keys in an encrypted keystore kept in the thctl config
+directory, sign unsigned message JSON offline and verify signatures.
+
+Signing never talks to Lotus. Pushing signed messages is a separate, explicit step.
+The keystore passphrase is prompted for, or read from ` + passphraseEnv + `.
+
+Examples:
+  # Generate a new BLS key
+  thctl wallet new bls
+
+  # Import a key exported with lotus wallet export
+  thctl wallet import key.hex
+
+  # Sign a message built by thctl fil miner withdraw, then push it
+  thctl wallet sign withdraw.json --out withdraw.signed.json
+  thctl wallet push withdraw.signed.json`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	cmd.SetHelpTemplate(template.SubCommandHelpTemplate)
+
+	// Add subcommands
+	cmd.AddCommand(
+		newNewCmd(),
+		newImportCmd(),
+		newListCmd(),
+		newSignCmd(),
+		newVerifyCmd(),
+		newPushCmd(),
+	)
+
+	return cmd
+}
+
+func newListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the addresses in the keystore",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := wallet.OpenKeystore().List()
+			if err != nil {
+				return err
+			}
+
+			format, _ := cmd.Flags().GetString("output")
+			switch format {
+			case "json":
+				return output.JSON(entries)
+			case "yaml":
+				return output.YAML(entries)
+			case "table":
+				t := table.NewWriter()
+				t.AppendHeader(table.Row{"Address", "Type"})
+				for _, e := range entries {
+					t.AppendRow(table.Row{e.Address, e.Type})
+				}
+				fmt.Println(t.Render())
+			default:
+				return fmt.Errorf("unsupported output format: %s", format)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("output", "o", "table", "Output format: json, yaml, or table")
+
+	return cmd
+}
+
+// readPassphrase returns the keystore passphrase from the environment or prompts for it.
+// With confirm set, an interactive passphrase has to be entered twice.
+func readPassphrase(prompt string, confirm bool) (string, error) {
+	if passphrase := os.Getenv(passphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		// Read a single line so the passphrase can be piped in
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("no passphrase given: set %s or run interactively", passphraseEnv)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %v", err)
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "Repeat passphrase: ")
+		again, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase: %v", err)
+		}
+		if string(again) != string(passphrase) {
+			return "", fmt.Errorf("passphrases do not match")
+		}
+	}
+	return string(passphrase), nil
+}
+
+// readJSONList decodes a file holding either a single JSON object or an array of them,
+// and reports whether it was an array so output can keep the same shape
+func readJSONList[T any](path string) ([]T, bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "[") {
+		var items []T
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, false, fmt.Errorf("failed to decode %s: %v", path, err)
+		}
+		return items, true, nil
+	}
+
+	var item T
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, false, fmt.Errorf("failed to decode %s: %v", path, err)
+	}
+	return []T{item}, false, nil
+}
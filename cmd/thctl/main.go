@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/THCloudAI/thctl/cmd/thctl/commands/auth"
@@ -17,6 +19,9 @@ import (
 	"github.com/THCloudAI/thctl/cmd/thctl/commands/fil"
 	"github.com/THCloudAI/thctl/cmd/thctl/commands/oss"
 	"github.com/THCloudAI/thctl/cmd/thctl/commands/s3"
+	"github.com/THCloudAI/thctl/cmd/thctl/commands/wallet"
+	"github.com/THCloudAI/thctl/internal/config"
+	"github.com/THCloudAI/thctl/internal/lotus"
 	"github.com/THCloudAI/thctl/pkg/framework/output"
 	"github.com/THCloudAI/thctl/pkg/version"
 )
@@ -76,6 +81,7 @@ Global Options:
 				fmt.Fprintf(os.Stderr, "Error creating config directory: %v\n", err)
 				os.Exit(1)
 			}
+			config.SetConfigDir(configDir)
 
 			// Validate output format
 			if !output.Format(outputFormat).IsValid() {
@@ -101,12 +107,28 @@ func init() {
 		cos.NewCosCmd(),
 		oss.NewOssCmd(),
 		s3.NewS3Cmd(),
+		wallet.NewWalletCmd(),
 	)
 }
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	printServedBy()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// printServedBy tells on stderr which Lotus endpoints served the calls of the command,
+// and which methods each one answered, when several endpoints are configured
+func printServedBy() {
+	for _, e := range lotus.EnvServedBy() {
+		calls := make([]string, 0, len(e.Calls))
+		for method, n := range e.Calls {
+			calls = append(calls, fmt.Sprintf("%s×%d", strings.TrimPrefix(method, "Filecoin."), n))
+		}
+		sort.Strings(calls)
+		fmt.Fprintf(os.Stderr, "Served by %s: %s\n", e.URL, strings.Join(calls, " "))
+	}
+}
@@ -0,0 +1,288 @@
+package address
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/sha3"
+)
+
+// Network names derived from the address prefix
+const (
+	NetworkMainnet = "mainnet"
+	NetworkTestnet = "testnet"
+)
+
+// eamActorID is the ID of the Ethereum address manager, the namespace of f410 addresses
+const eamActorID = 10
+
+// ethAddressLength is the length of an Ethereum address in bytes
+const ethAddressLength = 20
+
+// Info describes a parsed address
+type Info struct {
+	Input        string  `json:"input" yaml:"input"`
+	Address      string  `json:"address" yaml:"address"`
+	Testnet      string  `json:"testnet" yaml:"testnet"`
+	Network      string  `json:"network" yaml:"network"`
+	Protocol     byte    `json:"protocol" yaml:"protocol"`
+	ProtocolName string  `json:"protocolName" yaml:"protocolName"`
+	ActorID      *uint64 `json:"actorId,omitempty" yaml:"actorId,omitempty"`
+	Namespace    *uint64 `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Payload      string  `json:"payload" yaml:"payload"`
+	Checksum     string  `json:"checksum,omitempty" yaml:"checksum,omitempty"`
+	EthAddress   string  `json:"ethAddress,omitempty" yaml:"ethAddress,omitempty"`
+}
+
+// Parse parses a Filecoin address with either network prefix, or an Ethereum 0x address,
+// which is converted to its f410 form (or its ID address for masked ID addresses).
+// The checksum of f1, f2, f3 and f4 addresses is verified.
+func Parse(s string) (address.Address, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return address.Undef, fmt.Errorf("empty address")
+	}
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return FromEthAddress(s)
+	}
+
+	addr, err := address.NewFromString(s)
+	if err != nil {
+		if errors.Is(err, address.ErrInvalidChecksum) {
+			return address.Undef, fmt.Errorf("invalid address %q: checksum does not match", s)
+		}
+		return address.Undef, fmt.Errorf("invalid address %q: %v", s, err)
+	}
+	return addr, nil
+}
+
+// Inspect parses an address and describes its protocol, network, payload and alternative forms
+func Inspect(s string) (*Info, error) {
+	addr, err := Parse(s)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{
+		Input:        strings.TrimSpace(s),
+		Address:      withPrefix(addr, address.MainnetPrefix),
+		Testnet:      withPrefix(addr, address.TestnetPrefix),
+		Network:      NetworkMainnet,
+		Protocol:     addr.Protocol(),
+		ProtocolName: ProtocolName(addr),
+		Payload:      hex.EncodeToString(addr.Payload()),
+	}
+	if strings.HasPrefix(info.Input, address.TestnetPrefix) {
+		info.Network = NetworkTestnet
+	}
+
+	switch addr.Protocol() {
+	case address.ID:
+		id, err := address.IDFromAddress(addr)
+		if err != nil {
+			return nil, err
+		}
+		info.ActorID = &id
+	case address.Delegated:
+		namespace, _, err := delegatedParts(addr)
+		if err != nil {
+			return nil, err
+		}
+		info.Namespace = &namespace
+	}
+	if addr.Protocol() != address.ID {
+		info.Checksum = hex.EncodeToString(address.Checksum(append([]byte{addr.Protocol()}, addr.Payload()...)))
+	}
+	if eth, err := ToEthAddress(addr); err == nil {
+		info.EthAddress = eth
+	}
+
+	return info, nil
+}
+
+// Validate reports whether s is a valid address
+func Validate(s string) error {
+	_, err := Parse(s)
+	return err
+}
+
+// ValidateMiner reports whether s can identify a miner actor: an ID (f0) or actor (f2) address
+func ValidateMiner(s string) error {
+	addr, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	if addr.Protocol() != address.ID && addr.Protocol() != address.Actor {
+		return fmt.Errorf("invalid miner address %q: expected an ID (f0) or actor (f2) address, got a %s address", s, ProtocolName(addr))
+	}
+	return nil
+}
+
+// MinerArgs returns a cobra argument validator rejecting invalid miner addresses at the given positions
+func MinerArgs(positions ...int) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		for _, i := range positions {
+			if i < len(args) {
+				if err := ValidateMiner(args[i]); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// MinersArgs returns a cobra argument validator rejecting invalid miner addresses from the given position on
+func MinersArgs(from int) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		for i := from; i < len(args); i++ {
+			if err := ValidateMiner(args[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// AddressArgs returns a cobra argument validator rejecting invalid addresses from the given position on
+func AddressArgs(from int) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		for i := from; i < len(args); i++ {
+			if err := Validate(args[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// AddressArgsAt returns a cobra argument validator rejecting invalid addresses at the given positions
+func AddressArgsAt(positions ...int) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		for _, i := range positions {
+			if i < len(args) {
+				if err := Validate(args[i]); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// ProtocolName returns a readable name for the protocol of an address
+func ProtocolName(addr address.Address) string {
+	switch addr.Protocol() {
+	case address.ID:
+		return "id"
+	case address.SECP256K1:
+		return "secp256k1"
+	case address.Actor:
+		return "actor"
+	case address.BLS:
+		return "bls"
+	case address.Delegated:
+		return "delegated"
+	default:
+		return "unknown"
+	}
+}
+
+// FromEthAddress converts an Ethereum address to a Filecoin address. Masked ID
+// addresses (0xff followed by zeros and an 8 byte ID) map to ID addresses,
+// anything else to an f410 address in the Ethereum address manager namespace.
+func FromEthAddress(s string) (address.Address, error) {
+	raw := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(s), "0x"), "0X")
+	b, err := hex.DecodeString(raw)
+	if err != nil || len(b) != ethAddressLength {
+		return address.Undef, fmt.Errorf("invalid Ethereum address %q", s)
+	}
+	if raw != strings.ToLower(raw) && raw != strings.ToUpper(raw) && ethChecksum(b) != raw {
+		return address.Undef, fmt.Errorf("invalid Ethereum address %q: checksum does not match", s)
+	}
+
+	if isMaskedID(b) {
+		return address.NewIDAddress(binary.BigEndian.Uint64(b[12:]))
+	}
+	return address.NewDelegatedAddress(eamActorID, b)
+}
+
+// ToEthAddress converts an f410 or ID address to its checksummed Ethereum form
+func ToEthAddress(addr address.Address) (string, error) {
+	var b []byte
+	switch addr.Protocol() {
+	case address.ID:
+		id, err := address.IDFromAddress(addr)
+		if err != nil {
+			return "", err
+		}
+		b = make([]byte, ethAddressLength)
+		b[0] = 0xff
+		binary.BigEndian.PutUint64(b[12:], id)
+	case address.Delegated:
+		namespace, sub, err := delegatedParts(addr)
+		if err != nil {
+			return "", err
+		}
+		if namespace != eamActorID || len(sub) != ethAddressLength {
+			return "", fmt.Errorf("%s is not an Ethereum address", addr)
+		}
+		b = sub
+	default:
+		return "", fmt.Errorf("%s addresses have no Ethereum form", ProtocolName(addr))
+	}
+	return "0x" + ethChecksum(b), nil
+}
+
+// delegatedParts splits a delegated address into its namespace and sub-address
+func delegatedParts(addr address.Address) (uint64, []byte, error) {
+	payload := addr.Payload()
+	namespace, n := binary.Uvarint(payload)
+	if n <= 0 {
+		return 0, nil, fmt.Errorf("invalid delegated address %s", addr)
+	}
+	return namespace, payload[n:], nil
+}
+
+// isMaskedID reports whether an Ethereum address is a masked ID address
+func isMaskedID(b []byte) bool {
+	if b[0] != 0xff {
+		return false
+	}
+	for _, v := range b[1:12] {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ethChecksum returns the EIP-55 mixed case hex encoding of an Ethereum address without 0x
+func ethChecksum(b []byte) string {
+	lower := hex.EncodeToString(b)
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(lower))
+	digest := h.Sum(nil)
+
+	out := []byte(lower)
+	for i, c := range out {
+		nibble := digest[i/2]
+		if i%2 == 0 {
+			nibble >>= 4
+		}
+		if c >= 'a' && nibble&0xf >= 8 {
+			out[i] = c - 'a' + 'A'
+		}
+	}
+	return string(out)
+}
+
+// withPrefix encodes an address with the given network prefix
+func withPrefix(addr address.Address, prefix string) string {
+	return prefix + addr.String()[1:]
+}
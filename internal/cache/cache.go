@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/THCloudAI/thctl/internal/config"
+)
+
+// Store is a simple file-backed cache of JSON documents kept under the thctl config directory
+type Store struct {
+	dir string
+}
+
+// New creates a cache store for the given namespace
+func New(namespace string) *Store {
+	return &Store{
+		dir: filepath.Join(config.GetConfigDir(), "cache", namespace),
+	}
+}
+
+// Dir returns the directory backing the store
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Get loads the cached value for key into v and reports whether it was found
+func (s *Store) Get(key string, v interface{}) (bool, error) {
+	data, err := os.ReadFile(s.path(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read cache entry %s: %w", key, err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		// A corrupt entry is treated as a miss so it gets rewritten
+		return false, nil
+	}
+	return true, nil
+}
+
+// Put stores v under key, replacing any existing entry
+func (s *Store) Put(key string, v interface{}) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry %s: %w", key, err)
+	}
+
+	// Write to a temporary file first so readers never see a partial entry
+	tmp := s.path(key) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write cache entry %s: %w", key, err)
+	}
+	return os.Rename(tmp, s.path(key))
+}
+
+func (s *Store) path(key string) string {
+	return filepath.Join(s.dir, key+".json")
+}
@@ -4,6 +4,7 @@ import (
     "fmt"
     "os"
     "path/filepath"
+    "strconv"
     "sync"
     "time"
 
@@ -16,10 +17,27 @@ type Config struct {
         APIURL    string        `yaml:"api_url"`
         AuthToken string        `yaml:"auth_token"`
         Timeout   time.Duration `yaml:"timeout"`
+        // Endpoints are tried in order, failing over when one is down
+        Endpoints []LotusEndpoint `yaml:"endpoints"`
+        // Balance spreads read-only batch calls across the healthy endpoints
+        Balance bool `yaml:"balance"`
+        // MaxLag is the number of epochs an endpoint may be behind the best one
+        MaxLag int64 `yaml:"max_lag"`
     } `yaml:"lotus"`
+    LotusMiner struct {
+        APIURL    string `yaml:"api_url"`
+        AuthToken string `yaml:"auth_token"`
+    } `yaml:"lotus_miner"`
     THCloud struct {
         APIKey string `yaml:"api_key"`
     } `yaml:"thcloud"`
+    History struct {
+        Enabled bool `yaml:"enabled"`
+    } `yaml:"history"`
+    Storage struct {
+        DefaultProvider string           `yaml:"default_provider"`
+        Profiles        []StorageProfile `yaml:"profiles"`
+    } `yaml:"storage"`
 }
 
 var (
@@ -56,7 +74,7 @@ func Load() (cfg *Config, err error) {
         config = &Config{}
 
         // Try to load from .thctl.env file in the current directory
-        fmt.Printf("Trying to load .thctl.env from current directory...\n")
+        fmt.Fprintf(os.Stderr, "Trying to load .thctl.env from current directory...\n")
         err = godotenv.Load(".thctl.env")
         if err != nil && !os.IsNotExist(err) {
             err = fmt.Errorf("failed to load .thctl.env file: %v", err)
@@ -68,7 +86,7 @@ func Load() (cfg *Config, err error) {
             home, homeErr := os.UserHomeDir()
             if homeErr == nil {
                 envFile := filepath.Join(home, ".thctl.env")
-                fmt.Printf("Trying to load .thctl.env from home directory: %s\n", envFile)
+                fmt.Fprintf(os.Stderr, "Trying to load .thctl.env from home directory: %s\n", envFile)
                 err = godotenv.Load(envFile)
                 if err != nil && !os.IsNotExist(err) {
                     err = fmt.Errorf("failed to load home directory .thctl.env file: %v", err)
@@ -81,9 +99,21 @@ func Load() (cfg *Config, err error) {
         config.Lotus.APIURL = getEnvWithDefault("LOTUS_API_URL", "/ip4/127.0.0.1/tcp/1234")
         config.Lotus.AuthToken = getEnvWithDefault("LOTUS_API_TOKEN", "")
         config.Lotus.Timeout = getDurationEnvWithDefault("LOTUS_API_TIMEOUT", 30*time.Second)
+        config.Lotus.Endpoints = loadLotusEndpoints()
+        if len(config.Lotus.Endpoints) > 0 {
+            // The first endpoint is the primary node
+            config.Lotus.APIURL = config.Lotus.Endpoints[0].URL
+            config.Lotus.AuthToken = config.Lotus.Endpoints[0].Token
+        }
+        config.Lotus.Balance = getBoolEnvWithDefault("LOTUS_API_BALANCE", false)
+        config.Lotus.MaxLag = getMaxLag()
+        config.LotusMiner.APIURL, config.LotusMiner.AuthToken = loadMinerAPI()
         config.THCloud.APIKey = getEnvWithDefault("THCLOUD_API_KEY", "")
+        config.History.Enabled = getBoolEnvWithDefault("THCTL_HISTORY", false)
+        config.Storage.DefaultProvider = getEnvWithDefault("DEFAULT_STORAGE_PROVIDER", "")
+        config.Storage.Profiles = loadStorageProfiles()
 
-        fmt.Printf("Loaded config: LOTUS_API_URL=%s\n", config.Lotus.APIURL)
+        fmt.Fprintf(os.Stderr, "Loaded config: LOTUS_API_URL=%s\n", config.Lotus.APIURL)
 
         // Clear error if we successfully loaded the config
         err = nil
@@ -117,3 +147,16 @@ func getDurationEnvWithDefault(key string, defaultValue time.Duration) time.Dura
     }
     return duration
 }
+
+// getBoolEnvWithDefault returns the boolean value of an environment variable or a default value
+func getBoolEnvWithDefault(key string, defaultValue bool) bool {
+    value := os.Getenv(key)
+    if value == "" {
+        return defaultValue
+    }
+    enabled, err := strconv.ParseBool(value)
+    if err != nil {
+        return defaultValue
+    }
+    return enabled
+}
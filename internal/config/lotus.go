@@ -0,0 +1,54 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LotusEndpoint is a Lotus API endpoint with the token used to call it
+type LotusEndpoint struct {
+	URL   string `yaml:"url"`
+	Token string `yaml:"token"`
+}
+
+// defaultMaxLag is the number of epochs an endpoint may be behind the others
+// before it is only used when the others fail
+const defaultMaxLag = 5
+
+// loadLotusEndpoints returns the ordered endpoints of LOTUS_API_ENDPOINTS, a comma
+// separated list of token:url entries in the format of FULLNODE_API_INFO. Entries
+// without a token, such as public gateways, are given as a bare URL or multiaddr.
+func loadLotusEndpoints() []LotusEndpoint {
+	var endpoints []LotusEndpoint
+	for _, entry := range strings.Split(os.Getenv("LOTUS_API_ENDPOINTS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		endpoints = append(endpoints, parseLotusEndpoint(entry))
+	}
+	return endpoints
+}
+
+// parseLotusEndpoint splits a token:url entry
+func parseLotusEndpoint(entry string) LotusEndpoint {
+	if strings.HasPrefix(entry, "/") || strings.HasPrefix(entry, "http://") || strings.HasPrefix(entry, "https://") {
+		return LotusEndpoint{URL: entry}
+	}
+	token, url, found := strings.Cut(entry, ":")
+	if !found {
+		return LotusEndpoint{URL: entry}
+	}
+	return LotusEndpoint{URL: url, Token: token}
+}
+
+// getMaxLag returns LOTUS_API_MAX_LAG, the number of epochs an endpoint may lag. Unset,
+// invalid and non-positive values give the default.
+func getMaxLag() int64 {
+	lag, err := strconv.ParseInt(os.Getenv("LOTUS_API_MAX_LAG"), 10, 64)
+	if err != nil || lag <= 0 {
+		return defaultMaxLag
+	}
+	return lag
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// loadMinerAPI returns the URL and token of the lotus-miner API from LOTUS_MINER_API_URL
+// and LOTUS_MINER_API_TOKEN, falling back to the MINER_API_INFO variable of the lotus-miner
+// CLI, which holds both as token:multiaddr
+func loadMinerAPI() (apiURL, token string) {
+	apiURL = os.Getenv("LOTUS_MINER_API_URL")
+	token = os.Getenv("LOTUS_MINER_API_TOKEN")
+	if apiURL != "" {
+		return apiURL, token
+	}
+
+	info := os.Getenv("MINER_API_INFO")
+	if info == "" {
+		return "", token
+	}
+	if i := strings.Index(info, ":/"); i >= 0 {
+		return info[i+1:], info[:i]
+	}
+	return info, token
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// StorageProfile is the configuration of a cloud storage provider
+type StorageProfile struct {
+	Provider  string `yaml:"provider"`
+	Endpoint  string `yaml:"endpoint"`
+	Region    string `yaml:"region"`
+	Bucket    string `yaml:"bucket"`
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+}
+
+// storageEnv names the environment variables of a storage provider
+type storageEnv struct {
+	provider, endpoint, region, bucket, accessKey, secretKey string
+}
+
+// storageEnvs lists the supported storage providers, in the order they are reported
+var storageEnvs = []storageEnv{
+	{"oss", "OSS_ENDPOINT", "OSS_REGION", "OSS_BUCKET", "OSS_ACCESS_KEY_ID", "OSS_ACCESS_KEY_SECRET"},
+	{"s3", "AWS_ENDPOINT", "AWS_REGION", "AWS_BUCKET", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY"},
+	{"cos", "COS_ENDPOINT", "COS_REGION", "COS_BUCKET", "COS_SECRET_ID", "COS_SECRET_KEY"},
+	{"obs", "OBS_ENDPOINT", "OBS_REGION", "OBS_BUCKET", "OBS_ACCESS_KEY", "OBS_SECRET_KEY"},
+	{"minio", "MINIO_ENDPOINT", "MINIO_REGION", "MINIO_BUCKET", "MINIO_ACCESS_KEY", "MINIO_SECRET_KEY"},
+}
+
+// loadStorageProfiles returns a profile for every provider with a bucket or an access
+// key set
+func loadStorageProfiles() []StorageProfile {
+	profiles := make([]StorageProfile, 0)
+	for _, env := range storageEnvs {
+		profile := StorageProfile{
+			Provider:  env.provider,
+			Endpoint:  os.Getenv(env.endpoint),
+			Region:    os.Getenv(env.region),
+			Bucket:    os.Getenv(env.bucket),
+			AccessKey: os.Getenv(env.accessKey),
+			SecretKey: os.Getenv(env.secretKey),
+		}
+		if profile.Bucket == "" && profile.AccessKey == "" {
+			continue
+		}
+		if profile.Provider == "s3" && profile.Endpoint == "" {
+			profile.Endpoint = "s3.amazonaws.com"
+		}
+		// MinIO is often served without TLS; the endpoint scheme carries that
+		if profile.Provider == "minio" && !getBoolEnvWithDefault("MINIO_USE_SSL", true) && !strings.Contains(profile.Endpoint, "://") {
+			profile.Endpoint = "http://" + profile.Endpoint
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles
+}
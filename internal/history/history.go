@@ -0,0 +1,205 @@
+package history
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"github.com/THCloudAI/thctl/internal/config"
+	"github.com/THCloudAI/thctl/internal/lotus"
+)
+
+// Store keeps snapshots of miner information in a bbolt database under the thctl config
+// directory, with one bucket per miner ID keyed by the time of the snapshot
+type Store struct {
+	db *bolt.DB
+}
+
+// aliasBucket maps the robust addresses of recorded miners to their ID, so a miner can be
+// looked up by either address without a Lotus node
+var aliasBucket = []byte("aliases")
+
+// Snapshot is the information of a miner recorded at a point in time
+type Snapshot struct {
+	Timestamp int64            `json:"timestamp"`
+	Info      *lotus.MinerInfo `json:"info"`
+}
+
+// Path returns the location of the history database
+func Path() string {
+	return filepath.Join(config.GetConfigDir(), "history.db")
+}
+
+// Open opens the history database, creating it when needed. The database is locked
+// while open, so a second thctl process waits up to a second for it.
+func Open() (*Store, error) {
+	if err := os.MkdirAll(config.GetConfigDir(), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create config directory: %w", err)
+	}
+	db, err := bolt.Open(Path(), 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history database %s: %w", Path(), err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Save records the information of a miner taken at the given time under its ID,
+// replacing a snapshot taken in the same second
+func (s *Store) Save(info *lotus.MinerInfo, at time.Time) error {
+	data, err := json.Marshal(Snapshot{Timestamp: at.Unix(), Info: info})
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(bucketName(info.ID))
+		if err != nil {
+			return fmt.Errorf("failed to create history bucket: %w", err)
+		}
+		if info.Robust != "" {
+			aliases, err := tx.CreateBucketIfNotExists(aliasBucket)
+			if err != nil {
+				return fmt.Errorf("failed to create history bucket: %w", err)
+			}
+			if err := aliases.Put(bucketName(info.Robust), bucketName(info.ID)); err != nil {
+				return err
+			}
+		}
+		return bucket.Put(timeKey(at), data)
+	})
+}
+
+// List returns the snapshots of a miner taken between since and until (inclusive),
+// oldest first
+func (s *Store) List(minerID string, since, until time.Time) ([]Snapshot, error) {
+	snapshots := make([]Snapshot, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := minerBucket(tx, minerID)
+		if bucket == nil {
+			return nil
+		}
+		c := bucket.Cursor()
+		end := timeKey(until)
+		for k, v := c.Seek(timeKey(since)); k != nil && bytes.Compare(k, end) <= 0; k, v = c.Next() {
+			snapshot, err := decodeSnapshot(v)
+			if err != nil {
+				return err
+			}
+			snapshots = append(snapshots, *snapshot)
+		}
+		return nil
+	})
+	return snapshots, err
+}
+
+// At returns the last snapshot of a miner taken at or before t, or the first one after
+// it when there is none. It returns nil when the miner has no snapshots.
+func (s *Store) At(minerID string, t time.Time) (*Snapshot, error) {
+	var snapshot *Snapshot
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := minerBucket(tx, minerID)
+		if bucket == nil {
+			return nil
+		}
+		c := bucket.Cursor()
+		key := timeKey(t)
+		k, v := c.Seek(key)
+		switch {
+		case k == nil:
+			_, v = c.Last()
+		case !bytes.Equal(k, key):
+			if _, prev := c.Prev(); prev != nil {
+				v = prev
+			}
+		}
+		if v == nil {
+			return nil
+		}
+		var err error
+		snapshot, err = decodeSnapshot(v)
+		return err
+	})
+	return snapshot, err
+}
+
+// Latest returns the last snapshot of a miner, nil when it has none
+func (s *Store) Latest(minerID string) (*Snapshot, error) {
+	var snapshot *Snapshot
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := minerBucket(tx, minerID)
+		if bucket == nil {
+			return nil
+		}
+		_, v := bucket.Cursor().Last()
+		if v == nil {
+			return nil
+		}
+		var err error
+		snapshot, err = decodeSnapshot(v)
+		return err
+	})
+	return snapshot, err
+}
+
+// ParseAge parses how far back to look, as a Go duration or a number of days ("7d")
+// or weeks ("2w")
+func ParseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			count, err := strconv.ParseFloat(n, 64)
+			if err != nil || count < 0 {
+				return 0, fmt.Errorf("invalid age %q", s)
+			}
+			return time.Duration(count * float64(unit)), nil
+		}
+	}
+	age, err := time.ParseDuration(s)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age %q: expected a duration such as 12h, 7d or 2w", s)
+	}
+	return age, nil
+}
+
+// minerBucket returns the bucket of a miner given by ID or robust address, nil when it
+// has no snapshots
+func minerBucket(tx *bolt.Tx, minerID string) *bolt.Bucket {
+	name := bucketName(minerID)
+	if aliases := tx.Bucket(aliasBucket); aliases != nil {
+		if id := aliases.Get(name); id != nil {
+			name = id
+		}
+	}
+	return tx.Bucket(name)
+}
+
+// bucketName returns the bucket of a miner, the same for its f and t addresses
+func bucketName(minerID string) []byte {
+	return []byte("f" + strings.TrimLeft(minerID, "ft"))
+}
+
+// timeKey encodes a time as a key that sorts chronologically
+func timeKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(t.Unix()))
+	return key
+}
+
+// decodeSnapshot decodes a stored snapshot
+func decodeSnapshot(data []byte) (*Snapshot, error) {
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,183 @@
+package history
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/THCloudAI/thctl/internal/lotus"
+	"github.com/THCloudAI/thctl/internal/units"
+)
+
+// Metric kinds, which select how values are formatted
+const (
+	KindFIL   = "fil"
+	KindBytes = "bytes"
+	KindCount = "count"
+	KindText  = "text"
+)
+
+// Metric is a numeric value of the miner information tracked across snapshots
+type Metric struct {
+	Key   string
+	Name  string
+	Kind  string
+	value func(info *lotus.MinerInfo) *big.Int
+}
+
+// Metrics lists the tracked metrics in display order
+var Metrics = []Metric{
+	{"rawBytePower", "Raw Power", KindBytes, func(i *lotus.MinerInfo) *big.Int { return i.Miner.RawBytePower.Int() }},
+	{"qualityAdjPower", "QA Power", KindBytes, func(i *lotus.MinerInfo) *big.Int { return i.Miner.QualityAdjPower.Int() }},
+	{"balance", "Balance", KindFIL, func(i *lotus.MinerInfo) *big.Int { return i.Balance.Atto() }},
+	{"availableBalance", "Available Balance", KindFIL, func(i *lotus.MinerInfo) *big.Int { return i.Miner.AvailableBalance.Atto() }},
+	{"vestingFunds", "Vesting Funds", KindFIL, func(i *lotus.MinerInfo) *big.Int { return i.Miner.VestingFunds.Atto() }},
+	{"initialPledge", "Initial Pledge", KindFIL, func(i *lotus.MinerInfo) *big.Int { return i.Miner.InitialPledgeRequirement.Atto() }},
+	{"preCommitDeposits", "PreCommit Deposits", KindFIL, func(i *lotus.MinerInfo) *big.Int { return i.Miner.PreCommitDeposits.Atto() }},
+	{"workerBalance", "Worker Balance", KindFIL, func(i *lotus.MinerInfo) *big.Int { return i.Miner.Worker.Balance.Atto() }},
+	{"liveSectors", "Live Sectors", KindCount, func(i *lotus.MinerInfo) *big.Int { return new(big.Int).SetUint64(i.Miner.Sectors.Live) }},
+	{"activeSectors", "Active Sectors", KindCount, func(i *lotus.MinerInfo) *big.Int { return new(big.Int).SetUint64(i.Miner.Sectors.Active) }},
+	{"faultySectors", "Faulty Sectors", KindCount, func(i *lotus.MinerInfo) *big.Int { return new(big.Int).SetUint64(i.Miner.Sectors.Faulty) }},
+	{"recoveringSectors", "Recovering Sectors", KindCount, func(i *lotus.MinerInfo) *big.Int { return new(big.Int).SetUint64(i.Miner.Sectors.Recovering) }},
+	{"qualityAdjPowerRank", "QA Power Rank", KindCount, func(i *lotus.MinerInfo) *big.Int { return new(big.Int).SetUint64(i.Miner.QualityAdjPowerRank) }},
+}
+
+// Value returns the value of the metric in a snapshot
+func (m Metric) Value(s *Snapshot) *big.Int {
+	if s == nil || s.Info == nil {
+		return new(big.Int)
+	}
+	return m.value(s.Info)
+}
+
+// Format formats a value of the metric with the current display settings
+func (m Metric) Format(v *big.Int) string {
+	return formatValue(m.Kind, v)
+}
+
+// Point holds the raw metric values of a snapshot by metric key
+type Point struct {
+	Timestamp int64             `json:"timestamp"`
+	Values    map[string]string `json:"values"`
+}
+
+// Points returns the metric values of each snapshot
+func Points(snapshots []Snapshot) []Point {
+	points := make([]Point, 0, len(snapshots))
+	for i := range snapshots {
+		point := Point{Timestamp: snapshots[i].Timestamp, Values: make(map[string]string, len(Metrics))}
+		for _, m := range Metrics {
+			point.Values[m.Key] = m.Value(&snapshots[i]).String()
+		}
+		points = append(points, point)
+	}
+	return points
+}
+
+// formatValue formats a numeric value of the given kind
+func formatValue(kind string, v *big.Int) string {
+	switch kind {
+	case KindFIL:
+		return units.NewFIL(v).String()
+	case KindBytes:
+		return units.NewBytes(v).String()
+	default:
+		return v.String()
+	}
+}
+
+// textFields lists the text values of the miner information compared between snapshots
+var textFields = []struct {
+	name  string
+	value func(info *lotus.MinerInfo) string
+}{
+	{"Owner", func(i *lotus.MinerInfo) string { return i.Miner.Owner.Address }},
+	{"Worker", func(i *lotus.MinerInfo) string { return i.Miner.Worker.Address }},
+	{"Beneficiary", func(i *lotus.MinerInfo) string { return i.Miner.Beneficiary.Address }},
+	{"Control Addresses", func(i *lotus.MinerInfo) string {
+		addrs := make([]string, 0, len(i.Miner.ControlAddresses))
+		for _, control := range i.Miner.ControlAddresses {
+			addrs = append(addrs, control.Address)
+		}
+		return strings.Join(addrs, ", ")
+	}},
+	{"Peer ID", func(i *lotus.MinerInfo) string { return i.Miner.PeerID }},
+	{"Multiaddresses", func(i *lotus.MinerInfo) string { return strings.Join(i.Miner.MultiAddresses, ", ") }},
+}
+
+// Change is a value that differs between two snapshots. Numeric values are raw
+// attoFIL, bytes or counts; Kind tells which.
+type Change struct {
+	Field  string `json:"field"`
+	Kind   string `json:"kind"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+	Delta  string `json:"delta,omitempty"`
+}
+
+// Format returns the before, after and delta values formatted with the current display
+// settings, the delta being "-" for text fields
+func (c Change) Format() (string, string, string) {
+	if c.Kind == KindText {
+		return c.Before, c.After, "-"
+	}
+	parse := func(s string) *big.Int {
+		v, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			return new(big.Int)
+		}
+		return v
+	}
+	delta := parse(c.Delta)
+	sign := ""
+	if delta.Sign() > 0 {
+		sign = "+"
+	}
+	return formatValue(c.Kind, parse(c.Before)), formatValue(c.Kind, parse(c.After)), sign + formatValue(c.Kind, delta)
+}
+
+// Diff lists what changed between two snapshots of a miner
+type Diff struct {
+	MinerID string   `json:"minerId"`
+	From    int64    `json:"from"`
+	To      int64    `json:"to"`
+	Changes []Change `json:"changes"`
+}
+
+// Compare returns the metrics and text fields that differ between two snapshots
+func Compare(minerID string, from, to *Snapshot) *Diff {
+	diff := &Diff{
+		MinerID: minerID,
+		From:    from.Timestamp,
+		To:      to.Timestamp,
+		Changes: make([]Change, 0),
+	}
+
+	for _, m := range Metrics {
+		before, after := m.Value(from), m.Value(to)
+		if before.Cmp(after) == 0 {
+			continue
+		}
+		diff.Changes = append(diff.Changes, Change{
+			Field:  m.Name,
+			Kind:   m.Kind,
+			Before: before.String(),
+			After:  after.String(),
+			Delta:  new(big.Int).Sub(after, before).String(),
+		})
+	}
+
+	for _, field := range textFields {
+		var before, after string
+		if from.Info != nil {
+			before = field.value(from.Info)
+		}
+		if to.Info != nil {
+			after = field.value(to.Info)
+		}
+		if before != after {
+			diff.Changes = append(diff.Changes, Change{Field: field.name, Kind: KindText, Before: before, After: after})
+		}
+	}
+
+	return diff
+}
@@ -0,0 +1,265 @@
+package lotus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/THCloudAI/thctl/internal/address"
+	"github.com/THCloudAI/thctl/internal/units"
+)
+
+// Actor types, named as in the built-in actors manifest returned by StateActorCodeCIDs
+const (
+	ActorAccount          = "account"
+	ActorEthAccount       = "ethaccount"
+	ActorMultisig         = "multisig"
+	ActorMiner            = "storageminer"
+	ActorMarket           = "storagemarket"
+	ActorPower            = "storagepower"
+	ActorVerifiedRegistry = "verifiedregistry"
+	ActorDataCap          = "datacap"
+	ActorEVM              = "evm"
+	ActorPlaceholder      = "placeholder"
+	ActorUnknown          = "unknown"
+)
+
+// actorOnChain is the result of StateGetActor
+type actorOnChain struct {
+	Code             map[string]string `json:"Code"`
+	Head             map[string]string `json:"Head"`
+	Nonce            uint64            `json:"Nonce"`
+	Balance          string            `json:"Balance"`
+	DelegatedAddress *string           `json:"DelegatedAddress"`
+}
+
+// multisigState is the state of a multisig actor as returned by StateReadState
+type multisigState struct {
+	Signers               []string `json:"Signers"`
+	NumApprovalsThreshold uint64   `json:"NumApprovalsThreshold"`
+	NextTxnID             int64    `json:"NextTxnID"`
+	InitialBalance        string   `json:"InitialBalance"`
+	StartEpoch            int64    `json:"StartEpoch"`
+	UnlockDuration        int64    `json:"UnlockDuration"`
+}
+
+// evmState is the state of an EVM contract as returned by StateReadState
+type evmState struct {
+	Bytecode      map[string]string `json:"Bytecode"`
+	BytecodeHash  json.RawMessage   `json:"BytecodeHash"`
+	ContractState map[string]string `json:"ContractState"`
+	Nonce         uint64            `json:"Nonce"`
+	Tombstone     *struct {
+		Origin uint64 `json:"Origin"`
+		Nonce  uint64 `json:"Nonce"`
+	} `json:"Tombstone"`
+}
+
+// GetActorType returns the type of the actor at addr by matching its code CID
+// against the built-in actor codes of the current network version
+func (c *Client) GetActorType(ctx context.Context, addr string) (string, error) {
+	var actor actorOnChain
+	if err := c.callRPCWithRetry(ctx, "Filecoin.StateGetActor", []interface{}{addr, nil}, &actor); err != nil {
+		return "", fmt.Errorf("failed to get actor %s: %w", addr, err)
+	}
+	return c.actorType(ctx, actor.Code["/"])
+}
+
+// actorType maps a code CID to its actor type
+func (c *Client) actorType(ctx context.Context, code string) (string, error) {
+	network, err := c.Network(ctx)
+	if err != nil {
+		return "", err
+	}
+	codes, err := c.ActorCodes(ctx, network.Version)
+	if err != nil {
+		return "", err
+	}
+	for name, cid := range codes {
+		if cid == code {
+			return name, nil
+		}
+	}
+	return ActorUnknown, nil
+}
+
+// GetActorInfo resolves an address of any form, identifies the type of its actor and
+// reads its state. Multisig and EVM states are decoded; the raw state is always included.
+func (c *Client) GetActorInfo(ctx context.Context, addr string) (*ActorInfo, error) {
+	// Detect the network first so addresses print with its prefix
+	network, err := c.Network(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := address.Parse(addr)
+	if err != nil {
+		return nil, err
+	}
+	addr = parsed.String()
+
+	const (
+		callHead = iota
+		callActor
+		callID
+		callRobust
+		callState
+	)
+	responses, err := c.BatchCallWithRetry(ctx, []map[string]interface{}{
+		newRPCRequest(callHead, "Filecoin.ChainHead"),
+		newRPCRequest(callActor, "Filecoin.StateGetActor", addr, nil),
+		newRPCRequest(callID, "Filecoin.StateLookupID", addr, nil),
+		newRPCRequest(callRobust, "Filecoin.StateLookupRobustAddress", addr, nil),
+		newRPCRequest(callState, "Filecoin.StateReadState", addr, nil),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get actor %s: %w", addr, err)
+	}
+
+	info := &ActorInfo{Address: addr}
+	var (
+		head  TipSet
+		actor actorOnChain
+		state struct {
+			State map[string]interface{} `json:"State"`
+		}
+	)
+	for _, resp := range responses {
+		id, ok := resp["id"].(float64)
+		if !ok {
+			continue
+		}
+		if resp["error"] != nil {
+			// Actors created without a robust address, such as built-in actors, have none
+			if int(id) == callRobust {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get actor %s: %s", addr, rpcErrorMessage(resp["error"]))
+		}
+
+		switch int(id) {
+		case callHead:
+			err = decodeResult(resp["result"], &head)
+		case callActor:
+			err = decodeResult(resp["result"], &actor)
+		case callID:
+			err = decodeResult(resp["result"], &info.ID)
+		case callRobust:
+			err = decodeResult(resp["result"], &info.Robust)
+		case callState:
+			err = decodeResult(resp["result"], &state)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode actor %s: %w", addr, err)
+		}
+	}
+	if actor.Code == nil {
+		return nil, fmt.Errorf("actor %s not found", addr)
+	}
+
+	if !isIDAddress(addr) {
+		info.Robust = addr
+	}
+	info.NetworkVersion = network.Version
+	info.Height = head.Height
+	info.Code = actor.Code["/"]
+	info.Nonce = actor.Nonce
+	info.Balance = units.FILFromAtto(actor.Balance)
+	info.State = state.State
+	if info.Type, err = c.actorType(ctx, info.Code); err != nil {
+		return nil, err
+	}
+
+	// Contracts and Ethereum accounts are known by their f410 address; every
+	// other actor can still be reached from Ethereum through its masked ID
+	ethSource := info.ID
+	if actor.DelegatedAddress != nil {
+		info.DelegatedAddress = *actor.DelegatedAddress
+		ethSource = info.DelegatedAddress
+	}
+	if ethAddr, err := address.Parse(ethSource); err == nil {
+		info.EthAddress, _ = address.ToEthAddress(ethAddr)
+	}
+
+	switch info.Type {
+	case ActorMultisig:
+		info.Multisig, err = decodeMultisigState(state.State, info.Balance, int64(head.Height))
+	case ActorEVM:
+		info.EVM, err = decodeEVMState(state.State)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s state of %s: %w", info.Type, addr, err)
+	}
+
+	return info, nil
+}
+
+// decodeMultisigState decodes a multisig state and computes how much of its balance is still locked
+func decodeMultisigState(raw map[string]interface{}, balance units.FIL, height int64) (*MultisigState, error) {
+	var st multisigState
+	if err := decodeResult(raw, &st); err != nil {
+		return nil, err
+	}
+
+	initial := units.FILFromAtto(st.InitialBalance)
+	locked := multisigLocked(initial.Atto(), st.StartEpoch, st.UnlockDuration, height)
+	available := new(big.Int).Sub(balance.Atto(), locked)
+	if available.Sign() < 0 {
+		available.SetInt64(0)
+	}
+
+	return &MultisigState{
+		Signers:               st.Signers,
+		NumApprovalsThreshold: st.NumApprovalsThreshold,
+		NextTxnID:             st.NextTxnID,
+		InitialBalance:        initial,
+		StartEpoch:            st.StartEpoch,
+		UnlockDuration:        st.UnlockDuration,
+		LockedBalance:         units.NewFIL(locked),
+		AvailableBalance:      units.NewFIL(available),
+	}, nil
+}
+
+// multisigLocked returns the amount still locked by a multisig's linear vesting
+// schedule at height, rounded up the way the multisig actor does
+func multisigLocked(initial *big.Int, start, duration, height int64) *big.Int {
+	elapsed := height - start
+	if duration <= 0 || elapsed >= duration {
+		return new(big.Int)
+	}
+	if elapsed < 0 {
+		return new(big.Int).Set(initial)
+	}
+	numerator := new(big.Int).Mul(initial, big.NewInt(duration-elapsed))
+	d := big.NewInt(duration)
+	locked, rem := new(big.Int).QuoRem(numerator, d, new(big.Int))
+	if rem.Sign() > 0 {
+		locked.Add(locked, big.NewInt(1))
+	}
+	return locked
+}
+
+// decodeEVMState decodes the state of an EVM contract
+func decodeEVMState(raw map[string]interface{}) (*EVMState, error) {
+	var st evmState
+	if err := decodeResult(raw, &st); err != nil {
+		return nil, err
+	}
+
+	// Lotus encodes the hash as an array of bytes; accept base64 as well
+	var hash []byte
+	var arr [32]byte
+	if err := json.Unmarshal(st.BytecodeHash, &arr); err == nil {
+		hash = arr[:]
+	} else if err := json.Unmarshal(st.BytecodeHash, &hash); err != nil {
+		return nil, fmt.Errorf("invalid bytecode hash: %w", err)
+	}
+
+	return &EVMState{
+		Bytecode:      st.Bytecode["/"],
+		BytecodeHash:  fmt.Sprintf("0x%x", hash),
+		ContractState: st.ContractState["/"],
+		Nonce:         st.Nonce,
+		Tombstoned:    st.Tombstone != nil,
+	}, nil
+}
@@ -0,0 +1,473 @@
+package lotus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/filecoin-project/go-address"
+	filaddr "github.com/THCloudAI/thctl/internal/address"
+	"github.com/THCloudAI/thctl/internal/cache"
+	"github.com/THCloudAI/thctl/internal/units"
+)
+
+const (
+	// expectedLeadersPerEpoch is the expected number of winning tickets per epoch
+	expectedLeadersPerEpoch = 5
+	// chainFinality is the number of epochs after which a tipset can no longer be reorged
+	chainFinality = 900
+	// epochCacheChunk is the number of epochs stored in a single cache entry
+	epochCacheChunk = 2880
+	// tipsetBatchSize limits the number of tipsets fetched in a single batch request
+	tipsetBatchSize = 100
+	// maxPowerSamples limits the number of tipsets the miner's power is sampled at for luck
+	maxPowerSamples = 100
+)
+
+// epochSummary holds the block producers of a single epoch as stored in the cache
+type epochSummary struct {
+	Null         bool           `json:"null,omitempty"`
+	Timestamp    int64          `json:"ts,omitempty"`
+	Blocks       []blockSummary `json:"blocks,omitempty"`
+	RewardPerWin string         `json:"rewardPerWin,omitempty"`
+}
+
+// blockSummary identifies a block and the miner that produced it
+type blockSummary struct {
+	Cid      string `json:"cid"`
+	Miner    string `json:"miner"`
+	WinCount uint64 `json:"winCount"`
+}
+
+// tipSetKey rebuilds the tipset key of an epoch from its block CIDs
+func (e *epochSummary) tipSetKey() []map[string]string {
+	key := make([]map[string]string, 0, len(e.Blocks))
+	for _, b := range e.Blocks {
+		key = append(key, map[string]string{"/": b.Cid})
+	}
+	return key
+}
+
+// epochChunk is a cache entry holding the summaries of consecutive epochs
+type epochChunk map[uint64]*epochSummary
+
+// baseFee returns the base fee of the tipset in attoFIL per gas unit, "0" when unknown
+func (t *TipSet) baseFee() string {
+	if len(t.Blocks) == 0 || t.Blocks[0].ParentBaseFee == "" {
+		return "0"
+	}
+	return t.Blocks[0].ParentBaseFee
+}
+
+// GetChainHead retrieves the current chain head
+func (c *Client) GetChainHead(ctx context.Context) (*TipSet, error) {
+	var head TipSet
+	if err := c.callRPCWithRetry(ctx, "Filecoin.ChainHead", []interface{}{}, &head); err != nil {
+		return nil, fmt.Errorf("failed to get chain head: %w", err)
+	}
+	return &head, nil
+}
+
+// GetBlockHistory walks the chain between from and to (inclusive) and collects the
+// blocks mined by minerID, their rewards and the miner's luck over the range.
+// A to height of 0 means the current head. Finalized epochs are cached in store
+// when it is not nil so repeated ranges don't re-walk the chain.
+func (c *Client) GetBlockHistory(ctx context.Context, minerID string, from, to uint64, store *cache.Store) (*BlockHistory, error) {
+	// Blocks name their miner by ID
+	minerIDAddr, err := c.resolveMinerID(ctx, minerID)
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := c.GetChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if to == 0 || to > head.Height {
+		to = head.Height
+	}
+	if from > to {
+		return nil, fmt.Errorf("invalid height range: from %d is after to %d", from, to)
+	}
+
+	// Load cached chunks covering the range
+	chunks := make(map[uint64]epochChunk)
+	for start := chunkStart(from); start <= to; start += epochCacheChunk {
+		chunk := make(epochChunk)
+		if store != nil {
+			if _, err := store.Get(chunkKey(start), &chunk); err != nil {
+				return nil, err
+			}
+		}
+		chunks[start] = chunk
+	}
+
+	var missing []uint64
+	for h := from; h <= to; h++ {
+		if chunks[chunkStart(h)][h] == nil {
+			missing = append(missing, h)
+		}
+	}
+
+	fetched, err := c.fetchEpochSummaries(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	dirty := make(map[uint64]bool)
+	for h, summary := range fetched {
+		chunks[chunkStart(h)][h] = summary
+		dirty[chunkStart(h)] = true
+	}
+
+	history := &BlockHistory{
+		MinerID:    minerID,
+		FromHeight: from,
+		ToHeight:   to,
+		Epochs:     to - from + 1,
+		Blocks:     make([]MinedBlock, 0),
+	}
+
+	// Attribute blocks to the miner
+	var won []uint64
+	for h := from; h <= to; h++ {
+		summary := chunks[chunkStart(h)][h]
+		if summary == nil {
+			return nil, fmt.Errorf("no tipset returned for height %d", h)
+		}
+		if summary.Null {
+			history.NullRounds++
+			continue
+		}
+		for _, b := range summary.Blocks {
+			if !sameAddress(b.Miner, minerIDAddr) {
+				continue
+			}
+			history.Blocks = append(history.Blocks, MinedBlock{
+				Height:    h,
+				Cid:       b.Cid,
+				Timestamp: summary.Timestamp,
+				WinCount:  b.WinCount,
+			})
+			if len(won) == 0 || won[len(won)-1] != h {
+				won = append(won, h)
+			}
+		}
+	}
+
+	// Look up the block reward for every epoch the miner won
+	var needReward []uint64
+	for _, h := range won {
+		if chunks[chunkStart(h)][h].RewardPerWin == "" {
+			needReward = append(needReward, h)
+		}
+	}
+	if err := c.fetchRewardsPerWin(ctx, chunks, needReward); err != nil {
+		return nil, err
+	}
+	for _, h := range needReward {
+		dirty[chunkStart(h)] = true
+	}
+
+	total := new(big.Int)
+	for i := range history.Blocks {
+		block := &history.Blocks[i]
+		perWin, ok := new(big.Int).SetString(chunks[chunkStart(block.Height)][block.Height].RewardPerWin, 10)
+		if !ok {
+			perWin = new(big.Int)
+		}
+		reward := new(big.Int).Mul(perWin, new(big.Int).SetUint64(block.WinCount))
+		block.Reward = units.NewFIL(reward)
+		total.Add(total, reward)
+
+		history.BlocksMined++
+		history.WinCount += block.WinCount
+	}
+	history.TotalRewards = units.NewFIL(total)
+
+	// Compare actual wins against the expected wins from the miner's power share over the
+	// range, falling back to the share at the head when the node no longer has that state
+	expected, err := c.expectedWins(ctx, minerIDAddr, from, to, chunks)
+	if err != nil {
+		share, headErr := c.qualityAdjPowerShare(ctx, minerID)
+		if headErr != nil {
+			return nil, headErr
+		}
+		history.Warnings = append(history.Warnings, fmt.Sprintf(
+			"could not read the miner's power over the range, expected wins and luck use the power at the chain head: %v", err))
+		expected = share * expectedLeadersPerEpoch * float64(history.Epochs)
+	}
+	history.ExpectedWins = expected
+	history.PowerShare = expected / (expectedLeadersPerEpoch * float64(history.Epochs))
+	if history.ExpectedWins > 0 {
+		history.Luck = float64(history.WinCount) / history.ExpectedWins
+	}
+
+	if store != nil {
+		if err := saveEpochChunks(store, chunks, dirty, head.Height); err != nil {
+			return nil, err
+		}
+	}
+
+	return history, nil
+}
+
+// fetchEpochSummaries fetches the tipsets at the given heights in batches
+func (c *Client) fetchEpochSummaries(ctx context.Context, heights []uint64) (map[uint64]*epochSummary, error) {
+	summaries := make(map[uint64]*epochSummary, len(heights))
+
+	for start := 0; start < len(heights); start += tipsetBatchSize {
+		end := start + tipsetBatchSize
+		if end > len(heights) {
+			end = len(heights)
+		}
+
+		requests := make([]map[string]interface{}, 0, end-start)
+		for i, h := range heights[start:end] {
+			requests = append(requests, newRPCRequest(i, "Filecoin.ChainGetTipSetByHeight", h, nil))
+		}
+
+		responses, err := c.BatchCallWithRetry(ctx, requests)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch tipsets: %w", err)
+		}
+
+		for _, resp := range responses {
+			id, ok := resp["id"].(float64)
+			if !ok {
+				continue
+			}
+			height := heights[start+int(id)]
+			if resp["error"] != nil {
+				return nil, fmt.Errorf("failed to fetch tipset at height %d: %v", height, resp["error"])
+			}
+
+			var ts TipSet
+			if err := decodeResult(resp["result"], &ts); err != nil {
+				return nil, fmt.Errorf("failed to decode tipset at height %d: %w", height, err)
+			}
+
+			// Lotus returns the previous non-null tipset for null rounds
+			if ts.Height != height {
+				summaries[height] = &epochSummary{Null: true}
+				continue
+			}
+
+			summary := &epochSummary{}
+			for i, b := range ts.Blocks {
+				if i >= len(ts.Cids) {
+					break
+				}
+				winCount := uint64(1)
+				if b.ElectionProof != nil {
+					winCount = b.ElectionProof.WinCount
+				}
+				summary.Timestamp = b.Timestamp
+				summary.Blocks = append(summary.Blocks, blockSummary{
+					Cid:      ts.Cids[i]["/"],
+					Miner:    b.Miner,
+					WinCount: winCount,
+				})
+			}
+			summaries[height] = summary
+		}
+	}
+
+	return summaries, nil
+}
+
+// fetchRewardsPerWin reads the reward actor state at each height and records the
+// reward paid per winning ticket
+func (c *Client) fetchRewardsPerWin(ctx context.Context, chunks map[uint64]epochChunk, heights []uint64) error {
+	for start := 0; start < len(heights); start += tipsetBatchSize {
+		end := start + tipsetBatchSize
+		if end > len(heights) {
+			end = len(heights)
+		}
+
+		requests := make([]map[string]interface{}, 0, end-start)
+		for i, h := range heights[start:end] {
+			tsk := chunks[chunkStart(h)][h].tipSetKey()
+			requests = append(requests, newRPCRequest(i, "Filecoin.StateReadState", "f02", tsk))
+		}
+
+		responses, err := c.BatchCallWithRetry(ctx, requests)
+		if err != nil {
+			return fmt.Errorf("failed to read reward actor state: %w", err)
+		}
+
+		for _, resp := range responses {
+			id, ok := resp["id"].(float64)
+			if !ok {
+				continue
+			}
+			height := heights[start+int(id)]
+			if resp["error"] != nil {
+				return fmt.Errorf("failed to read reward actor state at height %d: %s", height, rpcErrorMessage(resp["error"]))
+			}
+
+			var state struct {
+				State struct {
+					ThisEpochReward string `json:"ThisEpochReward"`
+				} `json:"State"`
+			}
+			if err := decodeResult(resp["result"], &state); err != nil {
+				return fmt.Errorf("failed to decode reward actor state at height %d: %w", height, err)
+			}
+			reward, ok := new(big.Int).SetString(state.State.ThisEpochReward, 10)
+			if !ok {
+				return fmt.Errorf("invalid epoch reward %q at height %d", state.State.ThisEpochReward, height)
+			}
+			reward.Div(reward, big.NewInt(expectedLeadersPerEpoch))
+			chunks[chunkStart(height)][height].RewardPerWin = reward.String()
+		}
+	}
+
+	return nil
+}
+
+// expectedWins sums the wins the miner was expected to get over the range from its quality
+// adjusted power share, read at the last tipset of each of at most maxPowerSamples segments
+func (c *Client) expectedWins(ctx context.Context, minerIDAddr string, from, to uint64, chunks map[uint64]epochChunk) (float64, error) {
+	epochs := to - from + 1
+	segment := max(uint64(epochCacheChunk), (epochs+maxPowerSamples-1)/maxPowerSamples)
+
+	type sample struct {
+		epochs uint64
+		tsk    []map[string]string
+	}
+	// Segments of null rounds only count at the share of the next sample
+	var samples []sample
+	var pending uint64
+	for start := from; start <= to; start += segment {
+		end := min(start+segment-1, to)
+		pending += end - start + 1
+		for h := end; h >= start && h <= end; h-- {
+			if summary := chunks[chunkStart(h)][h]; !summary.Null {
+				samples = append(samples, sample{epochs: pending, tsk: summary.tipSetKey()})
+				pending = 0
+				break
+			}
+		}
+	}
+	if len(samples) == 0 {
+		return 0, fmt.Errorf("no tipset between heights %d and %d", from, to)
+	}
+	samples[len(samples)-1].epochs += pending
+
+	requests := make([]map[string]interface{}, 0, len(samples))
+	for i, s := range samples {
+		requests = append(requests, newRPCRequest(i, "Filecoin.StateMinerPower", minerIDAddr, s.tsk))
+	}
+	results, err := c.batchResults(ctx, requests)
+	if err != nil {
+		return 0, err
+	}
+
+	var expected float64
+	for i, s := range samples {
+		var power minerPower
+		if err := decodeResult(results[i], &power); err != nil {
+			return 0, fmt.Errorf("failed to decode miner power: %w", err)
+		}
+		expected += power.share() * expectedLeadersPerEpoch * float64(s.epochs)
+	}
+	return expected, nil
+}
+
+// minerPower is the quality adjusted power of a miner and of the network
+type minerPower struct {
+	MinerPower struct {
+		QualityAdjPower string `json:"QualityAdjPower"`
+	} `json:"MinerPower"`
+	TotalPower struct {
+		QualityAdjPower string `json:"QualityAdjPower"`
+	} `json:"TotalPower"`
+}
+
+// share returns the miner's share of the network quality adjusted power
+func (p *minerPower) share() float64 {
+	miner, ok := new(big.Float).SetString(p.MinerPower.QualityAdjPower)
+	if !ok {
+		return 0
+	}
+	total, ok := new(big.Float).SetString(p.TotalPower.QualityAdjPower)
+	if !ok || total.Sign() == 0 {
+		return 0
+	}
+	share, _ := new(big.Float).Quo(miner, total).Float64()
+	return share
+}
+
+// qualityAdjPowerShare returns the miner's share of the network quality adjusted power
+func (c *Client) qualityAdjPowerShare(ctx context.Context, minerID string) (float64, error) {
+	var power minerPower
+	if err := c.callRPCWithRetry(ctx, "Filecoin.StateMinerPower", []interface{}{minerID, nil}, &power); err != nil {
+		return 0, fmt.Errorf("failed to get miner power: %w", err)
+	}
+	return power.share(), nil
+}
+
+// saveEpochChunks writes the modified chunks back to the cache, leaving out
+// epochs that are not yet final
+func saveEpochChunks(store *cache.Store, chunks map[uint64]epochChunk, dirty map[uint64]bool, headHeight uint64) error {
+	starts := make([]uint64, 0, len(dirty))
+	for start := range dirty {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+
+	for _, start := range starts {
+		final := make(epochChunk, len(chunks[start]))
+		for h, summary := range chunks[start] {
+			if h+chainFinality <= headHeight {
+				final[h] = summary
+			}
+		}
+		if len(final) == 0 {
+			continue
+		}
+		if err := store.Put(chunkKey(start), final); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chunkStart returns the first height of the cache chunk containing height
+func chunkStart(height uint64) uint64 {
+	return height - height%epochCacheChunk
+}
+
+// chunkKey returns the cache key of the chunk starting at start
+func chunkKey(start uint64) string {
+	return fmt.Sprintf("epochs-%d", start)
+}
+
+// resolveMinerID returns the ID address of a miner given by ID or actor (f2) address,
+// for comparing against chain data that names miners by ID
+func (c *Client) resolveMinerID(ctx context.Context, minerID string) (string, error) {
+	addr, err := filaddr.Parse(minerID)
+	if err != nil {
+		return "", err
+	}
+	if addr.Protocol() == address.ID {
+		return minerID, nil
+	}
+	return c.LookupID(ctx, minerID)
+}
+
+// sameAddress compares two addresses ignoring the network prefix
+func sameAddress(a, b string) bool {
+	return len(a) > 1 && len(b) > 1 && a[1:] == b[1:]
+}
+
+// decodeResult converts a generic batch result into a typed value
+func decodeResult(result interface{}, v interface{}) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
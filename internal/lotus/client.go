@@ -1,16 +1,23 @@
 package lotus
 
 import (
-	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/big"
 	"net"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/THCloudAI/thctl/internal/address"
 	"github.com/THCloudAI/thctl/internal/config"
+	"github.com/THCloudAI/thctl/internal/units"
+	"github.com/THCloudAI/thctl/pkg/framework/logger"
 	"github.com/multiformats/go-multiaddr"
 )
 
@@ -20,10 +27,24 @@ type Config struct {
 	AuthToken  string        `mapstructure:"token"`
 	Timeout    time.Duration `mapstructure:"timeout"`
 	RetryCount int          `mapstructure:"retry_count"`
+	// Endpoints are tried in order when set, failing over from one to the next.
+	// APIURL and AuthToken are used when it is empty.
+	Endpoints []Endpoint `mapstructure:"endpoints"`
+	// Balance spreads read-only batch calls across the healthy endpoints
+	Balance bool `mapstructure:"balance"`
+	// MaxLag is the number of epochs an endpoint may be behind the best one, 0 for the default
+	MaxLag int64 `mapstructure:"max_lag"`
 }
 
 var defaultTimeout = 30 * time.Second
 
+// defaultMaxLag is the number of epochs an endpoint may be behind the best one when the
+// configuration does not say
+const defaultMaxLag = 5
+
+// errUnauthorized is returned when the node rejects the API token
+var errUnauthorized = errors.New("the node rejected the API token")
+
 // New creates a new Lotus client
 func New(cfg Config) *Client {
 	if cfg.Timeout == 0 {
@@ -32,36 +53,52 @@ func New(cfg Config) *Client {
 	if cfg.RetryCount == 0 {
 		cfg.RetryCount = 3
 	}
-
-	// Convert multiaddr to HTTP URL if needed
-	apiURL := cfg.APIURL
-	if strings.HasPrefix(apiURL, "/ip4/") || strings.HasPrefix(apiURL, "/ip6/") {
-		maddr, err := multiaddr.NewMultiaddr(apiURL)
-		if err != nil {
-			apiURL = fmt.Sprintf("http://%s", strings.TrimPrefix(apiURL, "/ip4/"))
-		} else {
-			// Extract host and port from multiaddr
-			host, err := maddr.ValueForProtocol(multiaddr.P_IP4)
-			if err != nil {
-				host, _ = maddr.ValueForProtocol(multiaddr.P_IP6)
-			}
-			port, _ := maddr.ValueForProtocol(multiaddr.P_TCP)
-			apiURL = fmt.Sprintf("http://%s:%s/rpc/v0", host, port)
-		}
+	if cfg.MaxLag <= 0 {
+		cfg.MaxLag = defaultMaxLag
 	}
 
 	httpClient := &http.Client{
 		Timeout: cfg.Timeout,
 	}
 
+	if len(cfg.Endpoints) == 0 {
+		cfg.Endpoints = []Endpoint{{URL: cfg.APIURL, Token: cfg.AuthToken}}
+	}
+	endpoints := make([]*endpoint, len(cfg.Endpoints))
+	for i, e := range cfg.Endpoints {
+		endpoints[i] = newEndpoint(e)
+	}
+
 	return &Client{
-		apiURL:     apiURL,
-		token:      cfg.AuthToken,
+		apiURL:     endpoints[0].url,
+		token:      endpoints[0].token,
 		httpClient: httpClient,
+		endpoints:  endpoints,
+		balance:    cfg.Balance,
+		maxLag:     cfg.MaxLag,
+	}
+}
+
+// resolveAPIURL converts an API multiaddr such as /ip4/127.0.0.1/tcp/1234 to an HTTP URL
+func resolveAPIURL(apiURL string) string {
+	if !strings.HasPrefix(apiURL, "/ip4/") && !strings.HasPrefix(apiURL, "/ip6/") {
+		return apiURL
 	}
+	maddr, err := multiaddr.NewMultiaddr(apiURL)
+	if err != nil {
+		return fmt.Sprintf("http://%s", strings.TrimPrefix(apiURL, "/ip4/"))
+	}
+	// Extract host and port from multiaddr
+	host, err := maddr.ValueForProtocol(multiaddr.P_IP4)
+	if err != nil {
+		host, _ = maddr.ValueForProtocol(multiaddr.P_IP6)
+	}
+	port, _ := maddr.ValueForProtocol(multiaddr.P_TCP)
+	return fmt.Sprintf("http://%s/rpc/v0", net.JoinHostPort(host, port))
 }
 
-// NewFromEnv creates a new Lotus client from environment variables
+// NewFromEnv creates a new Lotus client from environment variables. The endpoints that
+// serve its calls are reported by EnvServedBy.
 func NewFromEnv() (*Client, error) {
 	cfg, err := config.Load()
 	if err != nil {
@@ -72,18 +109,46 @@ func NewFromEnv() (*Client, error) {
 		return nil, fmt.Errorf("LOTUS_API_URL environment variable is not set")
 	}
 
-	return New(Config{
+	endpoints := make([]Endpoint, len(cfg.Lotus.Endpoints))
+	for i, e := range cfg.Lotus.Endpoints {
+		endpoints[i] = Endpoint{URL: e.URL, Token: e.Token}
+	}
+
+	client := New(Config{
 		APIURL:    cfg.Lotus.APIURL,
 		AuthToken: cfg.Lotus.AuthToken,
 		Timeout:   cfg.Lotus.Timeout,
-	}), nil
+		Endpoints: endpoints,
+		Balance:   cfg.Lotus.Balance,
+		MaxLag:    cfg.Lotus.MaxLag,
+	})
+
+	envClientsMu.Lock()
+	envClients = append(envClients, client)
+	envClientsMu.Unlock()
+	return client, nil
 }
 
 // Client represents a Lotus API client
 type Client struct {
+	// apiURL and token are those of the primary endpoint
 	apiURL     string
 	token      string
 	httpClient *http.Client
+
+	// The endpoints are health checked on the first call
+	endpoints []*endpoint
+	checkOnce sync.Once
+	balance   bool
+	maxLag    int64
+
+	// The network is detected on the first call and shared by later calls
+	networkMu  sync.Mutex
+	network    *Network
+	actorCodes map[uint64]map[string]string
+	// blockDelay is the epoch duration of the detected network, read by the endpoint
+	// checks that run while it is being detected
+	blockDelay atomic.Int64
 }
 
 // callRPCWithRetry makes a JSON-RPC call to the Lotus API with retry
@@ -92,6 +157,9 @@ func (c *Client) callRPCWithRetry(ctx context.Context, method string, params int
 		return fmt.Errorf("LOTUS_API_URL is not set")
 	}
 
+	// Detect the network on connect; failures surface from the call itself
+	c.Network(ctx)
+
 	requestBody, err := json.Marshal(map[string]interface{}{
 		"jsonrpc": "2.0",
 		"method":  method,
@@ -102,77 +170,78 @@ func (c *Client) callRPCWithRetry(ctx context.Context, method string, params int
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.apiURL, bytes.NewBuffer(requestBody))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	if c.token != "" {
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
-	}
-
-	resp, err := c.httpClient.Do(req)
+	data, e, err := c.do(ctx, requestBody, nil)
 	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+		return err
 	}
-	defer resp.Body.Close()
+	e.served(method)
+	return decodeRPCResponse(data, result)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+// minerInfoWorkers limits the miners fetched at the same time by GetMinerInfos
+const minerInfoWorkers = 4
+
+// GetMinerInfos retrieves the comprehensive information of several miners concurrently,
+// returned in the order of minerIDs
+func (c *Client) GetMinerInfos(ctx context.Context, minerIDs []string) ([]*MinerInfo, error) {
+	infos := make([]*MinerInfo, len(minerIDs))
+	errs := make([]error, len(minerIDs))
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, minerInfoWorkers)
+	for i, minerID := range minerIDs {
+		wg.Add(1)
+		go func(i int, minerID string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			infos[i], errs[i] = c.GetComprehensiveMinerInfo(ctx, minerID)
+		}(i, minerID)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("failed to get miner info of %s: %w", minerIDs[i], err)
+		}
 	}
+	return infos, nil
+}
 
-	var rpcResponse struct {
-		Error  *struct{ Message string } `json:"error,omitempty"`
-		Result json.RawMessage         `json:"result,omitempty"`
+// GetComprehensiveMinerInfo retrieves comprehensive information about a miner
+func (c *Client) GetComprehensiveMinerInfo(ctx context.Context, minerID string) (*MinerInfo, error) {
+	if err := address.ValidateMiner(minerID); err != nil {
+		return nil, err
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&rpcResponse); err != nil {
-		return fmt.Errorf("failed to decode response: %w", err)
+	// An f2 address may belong to any actor, so check the code before reading miner state
+	actorType, err := c.GetActorType(ctx, minerID)
+	if err != nil {
+		return nil, err
 	}
-
-	if rpcResponse.Error != nil {
-		return fmt.Errorf("RPC error: %s", rpcResponse.Error.Message)
+	if actorType != ActorMiner {
+		return nil, fmt.Errorf("%s is a %s actor, not a storage miner", minerID, actorType)
 	}
 
-	if err := json.Unmarshal(rpcResponse.Result, result); err != nil {
-		return fmt.Errorf("failed to unmarshal result: %w", err)
+	// Miners queried by actor address are reported and recorded under their ID
+	idAddr, err := c.resolveMinerID(ctx, minerID)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
-}
-
-// GetComprehensiveMinerInfo retrieves comprehensive information about a miner
-func (c *Client) GetComprehensiveMinerInfo(ctx context.Context, minerID string) (*MinerInfo, error) {
 	info := &MinerInfo{
-		ID:                 minerID,
+		ID:                 idAddr,
 		Address:           minerID,
-		Actor:             "storageminer",
+		Actor:             actorType,
 		OwnedMiners:       make([]string, 0),
 		WorkerMiners:      make([]string, 0),
 		BenefitedMiners:   make([]string, 0),
-		CreateTimestamp:   time.Now().Unix(),
-		LastSeenTimestamp: time.Now().Unix(),
 	}
 
 	// Initialize nested structs
-	info.Miner.Owner = struct {
-		Address string `json:"address"`
-		Balance string `json:"balance"`
-		ID      string `json:"id,omitempty"`
-	}{}
-	info.Miner.Worker = struct {
-		Address string `json:"address"`
-		Balance string `json:"balance"`
-		ID      string `json:"id,omitempty"`
-	}{}
-	info.Miner.Beneficiary = struct {
-		Address string `json:"address"`
-		Balance string `json:"balance"`
-		ID      string `json:"id,omitempty"`
-	}{}
 	info.Miner.ControlAddresses = make([]ControlAddress, 0)
 	info.Miner.MultiAddresses = make([]string, 0)
+	info.Miner.PendingChanges = make([]PendingChange, 0)
 	info.Miner.Sectors = struct {
 		Live       uint64 `json:"live"`
 		Active     uint64 `json:"active"`
@@ -180,16 +249,6 @@ func (c *Client) GetComprehensiveMinerInfo(ctx context.Context, minerID string)
 		Recovering uint64 `json:"recovering"`
 	}{}
 
-	// Initialize default values for strings
-	info.Miner.TotalRewards = "0"
-	info.Miner.PreCommitDeposits = "0"
-	info.Miner.VestingFunds = "0"
-	info.Miner.InitialPledgeRequirement = "0"
-	info.Miner.AvailableBalance = "0"
-	info.Miner.SectorPledgeBalance = "0"
-	info.Miner.PledgeBalance = "0"
-	info.Balance = "0"
-
 	// Prepare batch RPC calls
 	var (
 		minerInfo  interface{}
@@ -253,9 +312,10 @@ func (c *Client) GetComprehensiveMinerInfo(ctx context.Context, minerID string)
 	}
 
 	// Check for individual call errors and process responses
+	log := logger.WithModule("lotus")
 	for _, resp := range responses {
 		if resp["error"] != nil {
-			fmt.Printf("Error in RPC call: %v\n", resp["error"])
+			log.Debugf("Miner info call %v of %s failed: %v", resp["id"], minerID, resp["error"])
 			continue
 		}
 
@@ -277,15 +337,8 @@ func (c *Client) GetComprehensiveMinerInfo(ctx context.Context, minerID string)
 		case 3:
 			state = result
 		case 4:
-			// Process robust address
-			if result != nil {
-				robustBytes, _ := json.MarshalIndent(result, "", "  ")
-				fmt.Printf("Debug - StateLookupRobustAddress response:\n%s\n", string(robustBytes))
-				if robustAddr, ok := result.(string); ok {
-					info.Robust = robustAddr
-				}
-			} else {
-				fmt.Printf("Debug - StateLookupRobustAddress returned nil\n")
+			if robustAddr, ok := result.(string); ok {
+				info.Robust = robustAddr
 			}
 		case 5:
 			faults = result
@@ -301,12 +354,32 @@ func (c *Client) GetComprehensiveMinerInfo(ctx context.Context, minerID string)
 		return nil, fmt.Errorf("failed to process basic info: %v", err)
 	}
 
+	if err := c.processPendingChanges(ctx, info, minerInfo); err != nil {
+		return nil, fmt.Errorf("failed to process pending changes: %v", err)
+	}
+
+	if err := c.resolveAddresses(ctx, info); err != nil {
+		return nil, fmt.Errorf("failed to resolve miner addresses: %v", err)
+	}
+
 	c.processPowerInfo(info, minerPower)
 	c.processStateInfo(info, state)
 	c.processFaults(info, faults)
 	c.processRecoveries(info, recoveries)
 	c.processActiveSectors(info, active)
 
+	// Creation and last-active epochs need a search over chain history, which
+	// can fail on nodes without full state; report that instead of failing
+	lifetime, err := c.GetActorLifetime(ctx, info.ID, c.CacheStore(ctx, "chain"))
+	if err != nil {
+		info.Warnings = append(info.Warnings, fmt.Sprintf("could not determine actor creation and last active epochs: %v", err))
+	} else {
+		info.CreateHeight = lifetime.CreateHeight
+		info.CreateTimestamp = lifetime.CreateTimestamp
+		info.LastSeenHeight = lifetime.LastActiveHeight
+		info.LastSeenTimestamp = lifetime.LastActiveTimestamp
+	}
+
 	return info, nil
 }
 
@@ -350,14 +423,19 @@ func (c *Client) processBasicInfo(ctx context.Context, info *MinerInfo, minerInf
 		info.Miner.PeerID = peerID
 	}
 
-	// Extract multiaddresses
+	// Extract multiaddresses, which JSON delivers as base64 strings
 	if multiaddrs, ok := basicInfo["Multiaddrs"].([]interface{}); ok {
 		for _, addr := range multiaddrs {
-			if bytes, ok := addr.([]byte); ok {
-				maddr, err := multiaddr.NewMultiaddrBytes(bytes)
-				if err == nil {
-					info.Miner.MultiAddresses = append(info.Miner.MultiAddresses, maddr.String())
-				}
+			encoded, ok := addr.(string)
+			if !ok {
+				continue
+			}
+			raw, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				continue
+			}
+			if maddr, err := multiaddr.NewMultiaddrBytes(raw); err == nil {
+				info.Miner.MultiAddresses = append(info.Miner.MultiAddresses, maddr.String())
 			}
 		}
 	}
@@ -370,58 +448,255 @@ func (c *Client) processBasicInfo(ctx context.Context, info *MinerInfo, minerInf
 	return nil
 }
 
-// Process power info
-func (c *Client) processPowerInfo(info *MinerInfo, powerInfo interface{}) {
-	if powerInfo == nil {
-		return
+// processPendingChanges fills in the beneficiary term and the owner, worker and
+// beneficiary changes that have been proposed but have not taken effect yet
+func (c *Client) processPendingChanges(ctx context.Context, info *MinerInfo, minerInfo interface{}) error {
+	var state stateMinerInfo
+	if err := decodeResult(minerInfo, &state); err != nil {
+		return err
 	}
+	network, err := c.Network(ctx)
+	if err != nil {
+		return err
+	}
+	head, err := c.GetChainHead(ctx)
+	if err != nil {
+		return err
+	}
+	epoch := int64(head.Height)
 
-	power, ok := powerInfo.(map[string]interface{})
-	if !ok {
-		return
+	term := state.BeneficiaryTerm
+	info.Miner.BeneficiaryTerm = BeneficiaryTerm{
+		Quota:      units.FILFromAtto(term.Quota),
+		UsedQuota:  units.FILFromAtto(term.UsedQuota),
+		Expiration: term.Expiration,
+	}
+	if !sameAddress(state.Beneficiary, state.Owner) {
+		remaining := new(big.Int).Sub(parseBigInt(term.Quota), parseBigInt(term.UsedQuota))
+		if remaining.Sign() < 0 {
+			remaining.SetInt64(0)
+		}
+		info.Miner.BeneficiaryTerm.RemainingQuota = units.NewFIL(remaining)
+		info.Miner.BeneficiaryTerm.ExpirationTimestamp = network.EpochToTimestamp(term.Expiration)
+		info.Miner.BeneficiaryTerm.Expired = term.Expiration <= epoch
+		if info.Miner.BeneficiaryTerm.Expired {
+			info.Warnings = append(info.Warnings, fmt.Sprintf(
+				"beneficiary term of %s expired at epoch %d; withdrawals go to the owner only", state.Beneficiary, term.Expiration))
+		}
 	}
 
-	if minerPower, ok := power["MinerPower"].(map[string]interface{}); ok {
-		if raw, ok := minerPower["RawBytePower"].(string); ok {
-			info.Miner.RawBytePower = raw
+	if state.PendingOwnerAddress != nil {
+		info.Miner.PendingChanges = append(info.Miner.PendingChanges, PendingChange{
+			Role:    RoleOwner,
+			Current: state.Owner,
+			New:     *state.PendingOwnerAddress,
+			Action:  fmt.Sprintf("%s must confirm by sending ChangeOwnerAddress", *state.PendingOwnerAddress),
+		})
+	}
+
+	if state.hasPendingWorker() {
+		change := PendingChange{
+			Role:               RoleWorker,
+			Current:            state.Worker,
+			New:                state.NewWorker,
+			EffectiveEpoch:     state.WorkerChangeEpoch,
+			EffectiveTimestamp: network.EpochToTimestamp(state.WorkerChangeEpoch),
 		}
-		if quality, ok := minerPower["QualityAdjPower"].(string); ok {
-			info.Miner.QualityAdjPower = quality
+		if epoch >= state.WorkerChangeEpoch {
+			change.Action = "the owner can confirm it now with ConfirmChangeWorkerAddress"
+		} else {
+			change.Action = fmt.Sprintf("the owner can confirm it with ConfirmChangeWorkerAddress from epoch %d", state.WorkerChangeEpoch)
 		}
+		info.Miner.PendingChanges = append(info.Miner.PendingChanges, change)
 	}
 
-	if totalPower, ok := power["TotalPower"].(map[string]interface{}); ok {
-		if raw, ok := totalPower["RawBytePower"].(string); ok {
-			info.Miner.NetworkRawBytePower = raw
+	if pending := state.PendingBeneficiaryTerm; pending != nil {
+		quota := units.FILFromAtto(pending.NewQuota)
+		change := PendingChange{
+			Role:          RoleBeneficiary,
+			Current:       state.Beneficiary,
+			New:           pending.NewBeneficiary,
+			NewQuota:      &quota,
+			NewExpiration: pending.NewExpiration,
 		}
-		if quality, ok := totalPower["QualityAdjPower"].(string); ok {
-			info.Miner.NetworkQualityAdjPower = quality
+		// The current beneficiary only has to approve while its term still has value
+		var waiting []string
+		if !pending.ApprovedByNominee {
+			waiting = append(waiting, pending.NewBeneficiary)
+		}
+		if !pending.ApprovedByBeneficiary && !sameAddress(state.Beneficiary, state.Owner) &&
+			term.Expiration > epoch && parseBigInt(term.UsedQuota).Cmp(parseBigInt(term.Quota)) < 0 {
+			waiting = append(waiting, state.Beneficiary)
+		}
+		if len(waiting) > 0 {
+			change.Action = fmt.Sprintf("awaiting approval by %s with ChangeBeneficiary", strings.Join(waiting, " and "))
+		} else {
+			change.Action = "approved; takes effect with the next ChangeBeneficiary"
+		}
+		info.Miner.PendingChanges = append(info.Miner.PendingChanges, change)
+	}
+
+	for _, change := range info.Miner.PendingChanges {
+		info.Warnings = append(info.Warnings, fmt.Sprintf("pending %s change from %s to %s: %s", change.Role, change.Current, change.New, change.Action))
+	}
+	return nil
+}
+
+// Per-address calls issued by resolveAddresses, in request order
+const (
+	addrCallLookupID = iota
+	addrCallAccountKey
+	addrCallGetActor
+	addrCallCount
+)
+
+// resolveAddresses resolves the owner, worker, beneficiary and control addresses
+// to their ID and robust forms and fetches their balances in a single batch
+func (c *Client) resolveAddresses(ctx context.Context, info *MinerInfo) error {
+	targets := []*AddressInfo{&info.Miner.Owner, &info.Miner.Worker, &info.Miner.Beneficiary}
+	for i := range info.Miner.ControlAddresses {
+		targets = append(targets, &info.Miner.ControlAddresses[i])
+	}
+
+	requests := make([]map[string]interface{}, 0, len(targets)*addrCallCount)
+	for i, target := range targets {
+		if target.Address == "" {
+			continue
+		}
+		base := i * addrCallCount
+		requests = append(requests,
+			newRPCRequest(base+addrCallLookupID, "Filecoin.StateLookupID", target.Address, nil),
+			newRPCRequest(base+addrCallAccountKey, "Filecoin.StateAccountKey", target.Address, nil),
+			newRPCRequest(base+addrCallGetActor, "Filecoin.StateGetActor", target.Address, nil),
+		)
+	}
+	if len(requests) == 0 {
+		return nil
+	}
+
+	responses, err := c.BatchCallWithRetry(ctx, requests)
+	if err != nil {
+		return err
+	}
+
+	for _, resp := range responses {
+		id, ok := resp["id"].(float64)
+		if !ok || resp["error"] != nil {
+			continue
+		}
+		target := targets[int(id)/addrCallCount]
+
+		switch int(id) % addrCallCount {
+		case addrCallLookupID:
+			if idAddr, ok := resp["result"].(string); ok {
+				target.ID = idAddr
+			}
+		case addrCallAccountKey:
+			if key, ok := resp["result"].(string); ok {
+				target.Robust = key
+			}
+		case addrCallGetActor:
+			if actor, ok := resp["result"].(map[string]interface{}); ok {
+				if balance, ok := actor["Balance"].(string); ok {
+					target.Balance = units.FILFromAtto(balance)
+				}
+			}
+		}
+	}
+
+	// StateAccountKey only works for account actors; multisig and other actor
+	// types need a reverse lookup through the init actor instead
+	var (
+		pending  []*AddressInfo
+		fallback []map[string]interface{}
+	)
+	for _, target := range targets {
+		if target.Robust != "" || target.Address == "" {
+			continue
+		}
+		if !isIDAddress(target.Address) {
+			target.Robust = target.Address
+			continue
+		}
+		fallback = append(fallback, newRPCRequest(len(pending), "Filecoin.StateLookupRobustAddress", target.Address, nil))
+		pending = append(pending, target)
+	}
+	if len(fallback) > 0 {
+		responses, err := c.BatchCallWithRetry(ctx, fallback)
+		if err != nil {
+			return err
+		}
+		for _, resp := range responses {
+			id, ok := resp["id"].(float64)
+			if !ok || resp["error"] != nil {
+				continue
+			}
+			if robust, ok := resp["result"].(string); ok {
+				pending[int(id)].Robust = robust
+			}
+		}
+	}
+
+	c.checkPoStBalances(info)
+	return nil
+}
+
+// lowBalanceThreshold is the balance below which worker and control addresses
+// risk being unable to pay for WindowPoSt messages
+var lowBalanceThreshold = units.NewFIL(big.NewInt(1e18))
+
+// checkPoStBalances flags worker and control addresses whose balance is too low
+// to reliably submit WindowPoSt messages
+func (c *Client) checkPoStBalances(info *MinerInfo) {
+	check := func(role string, addr AddressInfo) {
+		if addr.Address == "" {
+			return
 		}
+		if addr.Balance.Cmp(lowBalanceThreshold) >= 0 {
+			return
+		}
+		info.Warnings = append(info.Warnings, fmt.Sprintf(
+			"%s address %s has a low balance (%s); WindowPoSt messages may fail",
+			role, addr.Address, addr.Balance))
+	}
+
+	check("worker", info.Miner.Worker)
+	for i, ctrl := range info.Miner.ControlAddresses {
+		check(fmt.Sprintf("control %d", i+1), ctrl)
 	}
 }
 
-// Process chain head info
-func (c *Client) processChainHead(info *MinerInfo, headInfo interface{}) {
-	if headInfo == nil {
+// isIDAddress reports whether addr is an ID (f0/t0) address
+func isIDAddress(addr string) bool {
+	return len(addr) > 2 && (addr[0] == 'f' || addr[0] == 't') && addr[1] == '0'
+}
+
+// Process power info
+func (c *Client) processPowerInfo(info *MinerInfo, powerInfo interface{}) {
+	if powerInfo == nil {
 		return
 	}
 
-	head, ok := headInfo.(map[string]interface{})
+	power, ok := powerInfo.(map[string]interface{})
 	if !ok {
 		return
 	}
 
-	if height, ok := head["Height"].(float64); ok {
-		info.LastSeenHeight = uint64(height)
-		info.CreateHeight = uint64(height) // 临时设置，实际应该从其他API获取
+	if minerPower, ok := power["MinerPower"].(map[string]interface{}); ok {
+		if raw, ok := minerPower["RawBytePower"].(string); ok {
+			info.Miner.RawBytePower = units.BytesFromString(raw)
+		}
+		if quality, ok := minerPower["QualityAdjPower"].(string); ok {
+			info.Miner.QualityAdjPower = units.BytesFromString(quality)
+		}
 	}
 
-	if blocks, ok := head["Blocks"].([]interface{}); ok && len(blocks) > 0 {
-		if block, ok := blocks[0].(map[string]interface{}); ok {
-			if timestamp, ok := block["Timestamp"].(float64); ok {
-				info.LastSeenTimestamp = int64(timestamp)
-				info.CreateTimestamp = int64(timestamp) // 临时设置，实际应该从其他API获取
-			}
+	if totalPower, ok := power["TotalPower"].(map[string]interface{}); ok {
+		if raw, ok := totalPower["RawBytePower"].(string); ok {
+			info.Miner.NetworkRawBytePower = units.BytesFromString(raw)
+		}
+		if quality, ok := totalPower["QualityAdjPower"].(string); ok {
+			info.Miner.NetworkQualityAdjPower = units.BytesFromString(quality)
 		}
 	}
 }
@@ -438,7 +713,7 @@ func (c *Client) processActorInfo(info *MinerInfo, actorInfo interface{}) {
 	}
 
 	if balance, ok := actor["Balance"].(string); ok {
-		info.Balance = balance
+		info.Balance = units.FILFromAtto(balance)
 	}
 }
 
@@ -455,26 +730,19 @@ func (c *Client) processStateInfo(info *MinerInfo, stateInfo interface{}) {
 
 	// Process balance
 	if balance, ok := state["Balance"].(string); ok {
-		info.Balance = balance
+		info.Balance = units.FILFromAtto(balance)
 	}
 
 	if stateObj, ok := state["State"].(map[string]interface{}); ok {
-		// Process balances
-		if lockedFunds, ok := stateObj["LockedFunds"].(string); ok {
-			info.Miner.AvailableBalance = lockedFunds
-		}
-		if pledge, ok := stateObj["InitialPledge"].(string); ok {
-			info.Miner.InitialPledgeRequirement = pledge
-			info.Miner.SectorPledgeBalance = pledge
-			info.Miner.PledgeBalance = pledge
-		}
-		if deposits, ok := stateObj["PreCommitDeposits"].(string); ok {
-			info.Miner.PreCommitDeposits = deposits
-		}
-		if vesting, ok := stateObj["VestingFunds"].(map[string]interface{}); ok {
-			if vestingFunds, ok := vesting["/"].(string); ok {
-				info.Miner.VestingFunds = vestingFunds
-			}
+		// Process balances the same way as the ledger snapshots so they reconcile
+		var funds minerFunds
+		if err := decodeResult(stateObj, &funds); err == nil {
+			info.Miner.VestingFunds = units.FILFromAtto(funds.LockedFunds)
+			info.Miner.InitialPledgeRequirement = units.FILFromAtto(funds.InitialPledge)
+			info.Miner.SectorPledgeBalance = units.FILFromAtto(funds.InitialPledge)
+			info.Miner.PledgeBalance = units.FILFromAtto(funds.InitialPledge)
+			info.Miner.PreCommitDeposits = units.FILFromAtto(funds.PreCommitDeposits)
+			info.Miner.AvailableBalance = units.NewFIL(funds.available(info.Balance.Atto()))
 		}
 	}
 }
@@ -528,52 +796,37 @@ func (c *Client) processActiveSectors(info *MinerInfo, active interface{}) {
 	}
 }
 
-// BatchCall executes multiple RPC calls in a single request
+// newRPCRequest builds a single JSON-RPC request for use in a batch
+func newRPCRequest(id int, method string, params ...interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+		"id":      id,
+	}
+}
+
+// BatchCall executes multiple RPC calls in a single request. The calls are recorded on
+// the endpoint that served them, see ServedBy. Read-only batches are split across the
+// healthy endpoints when balancing is enabled.
 func (c *Client) BatchCall(ctx context.Context, requests []map[string]interface{}) ([]map[string]interface{}, error) {
 	if len(requests) == 0 {
 		return nil, fmt.Errorf("no requests in batch")
 	}
 
-	// Marshal requests
-	data, err := json.Marshal(requests)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal requests: %w", err)
-	}
-
-	// Create request
-	req, err := http.NewRequestWithContext(ctx, "POST", c.apiURL, bytes.NewReader(data))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	if c.token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.token)
-	}
-
-	// Send request
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check status code
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
-	// Decode response
-	var responses []map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&responses); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if c.balance && len(requests) > 1 && isReadOnly(requests) {
+		if healthy := c.healthyEndpoints(ctx); len(healthy) > 1 {
+			return c.balancedBatch(ctx, requests, healthy)
+		}
 	}
-
-	return responses, nil
+	return c.batchBody(ctx, requests, nil)
 }
 
 // BatchCallWithRetry executes batch RPC calls with retry mechanism
 func (c *Client) BatchCallWithRetry(ctx context.Context, requests []map[string]interface{}) ([]map[string]interface{}, error) {
+	// Detect the network on connect; failures surface from the call itself
+	c.Network(ctx)
+
 	var lastErr error
 	for i := 0; i < 3; i++ {
 		responses, err := c.BatchCall(ctx, requests)
@@ -589,6 +842,35 @@ func (c *Client) BatchCallWithRetry(ctx context.Context, requests []map[string]i
 	return nil, fmt.Errorf("failed after 3 retries: %v", lastErr)
 }
 
+// batchResults sends the requests in batches and returns their results by request id. It
+// fails with the error of the first call the node could not answer.
+func (c *Client) batchResults(ctx context.Context, requests []map[string]interface{}) (map[int]interface{}, error) {
+	results := make(map[int]interface{}, len(requests))
+	for start := 0; start < len(requests); start += tipsetBatchSize {
+		end := min(start+tipsetBatchSize, len(requests))
+		responses, err := c.BatchCallWithRetry(ctx, requests[start:end])
+		if err != nil {
+			return nil, err
+		}
+		methods := make(map[int]string, end-start)
+		for _, req := range requests[start:end] {
+			id, _ := req["id"].(int)
+			methods[id], _ = req["method"].(string)
+		}
+		for _, resp := range responses {
+			id, ok := resp["id"].(float64)
+			if !ok {
+				continue
+			}
+			if resp["error"] != nil {
+				return nil, fmt.Errorf("%s call %d failed: %s", methods[int(id)], int(id), rpcErrorMessage(resp["error"]))
+			}
+			results[int(id)] = resp["result"]
+		}
+	}
+	return results, nil
+}
+
 // isRetryableError determines if an error is retryable
 func isRetryableError(err error) bool {
 	if err == nil {
@@ -607,19 +889,32 @@ func isRetryableError(err error) bool {
 		strings.Contains(errStr, "unexpected status code: 5")
 }
 
-// GetSectorInfo retrieves information about a specific sector
-func (c *Client) GetSectorInfo(ctx context.Context, minerID string, sectorNumber uint64) (*SectorInfo, error) {
-	var result SectorInfo
-	err := c.callRPCWithRetry(ctx, "Filecoin.StateSectorGetInfo", []interface{}{minerID, sectorNumber, nil}, &result)
+// GetMinerPower retrieves the power of a miner and of the whole network
+func (c *Client) GetMinerPower(ctx context.Context, minerID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := c.callRPCWithRetry(ctx, "Filecoin.StateMinerPower", []interface{}{minerID, nil}, &result)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get sector info: %w", err)
+		return nil, fmt.Errorf("failed to get miner power: %w", err)
 	}
-	return &result, nil
+	return result, nil
 }
 
 // ListSectors retrieves a list of sectors for a miner
 func (c *Client) ListSectors(ctx context.Context, minerID string) ([]uint64, error) {
-	var result []uint64
+	sectors, err := c.getMinerSectors(ctx, minerID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]uint64, 0, len(sectors))
+	for _, sector := range sectors {
+		result = append(result, sector.SectorNumber)
+	}
+	return result, nil
+}
+
+// getMinerSectors retrieves the on-chain info of every sector of a miner
+func (c *Client) getMinerSectors(ctx context.Context, minerID string) ([]sectorOnChain, error) {
+	var result []sectorOnChain
 	err := c.callRPCWithRetry(ctx, "Filecoin.StateMinerSectors", []interface{}{minerID, nil, nil}, &result)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list sectors: %w", err)
@@ -627,6 +922,56 @@ func (c *Client) ListSectors(ctx context.Context, minerID string) ([]uint64, err
 	return result, nil
 }
 
+// getStateMinerInfo retrieves the typed subset of StateMinerInfo used by thctl
+func (c *Client) getStateMinerInfo(ctx context.Context, minerID string) (*stateMinerInfo, error) {
+	var result stateMinerInfo
+	err := c.callRPCWithRetry(ctx, "Filecoin.StateMinerInfo", []interface{}{minerID, nil}, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get miner info: %w", err)
+	}
+	return &result, nil
+}
+
+// GetMinerPeer retrieves the libp2p peer ID and multiaddrs a miner advertises on chain.
+// Multiaddrs that cannot be decoded are skipped.
+func (c *Client) GetMinerPeer(ctx context.Context, minerID string) (*MinerPeer, error) {
+	info, err := c.getStateMinerInfo(ctx, minerID)
+	if err != nil {
+		return nil, err
+	}
+
+	peer := &MinerPeer{MinerID: minerID, Multiaddrs: make([]string, 0, len(info.Multiaddrs))}
+	if info.PeerId != nil {
+		peer.PeerID = *info.PeerId
+	}
+	for _, raw := range info.Multiaddrs {
+		if maddr, err := multiaddr.NewMultiaddrBytes(raw); err == nil {
+			peer.Multiaddrs = append(peer.Multiaddrs, maddr.String())
+		}
+	}
+	return peer, nil
+}
+
+// LookupID resolves an address to its ID address
+func (c *Client) LookupID(ctx context.Context, addr string) (string, error) {
+	var result string
+	err := c.callRPCWithRetry(ctx, "Filecoin.StateLookupID", []interface{}{addr, nil}, &result)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up ID of %s: %w", addr, err)
+	}
+	return result, nil
+}
+
+// LookupRobustAddress resolves an ID address to the robust address the actor was created with
+func (c *Client) LookupRobustAddress(ctx context.Context, addr string) (string, error) {
+	var result string
+	err := c.callRPCWithRetry(ctx, "Filecoin.StateLookupRobustAddress", []interface{}{addr, nil}, &result)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up robust address of %s: %w", addr, err)
+	}
+	return result, nil
+}
+
 // GetSectorPenalty retrieves penalty information for a sector
 func (c *Client) GetSectorPenalty(ctx context.Context, minerID string, sectorNumber uint64) (*SectorPenalty, error) {
 	var result SectorPenalty
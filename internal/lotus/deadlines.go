@@ -0,0 +1,321 @@
+package lotus
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/go-state-types/builtin/v15/miner"
+)
+
+// provingDeadline is the result of StateMinerProvingDeadline
+type provingDeadline struct {
+	CurrentEpoch         int64  `json:"CurrentEpoch"`
+	PeriodStart          int64  `json:"PeriodStart"`
+	Index                uint64 `json:"Index"`
+	Open                 int64  `json:"Open"`
+	Close                int64  `json:"Close"`
+	WPoStPeriodDeadlines uint64 `json:"WPoStPeriodDeadlines"`
+	WPoStProvingPeriod   int64  `json:"WPoStProvingPeriod"`
+	WPoStChallengeWindow int64  `json:"WPoStChallengeWindow"`
+}
+
+// deadline is a single entry of StateMinerDeadlines
+type deadline struct {
+	PostSubmissions      bitfield.BitField `json:"PostSubmissions"`
+	DisputableProofCount uint64            `json:"DisputableProofCount"`
+}
+
+// partition is a single entry of StateMinerPartitions
+type partition struct {
+	AllSectors        bitfield.BitField `json:"AllSectors"`
+	FaultySectors     bitfield.BitField `json:"FaultySectors"`
+	RecoveringSectors bitfield.BitField `json:"RecoveringSectors"`
+	LiveSectors       bitfield.BitField `json:"LiveSectors"`
+	ActiveSectors     bitfield.BitField `json:"ActiveSectors"`
+}
+
+// PoSt status values reported for each deadline
+const (
+	PoStStatusIdle      = "idle"
+	PoStStatusPending   = "pending"
+	PoStStatusSubmitted = "submitted"
+	PoStStatusMissed    = "missed"
+)
+
+// GetDeadlineSchedule builds the WindowPoSt schedule of a miner with per-partition
+// sector counts for every deadline. When scanMessages is set, the SubmitWindowedPoSt
+// messages of the last proving period are also decoded to find the epoch of the last
+// PoSt of each deadline and the sectors it skipped.
+func (c *Client) GetDeadlineSchedule(ctx context.Context, minerID string, scanMessages bool) (*DeadlineSchedule, error) {
+	var (
+		dlInfo    provingDeadline
+		deadlines []deadline
+	)
+	if err := c.callRPCWithRetry(ctx, "Filecoin.StateMinerProvingDeadline", []interface{}{minerID, nil}, &dlInfo); err != nil {
+		return nil, fmt.Errorf("failed to get proving deadline: %w", err)
+	}
+	if err := c.callRPCWithRetry(ctx, "Filecoin.StateMinerDeadlines", []interface{}{minerID, nil}, &deadlines); err != nil {
+		return nil, fmt.Errorf("failed to get deadlines: %w", err)
+	}
+	network, err := c.Network(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	partitions, err := c.getPartitions(ctx, minerID, len(deadlines))
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := &DeadlineSchedule{
+		MinerID:         minerID,
+		CurrentEpoch:    dlInfo.CurrentEpoch,
+		PeriodStart:     dlInfo.PeriodStart,
+		CurrentDeadline: dlInfo.Index,
+		ChallengeWindow: dlInfo.WPoStChallengeWindow,
+		Deadlines:       make([]DeadlineStatus, 0, len(deadlines)),
+	}
+
+	for i, dl := range deadlines {
+		status := DeadlineStatus{
+			Index:            uint64(i),
+			DisputableProofs: dl.DisputableProofCount,
+			Partitions:       make([]PartitionStatus, 0, len(partitions[i])),
+		}
+
+		// Windows of deadlines that already closed in this period are reported for the next period
+		status.Open = dlInfo.PeriodStart + int64(i)*dlInfo.WPoStChallengeWindow
+		if status.Open+dlInfo.WPoStChallengeWindow <= dlInfo.CurrentEpoch {
+			status.Open += dlInfo.WPoStProvingPeriod
+		}
+		status.Close = status.Open + dlInfo.WPoStChallengeWindow
+		status.IsOpen = status.Open <= dlInfo.CurrentEpoch && dlInfo.CurrentEpoch < status.Close
+		if !status.IsOpen {
+			status.OpensInSeconds = (status.Open - dlInfo.CurrentEpoch) * network.BlockDelaySecs
+		}
+
+		proven, err := dl.PostSubmissions.AllMap(uint64(len(partitions[i])))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode PoSt submissions of deadline %d: %w", i, err)
+		}
+
+		for j, p := range partitions[i] {
+			ps := PartitionStatus{
+				Index:      uint64(j),
+				Live:       countBits(p.LiveSectors),
+				Active:     countBits(p.ActiveSectors),
+				Faulty:     countBits(p.FaultySectors),
+				Recovering: countBits(p.RecoveringSectors),
+				Proven:     proven[uint64(j)],
+			}
+			if ps.Proven {
+				status.ProvenPartitions++
+			}
+			status.Live += ps.Live
+			status.Active += ps.Active
+			status.Faulty += ps.Faulty
+			status.Recovering += ps.Recovering
+			status.Partitions = append(status.Partitions, ps)
+		}
+
+		status.PoStStatus = postStatus(status)
+		schedule.Deadlines = append(schedule.Deadlines, status)
+	}
+
+	if scanMessages {
+		if err := c.applyPoStMessages(ctx, minerID, schedule, dlInfo.CurrentEpoch-dlInfo.WPoStProvingPeriod); err != nil {
+			schedule.Warnings = append(schedule.Warnings, fmt.Sprintf("could not scan WindowPoSt messages: %v", err))
+		} else {
+			schedule.MessagesScanned = true
+		}
+	}
+
+	for _, status := range schedule.Deadlines {
+		switch {
+		case status.PoStStatus == PoStStatusMissed:
+			schedule.Warnings = append(schedule.Warnings, fmt.Sprintf(
+				"deadline %d: no PoSt recorded for the last window with %d live sectors", status.Index, status.Live))
+		case status.IsOpen && status.PoStStatus == PoStStatusPending:
+			schedule.Warnings = append(schedule.Warnings, fmt.Sprintf(
+				"deadline %d is open and %d of %d partitions are not yet proven (window closes in %ds)",
+				status.Index, uint64(len(status.Partitions))-status.ProvenPartitions, len(status.Partitions),
+				(status.Close-dlInfo.CurrentEpoch)*network.BlockDelaySecs))
+		}
+		if status.Faulty > 0 {
+			schedule.Warnings = append(schedule.Warnings, fmt.Sprintf(
+				"deadline %d has %d faulty sectors (%d recovering)", status.Index, status.Faulty, status.Recovering))
+		}
+	}
+
+	return schedule, nil
+}
+
+// getPartitions fetches the partitions of the first count deadlines of a miner in one batch
+func (c *Client) getPartitions(ctx context.Context, minerID string, count int) ([][]partition, error) {
+	requests := make([]map[string]interface{}, 0, count)
+	for i := 0; i < count; i++ {
+		requests = append(requests, newRPCRequest(i, "Filecoin.StateMinerPartitions", minerID, i, nil))
+	}
+	partitions := make([][]partition, count)
+	if len(requests) == 0 {
+		return partitions, nil
+	}
+
+	responses, err := c.BatchCallWithRetry(ctx, requests)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get partitions: %w", err)
+	}
+	for _, resp := range responses {
+		id, ok := resp["id"].(float64)
+		if !ok {
+			continue
+		}
+		if resp["error"] != nil {
+			return nil, fmt.Errorf("failed to get partitions of deadline %d: %s", int(id), rpcErrorMessage(resp["error"]))
+		}
+		if err := decodeResult(resp["result"], &partitions[int(id)]); err != nil {
+			return nil, fmt.Errorf("failed to decode partitions of deadline %d: %w", int(id), err)
+		}
+	}
+	return partitions, nil
+}
+
+// postStatus derives the PoSt status of a deadline from its on-chain state. The
+// disputable proof count is the number of proofs accepted the last time the
+// deadline closed, so it tells whether the last window was proven.
+func postStatus(status DeadlineStatus) string {
+	if status.Live == 0 {
+		return PoStStatusIdle
+	}
+	if status.IsOpen {
+		if status.ProvenPartitions >= uint64(len(status.Partitions)) {
+			return PoStStatusSubmitted
+		}
+		return PoStStatusPending
+	}
+	if status.DisputableProofs > 0 {
+		return PoStStatusSubmitted
+	}
+	return PoStStatusMissed
+}
+
+// applyPoStMessages finds the successful SubmitWindowedPoSt messages sent to the
+// miner since fromHeight and records the last PoSt epoch and skipped sectors per deadline
+func (c *Client) applyPoStMessages(ctx context.Context, minerID string, schedule *DeadlineSchedule, fromHeight int64) error {
+	if fromHeight < 0 {
+		fromHeight = 0
+	}
+
+	var cids []map[string]string
+	match := map[string]interface{}{"To": minerID}
+	if err := c.callRPCWithRetry(ctx, "Filecoin.StateListMessages", []interface{}{match, nil, fromHeight}, &cids); err != nil {
+		return fmt.Errorf("failed to list messages: %w", err)
+	}
+	if len(cids) == 0 {
+		return nil
+	}
+
+	requests := make([]map[string]interface{}, 0, len(cids)*2)
+	for i, cid := range cids {
+		requests = append(requests,
+			newRPCRequest(2*i, "Filecoin.ChainGetMessage", cid),
+			newRPCRequest(2*i+1, "Filecoin.StateSearchMsg", nil, cid, -1, true),
+		)
+	}
+	// A large miner receives thousands of messages over a proving period
+	results, err := c.batchResults(ctx, requests)
+	if err != nil {
+		return err
+	}
+
+	type postMessage struct {
+		params *miner.SubmitWindowedPoStParams
+		height int64
+		ok     bool
+	}
+	messages := make([]postMessage, len(cids))
+
+	for id, result := range results {
+		msg := &messages[id/2]
+
+		if id%2 == 0 {
+			var m struct {
+				Method uint64 `json:"Method"`
+				Params []byte `json:"Params"`
+			}
+			if err := decodeResult(result, &m); err != nil || m.Method != uint64(builtin.MethodsMiner.SubmitWindowedPoSt) {
+				continue
+			}
+			var params miner.SubmitWindowedPoStParams
+			if err := params.UnmarshalCBOR(bytes.NewReader(m.Params)); err != nil {
+				continue
+			}
+			msg.params = &params
+			continue
+		}
+
+		var lookup struct {
+			Height  int64 `json:"Height"`
+			Receipt struct {
+				ExitCode int64 `json:"ExitCode"`
+			} `json:"Receipt"`
+		}
+		if err := decodeResult(result, &lookup); err != nil {
+			continue
+		}
+		msg.height = lookup.Height
+		msg.ok = lookup.Receipt.ExitCode == 0
+	}
+
+	// Keep only the latest successful submission per deadline and partition
+	type latest struct {
+		height  int64
+		skipped uint64
+	}
+	byPartition := make(map[[2]uint64]latest)
+	lastPoSt := make(map[uint64]int64)
+	for _, msg := range messages {
+		if msg.params == nil || !msg.ok {
+			continue
+		}
+		dl := msg.params.Deadline
+		if msg.height > lastPoSt[dl] {
+			lastPoSt[dl] = msg.height
+		}
+		for _, p := range msg.params.Partitions {
+			key := [2]uint64{dl, p.Index}
+			if prev, ok := byPartition[key]; ok && prev.height >= msg.height {
+				continue
+			}
+			byPartition[key] = latest{height: msg.height, skipped: countBits(p.Skipped)}
+		}
+	}
+
+	for i := range schedule.Deadlines {
+		status := &schedule.Deadlines[i]
+		status.LastPoStEpoch = lastPoSt[status.Index]
+		status.Skipped = 0
+		for j := range status.Partitions {
+			ps := &status.Partitions[j]
+			ps.Skipped = byPartition[[2]uint64{status.Index, ps.Index}].skipped
+			status.Skipped += ps.Skipped
+		}
+		if status.PoStStatus == PoStStatusMissed && status.LastPoStEpoch > 0 {
+			status.PoStStatus = PoStStatusSubmitted
+		}
+	}
+
+	return nil
+}
+
+// countBits returns the number of set bits in a bitfield, treating invalid bitfields as empty
+func countBits(bf bitfield.BitField) uint64 {
+	n, err := bf.Count()
+	if err != nil {
+		return 0
+	}
+	return n
+}
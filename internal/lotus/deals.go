@@ -0,0 +1,337 @@
+package lotus
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/THCloudAI/thctl/internal/units"
+)
+
+// dealBatchSize limits the number of deals fetched in a single batch request
+const dealBatchSize = 200
+
+// Deal status values, from the market deal state and the current epoch
+const (
+	DealStatusActive   = "active"
+	DealStatusExpiring = "expiring"
+	DealStatusPending  = "pending"
+	DealStatusSlashed  = "slashed"
+	DealStatusExpired  = "expired"
+	DealStatusNotFound = "not found"
+)
+
+// marketDeal is the result of StateMarketStorageDeal and an entry of StateMarketDeals
+type marketDeal struct {
+	Proposal struct {
+		PieceCID             map[string]string `json:"PieceCID"`
+		PieceSize            uint64            `json:"PieceSize"`
+		VerifiedDeal         bool              `json:"VerifiedDeal"`
+		Client               string            `json:"Client"`
+		Provider             string            `json:"Provider"`
+		StartEpoch           int64             `json:"StartEpoch"`
+		EndEpoch             int64             `json:"EndEpoch"`
+		StoragePricePerEpoch string            `json:"StoragePricePerEpoch"`
+	} `json:"Proposal"`
+	State struct {
+		SectorStartEpoch int64 `json:"SectorStartEpoch"`
+		SlashEpoch       int64 `json:"SlashEpoch"`
+	} `json:"State"`
+}
+
+// storageFee returns the total price of the deal over its duration in attoFIL
+func (d *marketDeal) storageFee() *big.Int {
+	fee := parseBigInt(d.Proposal.StoragePricePerEpoch)
+	return fee.Mul(fee, big.NewInt(d.Proposal.EndEpoch-d.Proposal.StartEpoch))
+}
+
+// status returns the status of the deal at epoch; deals ending before expiringBefore
+// are reported as expiring
+func (d *marketDeal) status(epoch, expiringBefore int64) string {
+	switch {
+	case d.State.SlashEpoch >= 0:
+		return DealStatusSlashed
+	case d.Proposal.EndEpoch <= epoch:
+		return DealStatusExpired
+	case d.State.SectorStartEpoch < 0:
+		return DealStatusPending
+	case d.Proposal.EndEpoch < expiringBefore:
+		return DealStatusExpiring
+	}
+	return DealStatusActive
+}
+
+// sectorOnChainInfo is the result of StateSectorGetInfo
+type sectorOnChainInfo struct {
+	sectorOnChain
+	SealedCID             map[string]string `json:"SealedCID"`
+	DealIDs               []uint64          `json:"DealIDs"`
+	DealWeight            string            `json:"DealWeight"`
+	ExpectedDayReward     string            `json:"ExpectedDayReward"`
+	ExpectedStoragePledge string            `json:"ExpectedStoragePledge"`
+}
+
+// GetSectorInfo retrieves information about a specific sector, with its deals resolved
+// through the market actor
+func (c *Client) GetSectorInfo(ctx context.Context, minerID string, sectorNumber uint64) (*SectorInfo, error) {
+	var sector *sectorOnChainInfo
+	err := c.callRPCWithRetry(ctx, "Filecoin.StateSectorGetInfo", []interface{}{minerID, sectorNumber, nil}, &sector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sector info: %w", err)
+	}
+	if sector == nil {
+		return nil, fmt.Errorf("sector %d of %s not found", sectorNumber, minerID)
+	}
+	network, err := c.Network(ctx)
+	if err != nil {
+		return nil, err
+	}
+	head, err := c.GetChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &SectorInfo{
+		SectorNumber:          sector.SectorNumber,
+		SealedCID:             sector.SealedCID["/"],
+		Deals:                 make([]SectorDeal, 0, len(sector.DealIDs)),
+		CreationTime:          network.EpochToTimestamp(sector.Activation),
+		ExpirationTime:        network.EpochToTimestamp(sector.Expiration),
+		DealWeight:            sector.DealWeight,
+		VerifiedWeight:        sector.VerifiedDealWeight,
+		InitialPledge:         sector.InitialPledge,
+		ExpectedDayReward:     sector.ExpectedDayReward,
+		ExpectedStoragePledge: sector.ExpectedStoragePledge,
+	}
+
+	deals, err := c.getMarketDeals(ctx, sector.DealIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range sector.DealIDs {
+		deal, ok := deals[id]
+		if !ok {
+			// Deals are removed from the market once they expire or are slashed
+			info.Deals = append(info.Deals, SectorDeal{DealID: id, PieceStatus: DealStatusNotFound})
+			continue
+		}
+		info.Deals = append(info.Deals, SectorDeal{
+			DealID:      id,
+			PieceCID:    deal.Proposal.PieceCID["/"],
+			PieceSize:   deal.Proposal.PieceSize,
+			Client:      deal.Proposal.Client,
+			StartEpoch:  uint64(deal.Proposal.StartEpoch),
+			EndEpoch:    uint64(deal.Proposal.EndEpoch),
+			StorageFee:  deal.storageFee().String(),
+			PieceStatus: deal.status(int64(head.Height), 0),
+		})
+	}
+	return info, nil
+}
+
+// GetMinerDeals lists the market deals of a miner, with the verified and unverified bytes
+// of its active deals and totals and remaining DataCap per client. Deals are found through
+// the deal IDs of the miner's sectors. Sectors activated with ProveCommitSectors3 don't list
+// their deals, so scanMarket reads every deal of the market actor instead, which is slow on
+// mainnet. Deals ending within expiringEpochs are reported as expiring.
+func (c *Client) GetMinerDeals(ctx context.Context, minerID string, expiringEpochs int64, scanMarket bool) (*MinerDeals, error) {
+	head, err := c.GetChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	network, err := c.Network(ctx)
+	if err != nil {
+		return nil, err
+	}
+	epoch := int64(head.Height)
+
+	result := &MinerDeals{
+		MinerID:        minerID,
+		Height:         epoch,
+		ExpiringBefore: epoch + expiringEpochs,
+		MarketScanned:  scanMarket,
+		Deals:          make([]MarketDeal, 0),
+		Clients:        make([]ClientDeals, 0),
+	}
+
+	deals := make(map[uint64]*marketDeal)
+	sectorOf := make(map[uint64]uint64)
+	if scanMarket {
+		// Deal proposals name their provider by ID
+		providerID, err := c.resolveMinerID(ctx, minerID)
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]*marketDeal
+		if err := c.callRPCWithRetry(ctx, "Filecoin.StateMarketDeals", []interface{}{nil}, &all); err != nil {
+			return nil, fmt.Errorf("failed to get market deals: %w", err)
+		}
+		for key, deal := range all {
+			id, err := strconv.ParseUint(key, 10, 64)
+			if err != nil || !sameAddress(deal.Proposal.Provider, providerID) {
+				continue
+			}
+			deals[id] = deal
+		}
+	} else {
+		var sectors []sectorOnChainInfo
+		if err := c.callRPCWithRetry(ctx, "Filecoin.StateMinerSectors", []interface{}{minerID, nil, nil}, &sectors); err != nil {
+			return nil, fmt.Errorf("failed to list sectors: %w", err)
+		}
+		ids := make([]uint64, 0)
+		for _, sector := range sectors {
+			for _, id := range sector.DealIDs {
+				sectorOf[id] = sector.SectorNumber
+				ids = append(ids, id)
+			}
+		}
+		if deals, err = c.getMarketDeals(ctx, ids); err != nil {
+			return nil, err
+		}
+	}
+
+	clients := make(map[string]*clientTotals)
+	verified, unverified := new(big.Int), new(big.Int)
+	for id, deal := range deals {
+		d := MarketDeal{
+			DealID:       id,
+			SectorNumber: sectorOf[id],
+			PieceCID:     deal.Proposal.PieceCID["/"],
+			PieceSize:    units.NewBytes(new(big.Int).SetUint64(deal.Proposal.PieceSize)),
+			Client:       deal.Proposal.Client,
+			Verified:     deal.Proposal.VerifiedDeal,
+			StartEpoch:   deal.Proposal.StartEpoch,
+			EndEpoch:     deal.Proposal.EndEpoch,
+			EndTimestamp: network.EpochToTimestamp(deal.Proposal.EndEpoch),
+			StorageFee:   units.NewFIL(deal.storageFee()),
+			Status:       deal.status(epoch, result.ExpiringBefore),
+		}
+		result.Deals = append(result.Deals, d)
+
+		switch d.Status {
+		case DealStatusPending:
+			result.PendingDeals++
+			continue
+		case DealStatusExpiring:
+			result.ExpiringDeals++
+		case DealStatusActive:
+		default:
+			continue
+		}
+		result.ActiveDeals++
+
+		totals, ok := clients[d.Client]
+		if !ok {
+			totals = &clientTotals{bytes: new(big.Int), verified: new(big.Int), fees: new(big.Int)}
+			clients[d.Client] = totals
+		}
+		size := new(big.Int).SetUint64(deal.Proposal.PieceSize)
+		totals.deals++
+		totals.bytes.Add(totals.bytes, size)
+		totals.fees.Add(totals.fees, d.StorageFee.Atto())
+		if d.Verified {
+			totals.verified.Add(totals.verified, size)
+			verified.Add(verified, size)
+		} else {
+			unverified.Add(unverified, size)
+		}
+	}
+	sort.Slice(result.Deals, func(i, j int) bool { return result.Deals[i].DealID < result.Deals[j].DealID })
+	result.VerifiedBytes = units.NewBytes(verified)
+	result.UnverifiedBytes = units.NewBytes(unverified)
+
+	dataCaps, err := c.getDataCaps(ctx, clients)
+	if err != nil {
+		return nil, err
+	}
+	for client, totals := range clients {
+		result.Clients = append(result.Clients, ClientDeals{
+			Client:        client,
+			Deals:         totals.deals,
+			Bytes:         units.NewBytes(totals.bytes),
+			VerifiedBytes: units.NewBytes(totals.verified),
+			StorageFees:   units.NewFIL(totals.fees),
+			DataCap:       dataCaps[client],
+		})
+	}
+	sort.Slice(result.Clients, func(i, j int) bool {
+		return result.Clients[i].Bytes.Int().Cmp(result.Clients[j].Bytes.Int()) > 0
+	})
+	return result, nil
+}
+
+// clientTotals accumulates the active deals of a client
+type clientTotals struct {
+	deals                 int
+	bytes, verified, fees *big.Int
+}
+
+// getMarketDeals looks up deals with StateMarketStorageDeal in batches. Deals the market
+// no longer holds are left out of the result.
+func (c *Client) getMarketDeals(ctx context.Context, ids []uint64) (map[uint64]*marketDeal, error) {
+	deals := make(map[uint64]*marketDeal, len(ids))
+	for start := 0; start < len(ids); start += dealBatchSize {
+		end := start + dealBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		requests := make([]map[string]interface{}, 0, end-start)
+		for i, id := range ids[start:end] {
+			requests = append(requests, newRPCRequest(i, "Filecoin.StateMarketStorageDeal", id, nil))
+		}
+		responses, err := c.BatchCallWithRetry(ctx, requests)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get market deals: %w", err)
+		}
+
+		for _, resp := range responses {
+			id, ok := resp["id"].(float64)
+			if !ok || resp["error"] != nil || resp["result"] == nil {
+				continue
+			}
+			var deal marketDeal
+			if err := decodeResult(resp["result"], &deal); err != nil {
+				continue
+			}
+			deals[ids[start+int(id)]] = &deal
+		}
+	}
+	return deals, nil
+}
+
+// getDataCaps looks up the remaining DataCap of each client with StateVerifiedClientStatus.
+// Clients that are not verified clients are left out of the result.
+func (c *Client) getDataCaps(ctx context.Context, clients map[string]*clientTotals) (map[string]*units.Bytes, error) {
+	dataCaps := make(map[string]*units.Bytes, len(clients))
+	if len(clients) == 0 {
+		return dataCaps, nil
+	}
+
+	names := make([]string, 0, len(clients))
+	requests := make([]map[string]interface{}, 0, len(clients))
+	for client := range clients {
+		requests = append(requests, newRPCRequest(len(names), "Filecoin.StateVerifiedClientStatus", client, nil))
+		names = append(names, client)
+	}
+	responses, err := c.BatchCallWithRetry(ctx, requests)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client DataCap: %w", err)
+	}
+
+	for _, resp := range responses {
+		id, ok := resp["id"].(float64)
+		if !ok || resp["error"] != nil {
+			continue
+		}
+		var allowance *string
+		if err := decodeResult(resp["result"], &allowance); err != nil || allowance == nil {
+			continue
+		}
+		dataCap := units.BytesFromString(*allowance)
+		dataCaps[names[int(id)]] = &dataCap
+	}
+	return dataCaps, nil
+}
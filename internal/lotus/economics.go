@@ -0,0 +1,367 @@
+package lotus
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	stbig "github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/go-state-types/builtin/v15/miner"
+	"github.com/filecoin-project/go-state-types/builtin/v15/util/smoothing"
+	"github.com/THCloudAI/thctl/internal/units"
+)
+
+// Sector sizes of the proofs accepted for new sectors
+const (
+	SectorSize32GiB uint64 = 32 << 30
+	SectorSize64GiB uint64 = 64 << 30
+)
+
+// tebibyte is the amount of power rewards are quoted for
+const tebibyte = 1 << 40
+
+// EconomicsOptions controls the sector terms the economics are computed for and the what-if scenario
+type EconomicsOptions struct {
+	// DurationDays is the commitment of new sectors, which sets their quality adjusted power
+	DurationDays int64
+	// GasTipsets is the number of recent tipsets scanned for the gas used by sector onboarding
+	GasTipsets int
+	// WhatIf computes the pledge and rewards of adding capacity when set
+	WhatIf *WhatIfOptions
+}
+
+// WhatIfOptions describes capacity to be added to a miner
+type WhatIfOptions struct {
+	// Capacity is the raw capacity to add in bytes
+	Capacity *big.Int
+	// Verified fills the sectors with verified deals, for ten times the quality adjusted power
+	Verified bool
+	// SectorSize is the size of the sectors the capacity is sealed in
+	SectorSize uint64
+}
+
+// rewardState is the subset of the reward actor state used for pledge and reward estimates
+type rewardState struct {
+	ThisEpochReward         stbig.Int                `json:"ThisEpochReward"`
+	ThisEpochRewardSmoothed smoothing.FilterEstimate `json:"ThisEpochRewardSmoothed"`
+	ThisEpochBaselinePower  stbig.Int                `json:"ThisEpochBaselinePower"`
+}
+
+// powerState is the subset of the power actor state used for pledge and reward estimates
+type powerState struct {
+	TotalRawBytePower        stbig.Int                `json:"TotalRawBytePower"`
+	TotalQualityAdjPower     stbig.Int                `json:"TotalQualityAdjPower"`
+	ThisEpochQAPowerSmoothed smoothing.FilterEstimate `json:"ThisEpochQAPowerSmoothed"`
+	RampStartEpoch           int64                    `json:"RampStartEpoch"`
+	RampDurationEpochs       uint64                   `json:"RampDurationEpochs"`
+}
+
+// circulatingSupply is the subset of StateVMCirculatingSupplyInternal used for pledge estimates
+type circulatingSupply struct {
+	FilCirculating stbig.Int `json:"FilCirculating"`
+}
+
+// economicsInputs holds the network state the pledge and reward estimates are computed from
+type economicsInputs struct {
+	epoch  int64
+	reward rewardState
+	power  powerState
+	supply stbig.Int
+}
+
+// initialPledge returns the initial pledge of qaPower, computed the way the miner actor does
+func (in *economicsInputs) initialPledge(qaPower abi.StoragePower) *big.Int {
+	return miner.InitialPledgeForPower(qaPower, in.reward.ThisEpochBaselinePower, in.reward.ThisEpochRewardSmoothed,
+		in.power.ThisEpochQAPowerSmoothed, in.supply, in.epoch-in.power.RampStartEpoch, in.power.RampDurationEpochs).Int
+}
+
+// expectedReward returns the block rewards expected for qaPower over epochs
+func (in *economicsInputs) expectedReward(qaPower abi.StoragePower, epochs int64) *big.Int {
+	return miner.ExpectedRewardForPower(in.reward.ThisEpochRewardSmoothed, in.power.ThisEpochQAPowerSmoothed,
+		qaPower, abi.ChainEpoch(epochs)).Int
+}
+
+// sectorQAPower returns the quality adjusted power of a new sector committed for duration epochs
+func sectorQAPower(sectorSize uint64, duration int64, verified bool) abi.StoragePower {
+	weight := stbig.Zero()
+	if verified {
+		weight = stbig.Mul(stbig.NewIntUnsigned(sectorSize), stbig.NewInt(duration))
+	}
+	return miner.QAPowerForWeight(abi.SectorSize(sectorSize), abi.ChainEpoch(duration), weight)
+}
+
+// GetNetworkEconomics estimates the initial pledge and expected rewards of new sectors and
+// the gas of onboarding them. Pledge and rewards use the smoothed estimates of the reward
+// and power actors, the way the miner actor computes pledge at pre-commit.
+func (c *Client) GetNetworkEconomics(ctx context.Context, opts EconomicsOptions) (*NetworkEconomics, error) {
+	network, err := c.Network(ctx)
+	if err != nil {
+		return nil, err
+	}
+	head, err := c.GetChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	in := economicsInputs{epoch: int64(head.Height)}
+	var supply circulatingSupply
+	requests := []map[string]interface{}{
+		newRPCRequest(0, "Filecoin.StateReadState", "f02", head.Cids),
+		newRPCRequest(1, "Filecoin.StateReadState", "f04", head.Cids),
+		newRPCRequest(2, "Filecoin.StateVMCirculatingSupplyInternal", head.Cids),
+	}
+	responses, err := c.BatchCallWithRetry(ctx, requests)
+	if err != nil {
+		return nil, err
+	}
+	for _, resp := range responses {
+		id, ok := resp["id"].(float64)
+		if !ok {
+			continue
+		}
+		if resp["error"] != nil {
+			return nil, fmt.Errorf("failed to read network state: %s", rpcErrorMessage(resp["error"]))
+		}
+		var target interface{} = &supply
+		switch int(id) {
+		case 0:
+			target = &struct {
+				State *rewardState `json:"State"`
+			}{&in.reward}
+		case 1:
+			target = &struct {
+				State *powerState `json:"State"`
+			}{&in.power}
+		}
+		if err := decodeResult(resp["result"], target); err != nil {
+			return nil, fmt.Errorf("failed to decode network state: %w", err)
+		}
+	}
+	in.supply = supply.FilCirculating
+
+	duration := opts.DurationDays * network.EpochsPerDay()
+	if duration < int64(miner.MinSectorExpiration) || duration > int64(miner.MaxSectorExpirationExtension) {
+		return nil, fmt.Errorf("sector duration must be between %d and %d days", int64(miner.MinSectorExpiration)/network.EpochsPerDay(),
+			int64(miner.MaxSectorExpirationExtension)/network.EpochsPerDay())
+	}
+	result := &NetworkEconomics{
+		Height:                 in.epoch,
+		NetworkVersion:         network.Version,
+		BaseFee:                units.FILFromAtto(head.baseFee()),
+		CirculatingSupply:      units.NewFIL(in.supply.Int),
+		NetworkRawBytePower:    units.NewBytes(in.power.TotalRawBytePower.Int),
+		NetworkQualityAdjPower: units.NewBytes(in.power.TotalQualityAdjPower.Int),
+		BaselinePower:          units.NewBytes(in.reward.ThisEpochBaselinePower.Int),
+		RewardPerEpoch:         units.NewFIL(in.reward.ThisEpochReward.Int),
+		RewardPerTiBPerDay:     units.NewFIL(in.expectedReward(stbig.NewInt(tebibyte), network.EpochsPerDay())),
+		DurationDays:           opts.DurationDays,
+	}
+
+	for _, size := range []uint64{SectorSize32GiB, SectorSize64GiB} {
+		for _, verified := range []bool{false, true} {
+			qaPower := sectorQAPower(size, duration, verified)
+			pledge := in.initialPledge(qaPower)
+			perTiB := new(big.Int).Mul(pledge, big.NewInt(tebibyte))
+			result.Sectors = append(result.Sectors, SectorEconomics{
+				SectorSize:      units.NewBytes(new(big.Int).SetUint64(size)),
+				Verified:        verified,
+				QualityAdjPower: units.NewBytes(qaPower.Int),
+				InitialPledge:   units.NewFIL(pledge),
+				PledgePerTiB:    units.NewFIL(perTiB.Div(perTiB, new(big.Int).SetUint64(size))),
+				RewardPerDay:    units.NewFIL(in.expectedReward(qaPower, network.EpochsPerDay())),
+			})
+		}
+	}
+
+	if result.Gas, err = c.sectorGas(ctx, head, opts.GasTipsets); err != nil {
+		return nil, err
+	}
+	result.Gas.CostPerSector = units.NewFIL(new(big.Int).Mul(
+		big.NewInt(result.Gas.PreCommitGasPerSector+result.Gas.ProveCommitGasPerSector), result.BaseFee.Atto()))
+
+	if opts.WhatIf != nil {
+		result.WhatIf = whatIf(&in, result, *opts.WhatIf, duration, network.EpochsPerDay())
+	}
+	return result, nil
+}
+
+// whatIf computes the pledge, gas and rewards of sealing the capacity of opts in new sectors.
+// Rewards over the commitment assume the current reward rate and network power hold.
+func whatIf(in *economicsInputs, result *NetworkEconomics, opts WhatIfOptions, duration, epochsPerDay int64) *WhatIf {
+	sectorSize := new(big.Int).SetUint64(opts.SectorSize)
+	sectors := new(big.Int).Add(opts.Capacity, sectorSize)
+	sectors.Sub(sectors, big.NewInt(1)).Div(sectors, sectorSize)
+
+	sectorPower := sectorQAPower(opts.SectorSize, duration, opts.Verified)
+	qaPower := new(big.Int).Mul(sectorPower.Int, sectors)
+	pledge := new(big.Int).Mul(in.initialPledge(sectorPower), sectors)
+	gas := new(big.Int).Mul(result.Gas.CostPerSector.Atto(), sectors)
+	perDay := in.expectedReward(stbig.NewFromGo(qaPower), epochsPerDay)
+	total := new(big.Int).Mul(perDay, big.NewInt(duration/epochsPerDay))
+
+	w := &WhatIf{
+		Capacity:           units.NewBytes(opts.Capacity),
+		Verified:           opts.Verified,
+		SectorSize:         units.NewBytes(sectorSize),
+		Sectors:            sectors.Uint64(),
+		QualityAdjPower:    units.NewBytes(qaPower),
+		InitialPledge:      units.NewFIL(pledge),
+		GasCost:            units.NewFIL(gas),
+		RewardPerDay:       units.NewFIL(perDay),
+		RewardOverDuration: units.NewFIL(total),
+		DurationDays:       result.DurationDays,
+	}
+	if networkQA := in.power.TotalQualityAdjPower.Int; networkQA.Sign() > 0 {
+		w.NetworkShare, _ = new(big.Rat).SetFrac(qaPower, networkQA).Float64()
+	}
+	if pledge.Sign() > 0 {
+		w.PledgeReturn, _ = new(big.Rat).SetFrac(new(big.Int).Sub(total, gas), pledge).Float64()
+	}
+	return w
+}
+
+// parentMessage is a single entry of ChainGetParentMessages
+type parentMessage struct {
+	Message UnsignedMessage `json:"Message"`
+}
+
+// messageReceipt is a single entry of ChainGetParentReceipts
+type messageReceipt struct {
+	ExitCode int64  `json:"ExitCode"`
+	Return   []byte `json:"Return"`
+	GasUsed  int64  `json:"GasUsed"`
+}
+
+// sectorGas averages the gas used per sector by the successful pre-commit and prove-commit
+// messages of the last tipsets. The actor's own estimates are used for a step that does not
+// appear in them.
+func (c *Client) sectorGas(ctx context.Context, head *TipSet, tipsets int) (SectorGas, error) {
+	gas := SectorGas{Tipsets: tipsets}
+
+	var preGas, preSectors, proveGas, proveSectors int64
+	if tipsets > 0 {
+		// Messages executed in a tipset are those of its parent, so the parent messages
+		// and receipts of each tipset line up by index
+		requests := make([]map[string]interface{}, 0, 2*tipsets)
+		for i := 0; i < tipsets && int64(head.Height)-int64(i) > 0; i++ {
+			requests = append(requests, newRPCRequest(2*i, "Filecoin.ChainGetTipSetByHeight", int64(head.Height)-int64(i), head.Cids))
+		}
+		responses, err := c.BatchCallWithRetry(ctx, requests)
+		if err != nil {
+			return gas, fmt.Errorf("failed to get recent tipsets: %w", err)
+		}
+
+		seen := make(map[string]bool)
+		requests = requests[:0]
+		for _, resp := range responses {
+			var ts TipSet
+			if resp["error"] != nil || decodeResult(resp["result"], &ts) != nil || len(ts.Cids) == 0 {
+				continue
+			}
+			// A null round returns the tipset before it, which is counted once
+			block := ts.Cids[0]
+			if seen[block["/"]] {
+				continue
+			}
+			seen[block["/"]] = true
+			id := 2 * len(seen)
+			requests = append(requests,
+				newRPCRequest(id, "Filecoin.ChainGetParentMessages", block),
+				newRPCRequest(id+1, "Filecoin.ChainGetParentReceipts", block),
+			)
+		}
+		if len(requests) > 0 {
+			if responses, err = c.BatchCallWithRetry(ctx, requests); err != nil {
+				return gas, fmt.Errorf("failed to get recent messages: %w", err)
+			}
+		}
+
+		messages := make(map[int][]parentMessage)
+		receipts := make(map[int][]messageReceipt)
+		for _, resp := range responses {
+			id, ok := resp["id"].(float64)
+			if !ok || resp["error"] != nil {
+				continue
+			}
+			if int(id)%2 == 0 {
+				var msgs []parentMessage
+				if decodeResult(resp["result"], &msgs) == nil {
+					messages[int(id)] = msgs
+				}
+			} else {
+				var rcpts []messageReceipt
+				if decodeResult(resp["result"], &rcpts) == nil {
+					receipts[int(id)-1] = rcpts
+				}
+			}
+		}
+
+		for id, msgs := range messages {
+			rcpts := receipts[id]
+			if len(rcpts) != len(msgs) {
+				continue
+			}
+			for i, msg := range msgs {
+				if rcpts[i].ExitCode != 0 || !isIDAddress(msg.Message.To) {
+					continue
+				}
+				precommit, count := onboardedSectors(msg.Message)
+				if count == 0 {
+					continue
+				}
+				gas.Messages++
+				if precommit {
+					preGas += rcpts[i].GasUsed
+					preSectors += count
+				} else {
+					proveGas += rcpts[i].GasUsed
+					proveSectors += count
+				}
+			}
+		}
+	}
+
+	gas.PreCommitSectors, gas.ProveCommitSectors = preSectors, proveSectors
+	if preSectors > 0 {
+		gas.PreCommitGasPerSector = preGas / preSectors
+	} else {
+		gas.PreCommitGasPerSector = miner.EstimatedSinglePreCommitGasUsage.Int64()
+		gas.Estimated = true
+	}
+	if proveSectors > 0 {
+		gas.ProveCommitGasPerSector = proveGas / proveSectors
+	} else {
+		gas.ProveCommitGasPerSector = miner.EstimatedSingleProveCommitGasUsage.Int64()
+		gas.Estimated = true
+	}
+	return gas, nil
+}
+
+// onboardedSectors decodes a batched pre-commit or prove-commit message and returns whether
+// it is a pre-commit and the number of sectors it covers, 0 for any other message
+func onboardedSectors(msg UnsignedMessage) (bool, int64) {
+	r := bytes.NewReader(msg.Params)
+	switch abi.MethodNum(msg.Method) {
+	case builtin.MethodsMiner.PreCommitSectorBatch2:
+		var p miner.PreCommitSectorBatchParams2
+		if p.UnmarshalCBOR(r) == nil {
+			return true, int64(len(p.Sectors))
+		}
+	case builtin.MethodsMiner.ProveCommitSectors3:
+		var p miner.ProveCommitSectors3Params
+		if p.UnmarshalCBOR(r) == nil {
+			return false, int64(len(p.SectorActivations))
+		}
+	case builtin.MethodsMiner.ProveCommitAggregate:
+		var p miner.ProveCommitAggregateParams
+		if p.UnmarshalCBOR(r) == nil {
+			if count, err := p.SectorNumbers.Count(); err == nil {
+				return false, int64(count)
+			}
+		}
+	}
+	return false, 0
+}
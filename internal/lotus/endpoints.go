@@ -0,0 +1,399 @@
+package lotus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Endpoint is a Lotus API endpoint with the token used to call it
+type Endpoint struct {
+	URL   string `mapstructure:"url"`
+	Token string `mapstructure:"token"`
+}
+
+// endpointCheckTimeout bounds the health check of each endpoint
+const endpointCheckTimeout = 5 * time.Second
+
+// EndpointStatus is the health of an endpoint as seen by the client
+type EndpointStatus struct {
+	URL        string `json:"url"`
+	Healthy    bool   `json:"healthy"`
+	HeadHeight int64  `json:"head_height,omitempty"`
+	// LagEpochs is the number of epochs behind the best endpoint
+	LagEpochs int64 `json:"lag_epochs"`
+	// LagSeconds is the age of the head tipset of the endpoint
+	LagSeconds int64  `json:"lag_seconds,omitempty"`
+	Error      string `json:"error,omitempty"`
+	// Served is the number of requests the endpoint answered
+	Served int `json:"served"`
+}
+
+// ServedEndpoint is an endpoint that answered calls of the client, with the number of
+// calls of each method it answered
+type ServedEndpoint struct {
+	URL   string         `json:"url"`
+	Calls map[string]int `json:"calls"`
+}
+
+// endpoint is a configured endpoint and its health
+type endpoint struct {
+	url   string
+	token string
+
+	mu     sync.Mutex
+	status EndpointStatus
+	calls  map[string]int
+}
+
+func newEndpoint(e Endpoint) *endpoint {
+	apiURL := resolveAPIURL(e.URL)
+	return &endpoint{
+		url:    apiURL,
+		token:  e.Token,
+		status: EndpointStatus{URL: apiURL, Healthy: true},
+	}
+}
+
+func (e *endpoint) healthy() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.status.Healthy
+}
+
+// served records a request the endpoint answered and the methods it called
+func (e *endpoint) served(methods ...string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.status.Served++
+	if e.calls == nil {
+		e.calls = make(map[string]int)
+	}
+	for _, method := range methods {
+		e.calls[method]++
+	}
+}
+
+// markDown takes the endpoint out of rotation and reports whether it was healthy until now
+func (e *endpoint) markDown(err error) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	wasHealthy := e.status.Healthy
+	e.status.Healthy = false
+	e.status.Error = err.Error()
+	return wasHealthy
+}
+
+// send posts a JSON-RPC request body to the endpoint and returns the response body
+func (c *Client) send(ctx context.Context, e *endpoint, body []byte) ([]byte, error) {
+	return postRPC(ctx, c.httpClient, e.url, e.token, body)
+}
+
+// shouldFailover reports whether a request that failed with err should be tried on
+// another endpoint: the endpoint could not be reached or answered with a 5xx status
+func shouldFailover(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) || strings.Contains(err.Error(), "unexpected status code: 5")
+}
+
+// do sends a request body to the endpoints in order, starting with first when it is
+// not nil, and fails over to the next one on connection and server errors. It returns
+// the response body and the endpoint that served it, on which the caller records the
+// methods it called.
+func (c *Client) do(ctx context.Context, body []byte, first *endpoint) ([]byte, *endpoint, error) {
+	endpoints := c.orderedEndpoints(ctx, first)
+	if len(endpoints) == 1 {
+		data, err := c.send(ctx, endpoints[0], body)
+		if err != nil {
+			return nil, nil, err
+		}
+		return data, endpoints[0], nil
+	}
+
+	var lastErr error
+	for i, e := range endpoints {
+		data, err := c.send(ctx, e, body)
+		if err == nil {
+			return data, e, nil
+		}
+		if ctx.Err() != nil || !shouldFailover(err) {
+			return nil, nil, fmt.Errorf("%s: %w", e.url, err)
+		}
+		if e.markDown(err) && i < len(endpoints)-1 {
+			fmt.Fprintf(os.Stderr, "⚠️  Lotus endpoint %s failed (%v), failing over to %s\n", e.url, err, endpoints[i+1].url)
+		}
+		lastErr = err
+	}
+	return nil, nil, fmt.Errorf("all %d Lotus endpoints failed, last error: %w", len(endpoints), lastErr)
+}
+
+// orderedEndpoints returns the endpoints to try, healthy ones first in the configured
+// order, starting with first when it is healthy. Endpoints that are down or lagging
+// are kept last so they are still tried when every other endpoint fails.
+func (c *Client) orderedEndpoints(ctx context.Context, first *endpoint) []*endpoint {
+	if len(c.endpoints) == 1 {
+		return c.endpoints
+	}
+	c.checkEndpoints(ctx)
+	c.checkHeadAges()
+
+	ordered := make([]*endpoint, 0, len(c.endpoints))
+	if first != nil && first.healthy() {
+		ordered = append(ordered, first)
+	}
+	var down []*endpoint
+	for _, e := range c.endpoints {
+		switch {
+		case e == first && e.healthy():
+		case e.healthy():
+			ordered = append(ordered, e)
+		default:
+			down = append(down, e)
+		}
+	}
+	return append(ordered, down...)
+}
+
+// healthyEndpoints returns the endpoints in rotation in the configured order
+func (c *Client) healthyEndpoints(ctx context.Context) []*endpoint {
+	c.checkEndpoints(ctx)
+	c.checkHeadAges()
+	var healthy []*endpoint
+	for _, e := range c.endpoints {
+		if e.healthy() {
+			healthy = append(healthy, e)
+		}
+	}
+	return healthy
+}
+
+// checkEndpoints reads the head of every endpoint once, concurrently, and takes out
+// of rotation the endpoints that are unreachable or more than maxLag epochs behind the
+// best head
+func (c *Client) checkEndpoints(ctx context.Context) {
+	c.checkOnce.Do(func() {
+		var wg sync.WaitGroup
+		for _, e := range c.endpoints {
+			wg.Add(1)
+			go func(e *endpoint) {
+				defer wg.Done()
+				checkCtx, cancel := context.WithTimeout(ctx, endpointCheckTimeout)
+				defer cancel()
+				e.check(checkCtx, c)
+			}(e)
+		}
+		wg.Wait()
+
+		var best int64
+		for _, e := range c.endpoints {
+			if e.status.HeadHeight > best {
+				best = e.status.HeadHeight
+			}
+		}
+		for _, e := range c.endpoints {
+			if e.status.Error != "" {
+				continue
+			}
+			e.status.LagEpochs = best - e.status.HeadHeight
+			if e.status.LagEpochs > c.maxLag {
+				e.status.Healthy = false
+				e.status.Error = fmt.Sprintf("%d epochs behind the best endpoint", e.status.LagEpochs)
+			}
+		}
+	})
+}
+
+// checkHeadAges takes out of rotation the checked endpoints whose head is more than
+// maxLag epochs older than the one epoch a current head may be. The epoch duration is
+// that of the detected network, so the calls detecting it may still be served by such
+// an endpoint.
+func (c *Client) checkHeadAges() {
+	blockDelay := c.blockDelay.Load()
+	if blockDelay == 0 {
+		return
+	}
+	maxAge := (c.maxLag + 1) * blockDelay
+	for _, e := range c.endpoints {
+		e.mu.Lock()
+		if e.status.Error == "" && e.status.LagSeconds > maxAge {
+			e.status.Healthy = false
+			e.status.Error = fmt.Sprintf("head is %s old", time.Duration(e.status.LagSeconds)*time.Second)
+		}
+		e.mu.Unlock()
+	}
+}
+
+// check reads the head of the endpoint
+func (e *endpoint) check(ctx context.Context, c *Client) {
+	var head TipSet
+	if err := callRPC(ctx, c.httpClient, e.url, e.token, "Filecoin.ChainHead", []interface{}{}, &head); err != nil {
+		e.markDown(err)
+		return
+	}
+	e.status.HeadHeight = int64(head.Height)
+	if len(head.Blocks) > 0 {
+		e.status.LagSeconds = time.Now().Unix() - head.Blocks[0].Timestamp
+	}
+}
+
+// EndpointStatuses returns the health of the configured endpoints, checking them and
+// detecting the network first
+func (c *Client) EndpointStatuses(ctx context.Context) []EndpointStatus {
+	c.checkEndpoints(ctx)
+	c.Network(ctx)
+	c.checkHeadAges()
+	statuses := make([]EndpointStatus, len(c.endpoints))
+	for i, e := range c.endpoints {
+		e.mu.Lock()
+		statuses[i] = e.status
+		e.mu.Unlock()
+	}
+	return statuses
+}
+
+// ServedBy returns the endpoints that answered calls of the client and the methods
+// each one answered
+func (c *Client) ServedBy() []ServedEndpoint {
+	var served []ServedEndpoint
+	for _, e := range c.endpoints {
+		e.mu.Lock()
+		if len(e.calls) > 0 {
+			calls := make(map[string]int, len(e.calls))
+			for method, n := range e.calls {
+				calls[method] = n
+			}
+			served = append(served, ServedEndpoint{URL: e.url, Calls: calls})
+		}
+		e.mu.Unlock()
+	}
+	return served
+}
+
+// envClients are the clients created by NewFromEnv, which back the fil commands
+var (
+	envClientsMu sync.Mutex
+	envClients   []*Client
+)
+
+// EnvServedBy returns the endpoints that answered calls of the clients created by
+// NewFromEnv that have several endpoints, so a command can tell which nodes served it
+func EnvServedBy() []ServedEndpoint {
+	envClientsMu.Lock()
+	defer envClientsMu.Unlock()
+
+	var served []ServedEndpoint
+	byURL := make(map[string]int)
+	for _, c := range envClients {
+		if len(c.endpoints) < 2 {
+			continue
+		}
+		for _, s := range c.ServedBy() {
+			i, ok := byURL[s.URL]
+			if !ok {
+				i = len(served)
+				byURL[s.URL] = i
+				served = append(served, ServedEndpoint{URL: s.URL, Calls: make(map[string]int)})
+			}
+			for method, n := range s.Calls {
+				served[i].Calls[method] += n
+			}
+		}
+	}
+	return served
+}
+
+// readOnlyPrefixes are the method prefixes that only read the chain state, so their
+// calls may be spread across endpoints
+var readOnlyPrefixes = []string{
+	"Filecoin.State",
+	"Filecoin.Chain",
+	"Filecoin.GasEstimate",
+	"Filecoin.Version",
+	"Filecoin.MpoolPending",
+	"Filecoin.MpoolGetNonce",
+	"Filecoin.WalletBalance",
+}
+
+// isReadOnly reports whether every request of a batch only reads the chain state
+func isReadOnly(requests []map[string]interface{}) bool {
+	for _, req := range requests {
+		method, _ := req["method"].(string)
+		readOnly := false
+		for _, prefix := range readOnlyPrefixes {
+			if strings.HasPrefix(method, prefix) {
+				readOnly = true
+				break
+			}
+		}
+		if !readOnly {
+			return false
+		}
+	}
+	return true
+}
+
+// batchBody marshals a batch and sends it, recording its calls on the endpoint that
+// served it
+func (c *Client) batchBody(ctx context.Context, requests []map[string]interface{}, first *endpoint) ([]map[string]interface{}, error) {
+	data, err := json.Marshal(requests)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal requests: %w", err)
+	}
+
+	body, e, err := c.do(ctx, data, first)
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []map[string]interface{}
+	if err := json.Unmarshal(body, &responses); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	methods := make([]string, 0, len(requests))
+	for _, req := range requests {
+		method, _ := req["method"].(string)
+		methods = append(methods, method)
+	}
+	e.served(methods...)
+	return responses, nil
+}
+
+// balancedBatch splits a read-only batch across the healthy endpoints and sends the
+// parts concurrently. Each part fails over to the other endpoints on its own.
+func (c *Client) balancedBatch(ctx context.Context, requests []map[string]interface{}, healthy []*endpoint) ([]map[string]interface{}, error) {
+	n := len(healthy)
+	if len(requests) < n {
+		n = len(requests)
+	}
+	parts := make([][]map[string]interface{}, n)
+	for i, req := range requests {
+		parts[i%n] = append(parts[i%n], req)
+	}
+
+	results := make([][]map[string]interface{}, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range parts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = c.batchBody(ctx, parts[i], healthy[i])
+		}(i)
+	}
+	wg.Wait()
+
+	responses := make([]map[string]interface{}, 0, len(requests))
+	for i := range results {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		responses = append(responses, results[i]...)
+	}
+	return responses, nil
+}
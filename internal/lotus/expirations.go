@@ -0,0 +1,496 @@
+package lotus
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+	stbig "github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/go-state-types/builtin/v15/miner"
+	"github.com/filecoin-project/go-state-types/builtin/v15/verifreg"
+
+	"github.com/THCloudAI/thctl/internal/units"
+)
+
+// Expiration bucket sizes
+const (
+	BucketDay   = "day"
+	BucketWeek  = "week"
+	BucketMonth = "month"
+)
+
+// sectorOnChain is a single entry of StateMinerSectors
+type sectorOnChain struct {
+	SectorNumber       uint64 `json:"SectorNumber"`
+	SealProof          int64  `json:"SealProof"`
+	Activation         int64  `json:"Activation"`
+	Expiration         int64  `json:"Expiration"`
+	PowerBaseEpoch     int64  `json:"PowerBaseEpoch"`
+	VerifiedDealWeight string `json:"VerifiedDealWeight"`
+	InitialPledge      string `json:"InitialPledge"`
+}
+
+// stateMinerInfo is the subset of StateMinerInfo used to build messages and compute power
+type stateMinerInfo struct {
+	Owner               string   `json:"Owner"`
+	Worker              string   `json:"Worker"`
+	NewWorker           string   `json:"NewWorker"`
+	WorkerChangeEpoch   int64    `json:"WorkerChangeEpoch"`
+	ControlAddresses    []string `json:"ControlAddresses"`
+	PendingOwnerAddress *string  `json:"PendingOwnerAddress"`
+	Beneficiary         string   `json:"Beneficiary"`
+	SectorSize          uint64   `json:"SectorSize"`
+	PeerId              *string  `json:"PeerId"`
+	Multiaddrs          [][]byte `json:"Multiaddrs"`
+
+	BeneficiaryTerm struct {
+		Quota      string `json:"Quota"`
+		UsedQuota  string `json:"UsedQuota"`
+		Expiration int64  `json:"Expiration"`
+	} `json:"BeneficiaryTerm"`
+	PendingBeneficiaryTerm *struct {
+		NewBeneficiary        string `json:"NewBeneficiary"`
+		NewQuota              string `json:"NewQuota"`
+		NewExpiration         int64  `json:"NewExpiration"`
+		ApprovedByBeneficiary bool   `json:"ApprovedByBeneficiary"`
+		ApprovedByNominee     bool   `json:"ApprovedByNominee"`
+	} `json:"PendingBeneficiaryTerm"`
+}
+
+// hasPendingWorker reports whether a worker change has been proposed and not yet confirmed
+func (m *stateMinerInfo) hasPendingWorker() bool {
+	return m.WorkerChangeEpoch >= 0 && m.NewWorker != "" && m.NewWorker != "<empty>"
+}
+
+// claim is a single entry of StateGetClaims
+type claim struct {
+	TermMax   int64  `json:"TermMax"`
+	TermStart int64  `json:"TermStart"`
+	Sector    uint64 `json:"Sector"`
+}
+
+// sectorLocation is the deadline and partition a sector is assigned to
+type sectorLocation struct {
+	deadline, partition uint64
+}
+
+// extensionDeclaration is a single ExpirationExtension2 entry being planned
+type extensionDeclaration struct {
+	sectorLocation
+	expiration int64
+	sectors    []uint64
+	claims     []miner.SectorClaim
+}
+
+// ExtensionOptions controls which sectors an extension plan covers and how messages are split
+type ExtensionOptions struct {
+	// ExpiringBefore selects the sectors expiring at or before this epoch
+	ExpiringBefore int64
+	// NewExpiration is the target expiration, lowered per sector to what the actor allows
+	NewExpiration int64
+	// MaxSectors limits the sectors extended by a single message (at most miner.AddressedSectorsMax)
+	MaxSectors uint64
+}
+
+// GetExpirationCalendar groups the sectors of a miner by the day, week or month in
+// which they expire, with the power and initial pledge expiring in each period.
+func (c *Client) GetExpirationCalendar(ctx context.Context, minerID string, bucket string) (*ExpirationCalendar, error) {
+	if bucket != BucketDay && bucket != BucketWeek && bucket != BucketMonth {
+		return nil, fmt.Errorf("unsupported bucket: %s", bucket)
+	}
+
+	head, err := c.GetChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	network, err := c.Network(ctx)
+	if err != nil {
+		return nil, err
+	}
+	info, err := c.getStateMinerInfo(ctx, minerID)
+	if err != nil {
+		return nil, err
+	}
+	sectors, err := c.getMinerSectors(ctx, minerID)
+	if err != nil {
+		return nil, err
+	}
+
+	type totals struct {
+		bucket ExpirationBucket
+		start  time.Time
+		raw    *big.Int
+		qa     *big.Int
+		pledge *big.Int
+	}
+	var (
+		byPeriod                   = make(map[string]*totals)
+		totalRaw, totalQA, pledged = new(big.Int), new(big.Int), new(big.Int)
+		size                       = new(big.Int).SetUint64(info.SectorSize)
+	)
+
+	for _, sector := range sectors {
+		start, end, period := bucketBounds(time.Unix(network.EpochToTimestamp(sector.Expiration), 0), bucket)
+		t, ok := byPeriod[period]
+		if !ok {
+			t = &totals{
+				bucket: ExpirationBucket{
+					Period:     period,
+					StartEpoch: network.TimeToEpoch(start),
+					EndEpoch:   network.TimeToEpoch(end) - 1,
+				},
+				start:  start,
+				raw:    new(big.Int),
+				qa:     new(big.Int),
+				pledge: new(big.Int),
+			}
+			byPeriod[period] = t
+		}
+
+		qa := sectorQualityAdjPower(info.SectorSize, sector)
+		pledge := parseBigInt(sector.InitialPledge)
+
+		t.bucket.Sectors++
+		t.raw.Add(t.raw, size)
+		t.qa.Add(t.qa, qa)
+		t.pledge.Add(t.pledge, pledge)
+		totalRaw.Add(totalRaw, size)
+		totalQA.Add(totalQA, qa)
+		pledged.Add(pledged, pledge)
+	}
+
+	calendar := &ExpirationCalendar{
+		MinerID:              minerID,
+		CurrentEpoch:         int64(head.Height),
+		Bucket:               bucket,
+		SectorSize:           info.SectorSize,
+		TotalSectors:         uint64(len(sectors)),
+		TotalRawBytePower:    units.NewBytes(totalRaw),
+		TotalQualityAdjPower: units.NewBytes(totalQA),
+		TotalInitialPledge:   units.NewFIL(pledged),
+		Buckets:              make([]ExpirationBucket, 0, len(byPeriod)),
+	}
+
+	ordered := make([]*totals, 0, len(byPeriod))
+	for _, t := range byPeriod {
+		ordered = append(ordered, t)
+	}
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].start.Before(ordered[j].start) })
+	for _, t := range ordered {
+		t.bucket.RawBytePower = units.NewBytes(t.raw)
+		t.bucket.QualityAdjPower = units.NewBytes(t.qa)
+		t.bucket.InitialPledge = units.NewFIL(t.pledge)
+		calendar.Buckets = append(calendar.Buckets, t.bucket)
+	}
+
+	return calendar, nil
+}
+
+// PlanSectorExtensions proposes ExtendSectorExpiration2 messages extending the live,
+// non-faulty sectors that expire before opts.ExpiringBefore. The new expiration of each
+// sector is capped by the maximum extension, the sector's maximum lifetime and the
+// terms of its verified claims. Messages are returned unsigned, without nonce or gas.
+func (c *Client) PlanSectorExtensions(ctx context.Context, minerID string, opts ExtensionOptions) (*ExtensionPlan, error) {
+	if opts.MaxSectors == 0 || opts.MaxSectors > miner.AddressedSectorsMax {
+		opts.MaxSectors = miner.AddressedSectorsMax
+	}
+
+	var dlInfo provingDeadline
+	if err := c.callRPCWithRetry(ctx, "Filecoin.StateMinerProvingDeadline", []interface{}{minerID, nil}, &dlInfo); err != nil {
+		return nil, fmt.Errorf("failed to get proving deadline: %w", err)
+	}
+	info, err := c.getStateMinerInfo(ctx, minerID)
+	if err != nil {
+		return nil, err
+	}
+	sectors, err := c.getMinerSectors(ctx, minerID)
+	if err != nil {
+		return nil, err
+	}
+	partitions, err := c.getPartitions(ctx, minerID, int(miner.WPoStPeriodDeadlines))
+	if err != nil {
+		return nil, err
+	}
+
+	// Locate every sector that can be extended: live and not faulty
+	locations := make(map[uint64]sectorLocation)
+	faulty := make(map[uint64]bool)
+	for dl, parts := range partitions {
+		for p, part := range parts {
+			live, err := part.LiveSectors.All(miner.AddressedSectorsMax)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode live sectors of deadline %d partition %d: %w", dl, p, err)
+			}
+			for _, n := range live {
+				locations[n] = sectorLocation{deadline: uint64(dl), partition: uint64(p)}
+			}
+			faults, err := part.FaultySectors.All(miner.AddressedSectorsMax)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode faulty sectors of deadline %d partition %d: %w", dl, p, err)
+			}
+			for _, n := range faults {
+				faulty[n] = true
+			}
+		}
+	}
+
+	plan := &ExtensionPlan{
+		MinerID:        minerID,
+		CurrentEpoch:   dlInfo.CurrentEpoch,
+		ExpiringBefore: opts.ExpiringBefore,
+		NewExpiration:  opts.NewExpiration,
+		Messages:       make([]ExtensionMessage, 0),
+	}
+	if maxExpiration := dlInfo.CurrentEpoch + int64(miner.MaxSectorExpirationExtension); opts.NewExpiration > maxExpiration {
+		plan.NewExpiration = maxExpiration
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+			"new expiration %d is beyond the maximum extension and was lowered to %d", opts.NewExpiration, maxExpiration))
+	}
+
+	selected := make([]sectorOnChain, 0)
+	needClaims := false
+	for _, sector := range sectors {
+		if sector.Expiration > opts.ExpiringBefore {
+			continue
+		}
+		selected = append(selected, sector)
+		if parseBigInt(sector.VerifiedDealWeight).Sign() > 0 {
+			needClaims = true
+		}
+	}
+
+	claimsBySector := make(map[uint64][]verifreg.ClaimId)
+	claimLimit := make(map[uint64]int64)
+	if needClaims {
+		var claims map[string]claim
+		if err := c.callRPCWithRetry(ctx, "Filecoin.StateGetClaims", []interface{}{minerID, nil}, &claims); err != nil {
+			return nil, fmt.Errorf("failed to get verified claims: %w", err)
+		}
+		for id, cl := range claims {
+			claimID, err := strconv.ParseUint(id, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid claim id %q: %w", id, err)
+			}
+			claimsBySector[cl.Sector] = append(claimsBySector[cl.Sector], verifreg.ClaimId(claimID))
+			if end := cl.TermStart + cl.TermMax; claimLimit[cl.Sector] == 0 || end < claimLimit[cl.Sector] {
+				claimLimit[cl.Sector] = end
+			}
+		}
+	}
+
+	type extensionItem struct {
+		sectorLocation
+		expiration int64
+		sector     uint64
+		claims     []verifreg.ClaimId
+	}
+	items := make([]extensionItem, 0, len(selected))
+
+	// The actor rejects the whole message when it changes the deadline being proven or
+	// the one after it, so their sectors are left for a later plan
+	immutable := map[uint64]bool{
+		dlInfo.Index: true,
+		(dlInfo.Index + 1) % miner.WPoStPeriodDeadlines: true,
+	}
+	deferred := 0
+
+	for _, sector := range selected {
+		skip := func(reason string) {
+			plan.Skipped = append(plan.Skipped, SkippedSector{SectorNumber: sector.SectorNumber, Expiration: sector.Expiration, Reason: reason})
+		}
+
+		loc, live := locations[sector.SectorNumber]
+		switch {
+		case sector.Expiration <= dlInfo.CurrentEpoch:
+			skip("already expired")
+			continue
+		case !live:
+			skip("not live")
+			continue
+		case faulty[sector.SectorNumber]:
+			skip("faulty")
+			continue
+		case immutable[loc.deadline]:
+			skip(fmt.Sprintf("in deadline %d, which cannot be changed while it is the current or next proving deadline", loc.deadline))
+			deferred++
+			continue
+		}
+
+		lifetime, err := builtin.SealProofSectorMaximumLifetime(abi.RegisteredSealProof(sector.SealProof))
+		if err != nil {
+			skip(fmt.Sprintf("unknown seal proof %d", sector.SealProof))
+			continue
+		}
+
+		expiration, limit := plan.NewExpiration, "target expiration"
+		if maxLifetime := sector.Activation + int64(lifetime); maxLifetime < expiration {
+			expiration, limit = maxLifetime, "maximum sector lifetime"
+		}
+
+		item := extensionItem{sectorLocation: loc, sector: sector.SectorNumber}
+		if parseBigInt(sector.VerifiedDealWeight).Sign() > 0 {
+			item.claims = claimsBySector[sector.SectorNumber]
+			if len(item.claims) == 0 {
+				skip("has verified deal weight but no verified registry claims")
+				continue
+			}
+			if claimLimit[sector.SectorNumber] < expiration {
+				expiration, limit = claimLimit[sector.SectorNumber], "verified claim term"
+			}
+		}
+
+		if expiration <= sector.Expiration {
+			skip(fmt.Sprintf("cannot be extended past its current expiration (limited by %s)", limit))
+			continue
+		}
+		item.expiration = expiration
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.deadline != b.deadline {
+			return a.deadline < b.deadline
+		}
+		if a.partition != b.partition {
+			return a.partition < b.partition
+		}
+		if a.expiration != b.expiration {
+			return a.expiration < b.expiration
+		}
+		return a.sector < b.sector
+	})
+
+	// Pack declarations (one per deadline, partition and new expiration) into messages
+	// without exceeding the per-message sector and declaration limits
+	var (
+		pending      []extensionDeclaration
+		pendingCount uint64
+	)
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		msg, err := buildExtensionMessage(minerID, info.Worker, pending)
+		if err != nil {
+			return err
+		}
+		msg.Sectors = pendingCount
+		for _, d := range pending {
+			if len(msg.Deadlines) == 0 || msg.Deadlines[len(msg.Deadlines)-1] != d.deadline {
+				msg.Deadlines = append(msg.Deadlines, d.deadline)
+			}
+		}
+		plan.Messages = append(plan.Messages, *msg)
+		plan.Sectors += pendingCount
+		pending, pendingCount = nil, 0
+		return nil
+	}
+
+	for _, item := range items {
+		if pendingCount == opts.MaxSectors {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+		last := len(pending) - 1
+		if last < 0 || pending[last].sectorLocation != item.sectorLocation || pending[last].expiration != item.expiration {
+			if len(pending) == miner.DeclarationsMax {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+			}
+			pending = append(pending, extensionDeclaration{sectorLocation: item.sectorLocation, expiration: item.expiration, claims: []miner.SectorClaim{}})
+			last = len(pending) - 1
+		}
+		if len(item.claims) > 0 {
+			pending[last].claims = append(pending[last].claims, miner.SectorClaim{
+				SectorNumber:   abi.SectorNumber(item.sector),
+				MaintainClaims: item.claims,
+				DropClaims:     []verifreg.ClaimId{},
+			})
+		} else {
+			pending[last].sectors = append(pending[last].sectors, item.sector)
+		}
+		pendingCount++
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	if deferred > 0 {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+			"%d sectors in the current or next proving deadline were left out; plan again in about an hour to extend them", deferred))
+	}
+
+	return plan, nil
+}
+
+// buildExtensionMessage encodes the ExtendSectorExpiration2 params for a set of
+// declarations into an unsigned message from the worker to the miner
+func buildExtensionMessage(minerID, worker string, declarations []extensionDeclaration) (*ExtensionMessage, error) {
+	params := miner.ExtendSectorExpiration2Params{
+		Extensions: make([]miner.ExpirationExtension2, 0, len(declarations)),
+	}
+	for _, d := range declarations {
+		params.Extensions = append(params.Extensions, miner.ExpirationExtension2{
+			Deadline:          d.deadline,
+			Partition:         d.partition,
+			Sectors:           bitfield.NewFromSet(d.sectors),
+			SectorsWithClaims: d.claims,
+			NewExpiration:     abi.ChainEpoch(d.expiration),
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := params.MarshalCBOR(&buf); err != nil {
+		return nil, fmt.Errorf("failed to encode extension params: %w", err)
+	}
+
+	return &ExtensionMessage{
+		Declarations: len(declarations),
+		Message: UnsignedMessage{
+			To:         minerID,
+			From:       worker,
+			Value:      "0",
+			GasFeeCap:  "0",
+			GasPremium: "0",
+			Method:     uint64(builtin.MethodsMiner.ExtendSectorExpiration2),
+			Params:     buf.Bytes(),
+		},
+	}, nil
+}
+
+// sectorQualityAdjPower computes the quality adjusted power of a sector from its verified deal weight
+func sectorQualityAdjPower(sectorSize uint64, sector sectorOnChain) *big.Int {
+	base := sector.PowerBaseEpoch
+	if base == 0 {
+		base = sector.Activation
+	}
+	verified := stbig.NewFromGo(parseBigInt(sector.VerifiedDealWeight))
+	return miner.QAPowerForWeight(abi.SectorSize(sectorSize), abi.ChainEpoch(sector.Expiration-base), verified).Int
+}
+
+// bucketBounds returns the start and end of the day, week or month containing t, and its label
+func bucketBounds(t time.Time, bucket string) (time.Time, time.Time, string) {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch bucket {
+	case BucketWeek:
+		start := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		year, week := start.ISOWeek()
+		return start, start.AddDate(0, 0, 7), fmt.Sprintf("%d-W%02d", year, week)
+	case BucketMonth:
+		start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0), start.Format("2006-01")
+	default:
+		return day, day.AddDate(0, 0, 1), day.Format("2006-01-02")
+	}
+}
@@ -0,0 +1,174 @@
+package lotus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// syncStages names the stages reported by SyncState, in the order of the Lotus enum
+var syncStages = []string{"idle", "headers", "persist headers", "messages", "complete", "error", "fetching messages"}
+
+// roundTripSamples is the number of Version calls timed to measure the round trip
+const roundTripSamples = 3
+
+// NodeHealth describes the Lotus node behind the client: its version, network, chain
+// sync state and the permissions of the configured token. Latencies are in milliseconds.
+type NodeHealth struct {
+	Endpoint         string       `json:"endpoint"`
+	Version          string       `json:"version"`
+	APIVersion       string       `json:"apiVersion"`
+	Network          string       `json:"network"`
+	NetworkVersion   uint64       `json:"networkVersion"`
+	RoundTripMs      float64      `json:"roundTripMs"`
+	MaxRoundTripMs   float64      `json:"maxRoundTripMs"`
+	HeadHeight       int64        `json:"headHeight"`
+	HeadTimestamp    int64        `json:"headTimestamp"`
+	ExpectedHeight   int64        `json:"expectedHeight"`
+	LagEpochs        int64        `json:"lagEpochs"`
+	LagSeconds       int64        `json:"lagSeconds"`
+	Syncs            []SyncWorker `json:"syncs"`
+	TokenConfigured  bool         `json:"tokenConfigured"`
+	Permissions      []string     `json:"permissions"`
+	PermissionsError string       `json:"permissionsError,omitempty"`
+}
+
+// SyncWorker is the state of one of the chain sync workers of the node
+type SyncWorker struct {
+	WorkerID     uint64 `json:"workerId"`
+	Stage        string `json:"stage"`
+	BaseHeight   int64  `json:"baseHeight"`
+	TargetHeight int64  `json:"targetHeight"`
+	Height       int64  `json:"height"`
+	Message      string `json:"message,omitempty"`
+}
+
+// HasPermission reports whether the token grants perm
+func (h *NodeHealth) HasPermission(perm string) bool {
+	for _, p := range h.Permissions {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeVersion is the result of the Version call
+type nodeVersion struct {
+	Version    string `json:"Version"`
+	APIVersion uint32 `json:"APIVersion"`
+}
+
+// syncState is the result of the SyncState call
+type syncState struct {
+	ActiveSyncs []struct {
+		WorkerID uint64  `json:"WorkerID"`
+		Base     *TipSet `json:"Base"`
+		Target   *TipSet `json:"Target"`
+		Stage    int     `json:"Stage"`
+		Height   int64   `json:"Height"`
+		Message  string  `json:"Message"`
+	} `json:"ActiveSyncs"`
+}
+
+// GetNodeHealth checks the node behind the client. It fails only when the node cannot be
+// reached; a token the node rejects is reported in PermissionsError, with the other
+// checks made without it.
+func (c *Client) GetNodeHealth(ctx context.Context) (*NodeHealth, error) {
+	network, err := c.Network(ctx)
+	if err != nil {
+		// A rejected token fails every call; check the node without it to tell the two apart
+		if c.token != "" && errors.Is(err, errUnauthorized) {
+			health, err := New(Config{APIURL: c.apiURL, Timeout: c.httpClient.Timeout}).GetNodeHealth(ctx)
+			if err != nil {
+				return nil, err
+			}
+			health.TokenConfigured = true
+			health.PermissionsError = errUnauthorized.Error()
+			return health, nil
+		}
+		return nil, err
+	}
+
+	health := &NodeHealth{
+		Endpoint:        c.apiURL,
+		Network:         network.Name,
+		NetworkVersion:  network.Version,
+		TokenConfigured: c.token != "",
+		Syncs:           make([]SyncWorker, 0),
+		Permissions:     make([]string, 0),
+	}
+
+	// Time a few cheap calls, the first of which also reports the version
+	var total time.Duration
+	for i := 0; i < roundTripSamples; i++ {
+		var version nodeVersion
+		start := time.Now()
+		if err := c.callRPCWithRetry(ctx, "Filecoin.Version", []interface{}{}, &version); err != nil {
+			return nil, fmt.Errorf("failed to get node version: %w", err)
+		}
+		elapsed := time.Since(start)
+		total += elapsed
+		if ms := millis(elapsed); ms > health.MaxRoundTripMs {
+			health.MaxRoundTripMs = ms
+		}
+		health.Version = version.Version
+		health.APIVersion = formatAPIVersion(version.APIVersion)
+	}
+	health.RoundTripMs = millis(total / roundTripSamples)
+
+	head, err := c.GetChainHead(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain head: %w", err)
+	}
+	now := time.Now()
+	health.HeadHeight = int64(head.Height)
+	if len(head.Blocks) > 0 {
+		health.HeadTimestamp = head.Blocks[0].Timestamp
+	}
+	health.ExpectedHeight = (now.Unix() - network.GenesisTimestamp) / network.BlockDelaySecs
+	health.LagEpochs = max(health.ExpectedHeight-health.HeadHeight, 0)
+	health.LagSeconds = max(now.Unix()-health.HeadTimestamp, 0)
+
+	var state syncState
+	if err := c.callRPCWithRetry(ctx, "Filecoin.SyncState", []interface{}{}, &state); err != nil {
+		return nil, fmt.Errorf("failed to get sync state: %w", err)
+	}
+	for _, s := range state.ActiveSyncs {
+		worker := SyncWorker{WorkerID: s.WorkerID, Stage: syncStage(s.Stage), Height: s.Height, Message: s.Message}
+		if s.Base != nil {
+			worker.BaseHeight = int64(s.Base.Height)
+		}
+		if s.Target != nil {
+			worker.TargetHeight = int64(s.Target.Height)
+		}
+		health.Syncs = append(health.Syncs, worker)
+	}
+
+	if c.token != "" {
+		if err := c.callRPCWithRetry(ctx, "Filecoin.AuthVerify", []interface{}{c.token}, &health.Permissions); err != nil {
+			health.PermissionsError = err.Error()
+		}
+	}
+
+	return health, nil
+}
+
+// formatAPIVersion formats an API version packed as major, minor and patch bytes
+func formatAPIVersion(v uint32) string {
+	return fmt.Sprintf("v%d.%d.%d", v>>16&0xff, v>>8&0xff, v&0xff)
+}
+
+// syncStage names a SyncState stage
+func syncStage(stage int) string {
+	if stage < 0 || stage >= len(syncStages) {
+		return fmt.Sprintf("stage %d", stage)
+	}
+	return syncStages[stage]
+}
+
+// millis converts a duration to milliseconds, keeping microseconds
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,836 @@
+package lotus
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/go-state-types/builtin/v15/miner"
+	"github.com/THCloudAI/thctl/internal/cache"
+	"github.com/THCloudAI/thctl/internal/units"
+)
+
+// Ledger entry categories. Amounts are signed changes of the part of the account the
+// category belongs to: the balance for rewards, deposits, withdrawals, fees, unexplained
+// changes and gas, the initial pledge for pledge entries and the vesting funds for vesting
+// releases. Penalties are the funds the miner burned while executing a message sent to it,
+// such as termination fees and repaid fee debt; fault fees are those it burned in its
+// deadline cron, such as fault fees and expired pre-commit deposits.
+const (
+	LedgerBlockReward    = "block_reward"
+	LedgerDeposit        = "deposit"
+	LedgerWithdrawal     = "withdrawal"
+	LedgerVestingRelease = "vesting_release"
+	LedgerPledgeLock     = "pledge_lock"
+	LedgerPledgeUnlock   = "pledge_unlock"
+	LedgerPenalty        = "penalty"
+	LedgerFaultFee       = "fault_fee"
+	LedgerUnexplained    = "unexplained"
+	LedgerGasBurn        = "gas_burn"
+	LedgerGasTip         = "gas_tip"
+)
+
+// LedgerCategories lists the ledger categories in export order
+var LedgerCategories = []string{
+	LedgerBlockReward,
+	LedgerDeposit,
+	LedgerWithdrawal,
+	LedgerVestingRelease,
+	LedgerPledgeLock,
+	LedgerPledgeUnlock,
+	LedgerPenalty,
+	LedgerFaultFee,
+	LedgerUnexplained,
+	LedgerGasBurn,
+	LedgerGasTip,
+}
+
+// Roles of the ledger accounts besides the owner and the worker
+const (
+	RoleMiner   = "miner"
+	RoleControl = "control"
+)
+
+const (
+	// LedgerDateFormat is the layout of the ledger dates
+	LedgerDateFormat = "2006-01-02"
+	// lockedRewardPercent is the share of block rewards locked in the vesting funds
+	lockedRewardPercent = 75
+	// gasOveruseNum and gasOveruseDenom give the gas limit allowed above the gas used
+	// before the over-estimation is burned
+	gasOveruseNum   = 11
+	gasOveruseDenom = 10
+)
+
+// minerFunds is the subset of the miner actor state holding its funds
+type minerFunds struct {
+	LockedFunds       string `json:"LockedFunds"`
+	InitialPledge     string `json:"InitialPledge"`
+	PreCommitDeposits string `json:"PreCommitDeposits"`
+	FeeDebt           string `json:"FeeDebt"`
+	// VestingFunds links to the vesting table
+	VestingFunds map[string]string `json:"VestingFunds"`
+}
+
+// available returns the part of balance that is neither locked nor owed, like the
+// actor's available balance
+func (f *minerFunds) available(balance *big.Int) *big.Int {
+	available := new(big.Int).Set(balance)
+	for _, locked := range []string{f.LockedFunds, f.InitialPledge, f.PreCommitDeposits, f.FeeDebt} {
+		available.Sub(available, parseBigInt(locked))
+	}
+	return available
+}
+
+// ledgerSnapshot is the miner state at a day boundary
+type ledgerSnapshot struct {
+	tsk     []map[string]string
+	funds   LedgerFunds
+	vesting []miner.VestingFund
+	faulty  uint64
+}
+
+// ledgerMessage is a message of a ledger account with its receipt
+type ledgerMessage struct {
+	cid      string
+	sender   string
+	toMiner  bool
+	msg      UnsignedMessage
+	receipt  messageReceipt
+	executed string
+}
+
+// GetMinerLedger classifies the fund flows of a miner and of its owner, worker and
+// control addresses for each UTC day from the from date to the to date, capped at the
+// chain head. Block rewards, deposits, withdrawals and gas come from the chain, penalties
+// and fault fees from the burns in the execution traces, and vesting releases from the
+// vesting table. Pledge changes and the balance changes left unexplained are derived from
+// the miner state at the day boundaries. The balance and vesting funds are reconciled
+// against the flows read from the chain only. Control addresses are the current ones.
+// Finalized epochs of the block history are cached in store when it is not nil.
+func (c *Client) GetMinerLedger(ctx context.Context, minerID string, from, to time.Time, store *cache.Store) (*MinerLedger, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("invalid date range: from %s is after to %s",
+			from.Format(LedgerDateFormat), to.Format(LedgerDateFormat))
+	}
+
+	network, err := c.Network(ctx)
+	if err != nil {
+		return nil, err
+	}
+	head, err := c.GetChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each day starts at the first epoch of its midnight, the last one ends at the head
+	var dates []string
+	var bounds []int64
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		start := network.TimeToEpoch(day)
+		if start >= int64(head.Height) {
+			break
+		}
+		dates = append(dates, day.Format(LedgerDateFormat))
+		bounds = append(bounds, start)
+	}
+	if len(dates) == 0 {
+		return nil, fmt.Errorf("%s starts after the chain head at epoch %d", from.Format(LedgerDateFormat), head.Height)
+	}
+	end := network.TimeToEpoch(from.AddDate(0, 0, len(dates)))
+	if end > int64(head.Height) {
+		end = int64(head.Height)
+	}
+	bounds = append(bounds, end)
+
+	snapshots, err := c.ledgerSnapshots(ctx, minerID, bounds)
+	if err != nil {
+		return nil, err
+	}
+	// A boundary in null rounds moves to the next tipset
+	for i := range snapshots {
+		bounds[i] = snapshots[i].funds.Height
+	}
+	last := len(bounds) - 1
+
+	info, err := c.getStateMinerInfo(ctx, minerID)
+	if err != nil {
+		return nil, err
+	}
+	accounts := map[string]string{minerID: RoleMiner}
+	for _, account := range []struct{ addr, role string }{{info.Owner, RoleOwner}, {info.Worker, RoleWorker}} {
+		if _, ok := accounts[account.addr]; !ok {
+			accounts[account.addr] = account.role
+		}
+	}
+	for _, addr := range info.ControlAddresses {
+		if _, ok := accounts[addr]; !ok {
+			accounts[addr] = RoleControl
+		}
+	}
+
+	ledger := &MinerLedger{
+		MinerID:    minerID,
+		From:       dates[0],
+		To:         dates[len(dates)-1],
+		FromHeight: bounds[0],
+		ToHeight:   bounds[last],
+		Accounts:   accounts,
+		Totals:     make(map[string]units.FIL),
+		Days:       make([]LedgerDay, 0, len(dates)),
+		Entries:    make([]LedgerEntry, 0),
+	}
+	newEntry := func(height, timestamp int64, account, addr, category string, amount *big.Int) LedgerEntry {
+		return LedgerEntry{
+			Date:      dates[ledgerDay(bounds, height)],
+			Height:    height,
+			Timestamp: timestamp,
+			Account:   account,
+			Address:   addr,
+			Category:  category,
+			Amount:    units.NewFIL(amount),
+		}
+	}
+
+	// Traces name the miner by ID
+	minerIDAddr, err := c.resolveMinerID(ctx, minerID)
+	if err != nil {
+		return nil, err
+	}
+	var dlInfo provingDeadline
+	if err := c.callRPCWithRetry(ctx, "Filecoin.StateMinerProvingDeadline", []interface{}{minerID, snapshots[0].tsk}, &dlInfo); err != nil {
+		return nil, fmt.Errorf("failed to get proving deadline: %w", err)
+	}
+	if dlInfo.WPoStChallengeWindow <= 0 {
+		return nil, fmt.Errorf("invalid proving deadline of %s", minerID)
+	}
+
+	if bounds[last] > bounds[0] {
+		history, err := c.GetBlockHistory(ctx, minerID, uint64(bounds[0]), uint64(bounds[last]-1), store)
+		if err != nil {
+			return nil, err
+		}
+		for _, block := range history.Blocks {
+			entry := newEntry(int64(block.Height), block.Timestamp, RoleMiner, minerID, LedgerBlockReward, block.Reward.Atto())
+			entry.Cid = block.Cid
+			ledger.Entries = append(ledger.Entries, entry)
+		}
+
+		messages, err := c.ledgerMessages(ctx, minerID, accounts, snapshots[last].tsk, bounds[0])
+		if err != nil {
+			return nil, err
+		}
+		included, err := c.inclusionTipSets(ctx, messages)
+		if err != nil {
+			return nil, err
+		}
+		var replayed []*ledgerMessage
+		for _, m := range messages {
+			ts := included[m.executed]
+			if m.toMiner && m.receipt.ExitCode == 0 && ledgerDay(bounds, int64(ts.Height)) >= 0 {
+				replayed = append(replayed, m)
+			}
+		}
+		burns, err := c.messageBurns(ctx, minerIDAddr, replayed, included)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range messages {
+			ts := included[m.executed]
+			height := int64(ts.Height)
+			if ledgerDay(bounds, height) < 0 {
+				continue
+			}
+			timestamp := ts.Blocks[0].Timestamp
+
+			var flows []LedgerEntry
+			if m.sender != "" {
+				burn, tip := gasCharges(m.msg, m.receipt.GasUsed, parseBigInt(ts.baseFee()))
+				flows = append(flows,
+					newEntry(height, timestamp, accounts[m.sender], m.sender, LedgerGasBurn, burn.Neg(burn)),
+					newEntry(height, timestamp, accounts[m.sender], m.sender, LedgerGasTip, tip.Neg(tip)),
+				)
+			}
+			if m.toMiner && m.receipt.ExitCode == 0 {
+				if value := parseBigInt(m.msg.Value); value.Sign() > 0 {
+					flows = append(flows, newEntry(height, timestamp, RoleMiner, minerID, LedgerDeposit, value))
+				}
+				if m.msg.Method == uint64(builtin.MethodsMiner.WithdrawBalance) {
+					amount := withdrawnAmount(m.msg, m.receipt)
+					flows = append(flows, newEntry(height, timestamp, RoleMiner, minerID, LedgerWithdrawal, amount.Neg(amount)))
+				}
+				burned := new(big.Int).Set(burns[m.cid])
+				flows = append(flows, newEntry(height, timestamp, RoleMiner, minerID, LedgerPenalty, burned.Neg(burned)))
+			}
+			for _, flow := range flows {
+				if flow.Amount.Sign() != 0 {
+					flow.Cid = m.cid
+					ledger.Entries = append(ledger.Entries, flow)
+				}
+			}
+		}
+	}
+
+	// Vesting entries are released by the first deadline cron after they vest
+	for i := range dates {
+		for _, fund := range snapshots[i].vesting {
+			height := nextCron(int64(fund.Epoch), dlInfo.Close, dlInfo.WPoStChallengeWindow)
+			if height >= bounds[i] && height < bounds[i+1] {
+				ledger.Entries = append(ledger.Entries, newEntry(height, network.EpochToTimestamp(height),
+					RoleMiner, minerID, LedgerVestingRelease, new(big.Int).Neg(fund.Amount.Int)))
+			}
+		}
+	}
+
+	// Sum the miner's flows per day
+	var dayTotals []map[string]*big.Int
+	sumDays := func() {
+		dayTotals = make([]map[string]*big.Int, len(dates))
+		for i := range dayTotals {
+			dayTotals[i] = make(map[string]*big.Int)
+		}
+		for _, entry := range ledger.Entries {
+			if entry.Account != RoleMiner {
+				continue
+			}
+			totals := dayTotals[ledgerDay(bounds, entry.Height)]
+			if totals[entry.Category] == nil {
+				totals[entry.Category] = new(big.Int)
+			}
+			totals[entry.Category].Add(totals[entry.Category], entry.Amount.Atto())
+		}
+	}
+	sumDays()
+
+	// unexplained returns the change of the balance over a day that the flows read from the
+	// chain leave out
+	balanceFlows := []string{LedgerBlockReward, LedgerDeposit, LedgerWithdrawal, LedgerPenalty, LedgerFaultFee}
+	unexplained := func(i int) *big.Int {
+		change := new(big.Int).Sub(snapshots[i+1].funds.Balance.Atto(), snapshots[i].funds.Balance.Atto())
+		for _, category := range balanceFlows {
+			if amount := dayTotals[i][category]; amount != nil {
+				change.Sub(change, amount)
+			}
+		}
+		return change
+	}
+
+	// Tracing cron replays whole tipsets, so only the deadline crons of the days the miner
+	// had faulty sectors or the balance fell short of the flows are traced
+	var cronEpochs []int64
+	for i := range dates {
+		if snapshots[i].faulty == 0 && snapshots[i+1].faulty == 0 && unexplained(i).Sign() >= 0 {
+			continue
+		}
+		for epoch := nextCron(bounds[i]-1, dlInfo.Close, dlInfo.WPoStChallengeWindow); epoch < bounds[i+1]; epoch += dlInfo.WPoStChallengeWindow {
+			cronEpochs = append(cronEpochs, epoch)
+		}
+	}
+	if len(cronEpochs) > 0 {
+		burns, err := c.cronBurns(ctx, minerIDAddr, cronEpochs)
+		if err != nil {
+			return nil, err
+		}
+		for height, burned := range burns {
+			if burned.Sign() > 0 && ledgerDay(bounds, height) >= 0 {
+				ledger.Entries = append(ledger.Entries, newEntry(height, network.EpochToTimestamp(height),
+					RoleMiner, minerID, LedgerFaultFee, new(big.Int).Neg(burned)))
+			}
+		}
+		sumDays()
+	}
+
+	// Derive the rest from the state changes
+	lockedRewards := new(big.Int)
+	for i := range dates {
+		opening, closing := snapshots[i].funds, snapshots[i+1].funds
+		totals := dayTotals[i]
+		// Derived entries are booked at the last epoch of the day
+		height := closing.Height - 1
+		timestamp := network.EpochToTimestamp(height)
+		derive := func(category string, amount *big.Int) {
+			if amount.Sign() == 0 {
+				return
+			}
+			entry := newEntry(height, timestamp, RoleMiner, minerID, category, amount)
+			entry.Derived = true
+			ledger.Entries = append(ledger.Entries, entry)
+		}
+
+		// Such as the gas rewards of the miner's blocks, which block rewards leave out
+		derive(LedgerUnexplained, unexplained(i))
+
+		pledge := new(big.Int).Sub(closing.InitialPledge.Atto(), opening.InitialPledge.Atto())
+		if pledge.Sign() > 0 {
+			derive(LedgerPledgeLock, pledge)
+		} else {
+			derive(LedgerPledgeUnlock, pledge)
+		}
+
+		// Vesting funds grow by the locked share of the rewards
+		if reward := totals[LedgerBlockReward]; reward != nil {
+			locked := new(big.Int).Mul(reward, big.NewInt(lockedRewardPercent))
+			lockedRewards.Add(lockedRewards, locked.Div(locked, big.NewInt(100)))
+		}
+	}
+
+	sort.SliceStable(ledger.Entries, func(i, j int) bool {
+		a, b := ledger.Entries[i], ledger.Entries[j]
+		if a.Height != b.Height {
+			return a.Height < b.Height
+		}
+		return !a.Derived && b.Derived
+	})
+
+	// Totals per day and over the whole range
+	for i, date := range dates {
+		day := LedgerDay{
+			Date:        date,
+			StartHeight: bounds[i],
+			EndHeight:   bounds[i+1],
+			Totals:      make(map[string]units.FIL),
+			Opening:     snapshots[i].funds,
+			Closing:     snapshots[i+1].funds,
+		}
+		for _, entry := range ledger.Entries {
+			if entry.Date == date {
+				day.Totals[entry.Category] = day.Totals[entry.Category].Add(entry.Amount)
+			}
+		}
+		for category, amount := range day.Totals {
+			ledger.Totals[category] = ledger.Totals[category].Add(amount)
+		}
+		ledger.Days = append(ledger.Days, day)
+	}
+
+	opening, closing := snapshots[0].funds, snapshots[last].funds
+	sumOf := func(categories ...string) units.FIL {
+		var sum units.FIL
+		for _, category := range categories {
+			for _, entry := range ledger.Entries {
+				if entry.Category == category && entry.Account == RoleMiner {
+					sum = sum.Add(entry.Amount)
+				}
+			}
+		}
+		return sum
+	}
+	// Only flows read from the chain are reconciled, so the differences are the unexplained
+	// balance changes and the fees paid from the vesting funds
+	ledger.Reconciliation = []LedgerReconciliation{
+		reconcile("balance", opening.Balance, closing.Balance, sumOf(balanceFlows...)),
+		reconcile("vestingFunds", opening.VestingFunds, closing.VestingFunds,
+			sumOf(LedgerVestingRelease).Add(units.NewFIL(lockedRewards))),
+	}
+
+	return ledger, nil
+}
+
+// reconcile compares opening plus flows with closing
+func reconcile(field string, opening, closing, flows units.FIL) LedgerReconciliation {
+	expected := opening.Add(flows)
+	return LedgerReconciliation{
+		Field:      field,
+		Opening:    opening,
+		Flows:      flows,
+		Closing:    closing,
+		Difference: units.NewFIL(new(big.Int).Sub(closing.Atto(), expected.Atto())),
+	}
+}
+
+// ledgerDay returns the day whose range holds height, -1 when it is outside every day
+func ledgerDay(bounds []int64, height int64) int {
+	if len(bounds) < 2 || height < bounds[0] || height >= bounds[len(bounds)-1] {
+		return -1
+	}
+	return sort.Search(len(bounds), func(i int) bool { return bounds[i] > height }) - 1
+}
+
+// ledgerSnapshots reads the miner funds, vesting table and faulty sector count at the
+// first tipset at or after each height
+func (c *Client) ledgerSnapshots(ctx context.Context, minerID string, heights []int64) ([]ledgerSnapshot, error) {
+	requests := make([]map[string]interface{}, 0, len(heights))
+	for i, h := range heights {
+		requests = append(requests, newRPCRequest(i, "Filecoin.ChainGetTipSetAfterHeight", h, nil))
+	}
+	results, err := c.batchResults(ctx, requests)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tipsets: %w", err)
+	}
+
+	snapshots := make([]ledgerSnapshot, len(heights))
+	requests = requests[:0]
+	for i, h := range heights {
+		var ts TipSet
+		if err := decodeResult(results[i], &ts); err != nil || len(ts.Cids) == 0 {
+			return nil, fmt.Errorf("no tipset returned for height %d", h)
+		}
+		snapshots[i].tsk = ts.Cids
+		snapshots[i].funds.Height = int64(ts.Height)
+		requests = append(requests,
+			newRPCRequest(3*i, "Filecoin.StateGetActor", minerID, ts.Cids),
+			newRPCRequest(3*i+1, "Filecoin.StateReadState", minerID, ts.Cids),
+			newRPCRequest(3*i+2, "Filecoin.StateMinerSectorCount", minerID, ts.Cids),
+		)
+	}
+	if results, err = c.batchResults(ctx, requests); err != nil {
+		return nil, fmt.Errorf("failed to read miner state: %w", err)
+	}
+
+	requests = requests[:0]
+	for i := range snapshots {
+		var actor struct {
+			Balance string `json:"Balance"`
+		}
+		var state struct {
+			State minerFunds `json:"State"`
+		}
+		var sectors struct {
+			Faulty uint64 `json:"Faulty"`
+		}
+		if decodeResult(results[3*i], &actor) != nil || decodeResult(results[3*i+1], &state) != nil ||
+			decodeResult(results[3*i+2], &sectors) != nil || results[3*i] == nil {
+			return nil, fmt.Errorf("failed to read miner state at height %d", snapshots[i].funds.Height)
+		}
+		funds := &snapshots[i].funds
+		funds.Balance = units.FILFromAtto(actor.Balance)
+		funds.VestingFunds = units.FILFromAtto(state.State.LockedFunds)
+		funds.InitialPledge = units.FILFromAtto(state.State.InitialPledge)
+		funds.PreCommitDeposits = units.FILFromAtto(state.State.PreCommitDeposits)
+		funds.FeeDebt = units.FILFromAtto(state.State.FeeDebt)
+		snapshots[i].faulty = sectors.Faulty
+		requests = append(requests, newRPCRequest(i, "Filecoin.ChainReadObj", state.State.VestingFunds))
+	}
+	if results, err = c.batchResults(ctx, requests); err != nil {
+		return nil, fmt.Errorf("failed to read vesting funds: %w", err)
+	}
+
+	for i := range snapshots {
+		var data []byte
+		var vesting miner.VestingFunds
+		if decodeResult(results[i], &data) != nil || vesting.UnmarshalCBOR(bytes.NewReader(data)) != nil {
+			return nil, fmt.Errorf("failed to read vesting funds at height %d", snapshots[i].funds.Height)
+		}
+		snapshots[i].vesting = vesting.Funds
+	}
+
+	return snapshots, nil
+}
+
+// ledgerMessages finds the messages sent to the miner or from its other accounts down to
+// fromHeight, with their receipts
+func (c *Client) ledgerMessages(ctx context.Context, minerID string, accounts map[string]string, tsk []map[string]string, fromHeight int64) ([]*ledgerMessage, error) {
+	senders := make([]string, 0, len(accounts))
+	for addr, role := range accounts {
+		if role != RoleMiner {
+			senders = append(senders, addr)
+		}
+	}
+	sort.Strings(senders)
+
+	requests := []map[string]interface{}{
+		newRPCRequest(0, "Filecoin.StateListMessages", map[string]interface{}{"To": minerID}, tsk, fromHeight),
+	}
+	for i, addr := range senders {
+		requests = append(requests, newRPCRequest(i+1, "Filecoin.StateListMessages", map[string]interface{}{"From": addr}, tsk, fromHeight))
+	}
+	results, err := c.batchResults(ctx, requests)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+
+	var messages []*ledgerMessage
+	byCid := make(map[string]*ledgerMessage)
+	for i := range requests {
+		var cids []map[string]string
+		if err := decodeResult(results[i], &cids); err != nil {
+			return nil, fmt.Errorf("failed to decode message list: %w", err)
+		}
+		for _, cid := range cids {
+			m := byCid[cid["/"]]
+			if m == nil {
+				m = &ledgerMessage{cid: cid["/"]}
+				byCid[m.cid] = m
+				messages = append(messages, m)
+			}
+			if i == 0 {
+				m.toMiner = true
+			} else {
+				m.sender = senders[i-1]
+			}
+		}
+	}
+
+	requests = make([]map[string]interface{}, 0, 2*len(messages))
+	for i, m := range messages {
+		cid := map[string]string{"/": m.cid}
+		requests = append(requests,
+			newRPCRequest(2*i, "Filecoin.ChainGetMessage", cid),
+			newRPCRequest(2*i+1, "Filecoin.StateSearchMsg", nil, cid, -1, true),
+		)
+	}
+	if results, err = c.batchResults(ctx, requests); err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	for i, m := range messages {
+		var lookup struct {
+			Receipt messageReceipt      `json:"Receipt"`
+			TipSet  []map[string]string `json:"TipSet"`
+		}
+		if err := decodeResult(results[2*i], &m.msg); err != nil {
+			return nil, fmt.Errorf("failed to decode message %s: %w", m.cid, err)
+		}
+		if err := decodeResult(results[2*i+1], &lookup); err != nil || len(lookup.TipSet) == 0 {
+			return nil, fmt.Errorf("failed to find the receipt of message %s", m.cid)
+		}
+		m.receipt = lookup.Receipt
+		m.executed = tipSetKeyString(lookup.TipSet)
+	}
+	return messages, nil
+}
+
+// inclusionTipSets returns the parent of each tipset the messages were executed in, which
+// is the tipset that included them and holds the base fee they paid
+func (c *Client) inclusionTipSets(ctx context.Context, messages []*ledgerMessage) (map[string]*TipSet, error) {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, m := range messages {
+		if !seen[m.executed] {
+			seen[m.executed] = true
+			keys = append(keys, m.executed)
+		}
+	}
+
+	fetch := func(keys []string) (map[string]*TipSet, error) {
+		requests := make([]map[string]interface{}, 0, len(keys))
+		for i, key := range keys {
+			requests = append(requests, newRPCRequest(i, "Filecoin.ChainGetTipSet", parseTipSetKey(key)))
+		}
+		results, err := c.batchResults(ctx, requests)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tipsets: %w", err)
+		}
+		tipsets := make(map[string]*TipSet, len(keys))
+		for i, key := range keys {
+			var ts TipSet
+			if err := decodeResult(results[i], &ts); err != nil || len(ts.Blocks) == 0 {
+				return nil, fmt.Errorf("no tipset returned for key %s", key)
+			}
+			tipsets[key] = &ts
+		}
+		return tipsets, nil
+	}
+
+	executed, err := fetch(keys)
+	if err != nil {
+		return nil, err
+	}
+	var parentKeys []string
+	seen = make(map[string]bool)
+	for _, ts := range executed {
+		key := tipSetKeyString(ts.Blocks[0].Parents)
+		if !seen[key] {
+			seen[key] = true
+			parentKeys = append(parentKeys, key)
+		}
+	}
+	parents, err := fetch(parentKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	included := make(map[string]*TipSet, len(executed))
+	for key, ts := range executed {
+		included[key] = parents[tipSetKeyString(ts.Blocks[0].Parents)]
+	}
+	return included, nil
+}
+
+// executionTrace is the part of a Lotus execution trace that carries value transfers
+type executionTrace struct {
+	Msg struct {
+		From  string `json:"From"`
+		To    string `json:"To"`
+		Value string `json:"Value"`
+	} `json:"Msg"`
+	Subcalls []executionTrace `json:"Subcalls"`
+}
+
+// invocResult is the trace of a message or of an implicit message such as cron
+type invocResult struct {
+	Msg struct {
+		To string `json:"To"`
+	} `json:"Msg"`
+	ExecutionTrace executionTrace `json:"ExecutionTrace"`
+}
+
+// burnedBy sums the funds the actor sent to the burnt funds actor in the trace
+func (t *executionTrace) burnedBy(actor string) *big.Int {
+	burned := new(big.Int)
+	if sameAddress(t.Msg.From, actor) && sameAddress(t.Msg.To, builtin.BurntFundsActorAddr.String()) {
+		burned.Add(burned, parseBigInt(t.Msg.Value))
+	}
+	for i := range t.Subcalls {
+		burned.Add(burned, t.Subcalls[i].burnedBy(actor))
+	}
+	return burned
+}
+
+// messageBurns replays the messages in the tipsets that included them and returns the funds
+// each one made the miner burn by message CID
+func (c *Client) messageBurns(ctx context.Context, minerIDAddr string, messages []*ledgerMessage, included map[string]*TipSet) (map[string]*big.Int, error) {
+	requests := make([]map[string]interface{}, 0, len(messages))
+	for i, m := range messages {
+		requests = append(requests, newRPCRequest(i, "Filecoin.StateReplay", included[m.executed].Cids, map[string]string{"/": m.cid}))
+	}
+	results, err := c.batchResults(ctx, requests)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay messages: %w", err)
+	}
+
+	burns := make(map[string]*big.Int, len(messages))
+	for i, m := range messages {
+		var replay invocResult
+		if err := decodeResult(results[i], &replay); err != nil || results[i] == nil {
+			return nil, fmt.Errorf("failed to replay message %s", m.cid)
+		}
+		burns[m.cid] = replay.ExecutionTrace.burnedBy(minerIDAddr)
+	}
+	return burns, nil
+}
+
+// cronBurns traces the tipsets that run the cron of the given epochs, null rounds running
+// in the next tipset, and returns the funds the miner burned in cron by tipset height
+func (c *Client) cronBurns(ctx context.Context, minerIDAddr string, epochs []int64) (map[int64]*big.Int, error) {
+	requests := make([]map[string]interface{}, 0, len(epochs))
+	for i, epoch := range epochs {
+		requests = append(requests, newRPCRequest(i, "Filecoin.ChainGetTipSetAfterHeight", epoch, nil))
+	}
+	results, err := c.batchResults(ctx, requests)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tipsets: %w", err)
+	}
+
+	var tipsets []TipSet
+	seen := make(map[string]bool)
+	for i, epoch := range epochs {
+		var ts TipSet
+		if err := decodeResult(results[i], &ts); err != nil || len(ts.Cids) == 0 {
+			return nil, fmt.Errorf("no tipset returned for height %d", epoch)
+		}
+		if key := tipSetKeyString(ts.Cids); !seen[key] {
+			seen[key] = true
+			tipsets = append(tipsets, ts)
+		}
+	}
+
+	requests = make([]map[string]interface{}, 0, len(tipsets))
+	for i, ts := range tipsets {
+		requests = append(requests, newRPCRequest(i, "Filecoin.StateCompute", ts.Height, nil, ts.Cids))
+	}
+	if results, err = c.batchResults(ctx, requests); err != nil {
+		return nil, fmt.Errorf("failed to trace cron: %w", err)
+	}
+
+	burns := make(map[int64]*big.Int, len(tipsets))
+	for i, ts := range tipsets {
+		var output struct {
+			Trace []invocResult `json:"Trace"`
+		}
+		if err := decodeResult(results[i], &output); err != nil || results[i] == nil {
+			return nil, fmt.Errorf("failed to trace cron at height %d", ts.Height)
+		}
+		burned := new(big.Int)
+		for _, trace := range output.Trace {
+			// Burns of messages sent to the miner are penalties
+			if sameAddress(trace.Msg.To, builtin.CronActorAddr.String()) {
+				burned.Add(burned, trace.ExecutionTrace.burnedBy(minerIDAddr))
+			}
+		}
+		burns[int64(ts.Height)] = burned
+	}
+	return burns, nil
+}
+
+// nextCron returns the first epoch after epoch at which the deadline cron of a miner whose
+// deadlines close at epochs congruent to close modulo window runs
+func nextCron(epoch, close, window int64) int64 {
+	offset := ((close-1-epoch-1)%window + window) % window
+	return epoch + 1 + offset
+}
+
+// tipSetKeyString joins the block CIDs of a tipset key
+func tipSetKeyString(tsk []map[string]string) string {
+	cids := make([]string, 0, len(tsk))
+	for _, cid := range tsk {
+		cids = append(cids, cid["/"])
+	}
+	return strings.Join(cids, ",")
+}
+
+// parseTipSetKey splits a key joined by tipSetKeyString
+func parseTipSetKey(key string) []map[string]string {
+	var tsk []map[string]string
+	for _, cid := range strings.Split(key, ",") {
+		tsk = append(tsk, map[string]string{"/": cid})
+	}
+	return tsk
+}
+
+// gasCharges returns the gas burned and the tip paid by the sender of a message included
+// in a tipset with baseFee, following the gas outputs of the Lotus VM
+func gasCharges(msg UnsignedMessage, gasUsed int64, baseFee *big.Int) (*big.Int, *big.Int) {
+	feeCap := parseBigInt(msg.GasFeeCap)
+	premium := parseBigInt(msg.GasPremium)
+
+	baseFeeToPay := baseFee
+	if baseFee.Cmp(feeCap) > 0 {
+		baseFeeToPay = feeCap
+	}
+	tipRate := premium
+	if new(big.Int).Add(baseFee, premium).Cmp(feeCap) > 0 {
+		tipRate = new(big.Int).Sub(feeCap, baseFeeToPay)
+	}
+
+	burned := gasUsed + overEstimationBurn(gasUsed, msg.GasLimit)
+	burn := new(big.Int).Mul(baseFeeToPay, big.NewInt(burned))
+	tip := new(big.Int).Mul(tipRate, big.NewInt(msg.GasLimit))
+	return burn, tip
+}
+
+// overEstimationBurn returns the gas burned for a gas limit more than 10% above the gas used
+func overEstimationBurn(gasUsed, gasLimit int64) int64 {
+	if gasUsed == 0 {
+		return gasLimit
+	}
+	over := gasLimit - gasOveruseNum*gasUsed/gasOveruseDenom
+	if over < 0 {
+		return 0
+	}
+	if over > gasUsed {
+		over = gasUsed
+	}
+	burn := new(big.Int).Mul(big.NewInt(gasLimit-gasUsed), big.NewInt(over))
+	return burn.Div(burn, big.NewInt(gasUsed)).Int64()
+}
+
+// withdrawnAmount returns the amount paid out by a WithdrawBalance message, falling back
+// to the requested amount when the receipt does not hold it
+func withdrawnAmount(msg UnsignedMessage, receipt messageReceipt) *big.Int {
+	var amount abi.TokenAmount
+	if err := amount.UnmarshalCBOR(bytes.NewReader(receipt.Return)); err == nil && amount.Int != nil {
+		return new(big.Int).Set(amount.Int)
+	}
+	var params miner.WithdrawBalanceParams
+	if err := params.UnmarshalCBOR(bytes.NewReader(msg.Params)); err == nil && params.AmountRequested.Int != nil {
+		return new(big.Int).Set(params.AmountRequested.Int)
+	}
+	return new(big.Int)
+}
@@ -0,0 +1,268 @@
+package lotus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/THCloudAI/thctl/internal/cache"
+)
+
+// searchFanout is the number of heights probed per round when searching actor history
+const searchFanout = 16
+
+// errStateUnavailable is returned when the node cannot serve the chain or actor state at
+// a probed height, as with a splitstore node that pruned it
+var errStateUnavailable = errors.New("state not available on this node")
+
+// actorAtHeight is the state of an actor as seen at a probed height
+type actorAtHeight struct {
+	Exists bool
+	Head   string
+}
+
+// lastActive is the cached last active epoch of an actor, valid while its state head
+// is unchanged
+type lastActive struct {
+	Head   string `json:"head"`
+	Height uint64 `json:"height"`
+	// SeenHeight is the last chain height at which the actor had this state head
+	SeenHeight uint64 `json:"seenHeight"`
+}
+
+// lifetimeFailure records a search that failed for lack of state, so that runs within a
+// day do not repeat it
+type lifetimeFailure struct {
+	Height uint64 `json:"height"`
+	Error  string `json:"error"`
+}
+
+// GetActorLifetime finds the epoch at which an actor was created and the epoch at
+// which its state last changed by searching actor state over chain heights.
+// When store is not nil, the creation epoch is cached, the last active epoch is cached
+// against the actor's state head and a search the node lacks the state for is not
+// retried for a day.
+func (c *Client) GetActorLifetime(ctx context.Context, addr string, store *cache.Store) (*ActorLifetime, error) {
+	head, err := c.GetChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	network, err := c.Network(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key := strings.TrimLeft(addr, "ft")
+	failureKey := "lifetime-failed-" + key
+	if store != nil {
+		var failure lifetimeFailure
+		found, err := store.Get(failureKey, &failure)
+		if err != nil {
+			return nil, err
+		}
+		if found && failure.Height <= head.Height && head.Height-failure.Height < uint64(network.EpochsPerDay()) {
+			return nil, fmt.Errorf("%s (at height %d, retried a day later)", failure.Error, failure.Height)
+		}
+	}
+
+	lifetime, err := c.searchActorLifetime(ctx, addr, head, store)
+	if err != nil {
+		if store != nil && errors.Is(err, errStateUnavailable) {
+			if err := store.Put(failureKey, lifetimeFailure{Height: head.Height, Error: err.Error()}); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	lifetime.CreateTimestamp = network.EpochToTimestamp(int64(lifetime.CreateHeight))
+	lifetime.LastActiveTimestamp = network.EpochToTimestamp(int64(lifetime.LastActiveHeight))
+	return lifetime, nil
+}
+
+// searchActorLifetime searches the creation and last active epochs of an actor, reusing
+// the cached ones when store is not nil
+func (c *Client) searchActorLifetime(ctx context.Context, addr string, head *TipSet, store *cache.Store) (*ActorLifetime, error) {
+	current, err := c.probeActor(ctx, addr, []uint64{head.Height})
+	if err != nil {
+		return nil, err
+	}
+	if !current[0].Exists {
+		return nil, fmt.Errorf("actor %s not found at height %d", addr, head.Height)
+	}
+
+	lifetime := &ActorLifetime{Address: addr}
+
+	// Actor state at a tipset is its parent state, so the first height at which
+	// the actor exists is one past the epoch whose messages created it
+	key := strings.TrimLeft(addr, "ft")
+	cacheKey := "created-" + key
+	found := false
+	if store != nil {
+		if found, err = store.Get(cacheKey, &lifetime.CreateHeight); err != nil {
+			return nil, err
+		}
+	}
+	if !found {
+		first, err := c.findFirstHeight(ctx, addr, 0, head.Height, func(a actorAtHeight) bool {
+			return a.Exists
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find creation epoch: %w", err)
+		}
+		lifetime.CreateHeight = parentEpoch(first)
+		if store != nil {
+			if err := store.Put(cacheKey, lifetime.CreateHeight); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// The state head is unchanged from the epoch after the last state change up to the
+	// current head. A cached state head that differs was replaced after it was last seen.
+	activeKey := "active-" + key
+	var cached lastActive
+	found = false
+	if store != nil {
+		if found, err = store.Get(activeKey, &cached); err != nil {
+			return nil, err
+		}
+	}
+	if found && cached.Head == current[0].Head {
+		lifetime.LastActiveHeight = cached.Height
+	} else {
+		lo := lifetime.CreateHeight
+		if found && cached.SeenHeight > lo && cached.SeenHeight <= head.Height {
+			lo = cached.SeenHeight
+		}
+		last, err := c.findFirstHeight(ctx, addr, lo, head.Height, func(a actorAtHeight) bool {
+			return a.Exists && a.Head == current[0].Head
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find last active epoch: %w", err)
+		}
+		lifetime.LastActiveHeight = parentEpoch(last)
+	}
+	if store != nil {
+		entry := lastActive{Head: current[0].Head, Height: lifetime.LastActiveHeight, SeenHeight: head.Height}
+		if err := store.Put(activeKey, entry); err != nil {
+			return nil, err
+		}
+	}
+
+	return lifetime, nil
+}
+
+// findFirstHeight returns the smallest height in [lo, hi] at which pred holds, assuming
+// pred is false below that height and true from it on, and that pred holds at hi.
+// Each round probes searchFanout heights in a single batch.
+func (c *Client) findFirstHeight(ctx context.Context, addr string, lo, hi uint64, pred func(actorAtHeight) bool) (uint64, error) {
+	for lo < hi {
+		span := hi - lo
+		probes := make([]uint64, 0, searchFanout)
+		for i := uint64(0); i < searchFanout; i++ {
+			h := lo + i*span/searchFanout
+			if len(probes) == 0 || probes[len(probes)-1] != h {
+				probes = append(probes, h)
+			}
+		}
+
+		states, err := c.probeActor(ctx, addr, probes)
+		if err != nil {
+			return 0, err
+		}
+
+		next := hi
+		for i, state := range states {
+			if pred(state) {
+				next = probes[i]
+				break
+			}
+			lo = probes[i] + 1
+		}
+		hi = next
+	}
+	return hi, nil
+}
+
+// probeActor reads the actor at each of the given heights using two batch requests
+func (c *Client) probeActor(ctx context.Context, addr string, heights []uint64) ([]actorAtHeight, error) {
+	requests := make([]map[string]interface{}, 0, len(heights))
+	for i, h := range heights {
+		requests = append(requests, newRPCRequest(i, "Filecoin.ChainGetTipSetByHeight", h, nil))
+	}
+	responses, err := c.BatchCallWithRetry(ctx, requests)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tipsets: %w", err)
+	}
+
+	keys := make([][]map[string]string, len(heights))
+	for _, resp := range responses {
+		id, ok := resp["id"].(float64)
+		if !ok {
+			continue
+		}
+		if resp["error"] != nil {
+			return nil, fmt.Errorf("%w: failed to fetch tipset at height %d: %s", errStateUnavailable, heights[int(id)], rpcErrorMessage(resp["error"]))
+		}
+		var ts TipSet
+		if err := decodeResult(resp["result"], &ts); err != nil {
+			return nil, err
+		}
+		keys[int(id)] = ts.Cids
+	}
+
+	requests = requests[:0]
+	for i, key := range keys {
+		requests = append(requests, newRPCRequest(i, "Filecoin.StateGetActor", addr, key))
+	}
+	responses, err = c.BatchCallWithRetry(ctx, requests)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch actor: %w", err)
+	}
+
+	states := make([]actorAtHeight, len(heights))
+	for _, resp := range responses {
+		id, ok := resp["id"].(float64)
+		if !ok {
+			continue
+		}
+		if resp["error"] != nil {
+			msg := rpcErrorMessage(resp["error"])
+			if strings.Contains(msg, "actor not found") {
+				continue
+			}
+			// Anything else (e.g. pruned state on a splitstore node) makes the search unreliable
+			return nil, fmt.Errorf("%w: failed to read actor at height %d: %s", errStateUnavailable, heights[int(id)], msg)
+		}
+
+		var actor struct {
+			Head map[string]string `json:"Head"`
+		}
+		if err := decodeResult(resp["result"], &actor); err != nil {
+			return nil, err
+		}
+		states[int(id)] = actorAtHeight{Exists: true, Head: actor.Head["/"]}
+	}
+
+	return states, nil
+}
+
+// parentEpoch returns the epoch preceding height
+func parentEpoch(height uint64) uint64 {
+	if height == 0 {
+		return 0
+	}
+	return height - 1
+}
+
+// rpcErrorMessage extracts the message of a JSON-RPC error object
+func rpcErrorMessage(rpcErr interface{}) string {
+	if m, ok := rpcErr.(map[string]interface{}); ok {
+		if msg, ok := m["message"].(string); ok {
+			return msg
+		}
+	}
+	return fmt.Sprintf("%v", rpcErr)
+}
@@ -0,0 +1,221 @@
+package lotus
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	stbig "github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/go-state-types/builtin/v15/miner"
+	filaddr "github.com/THCloudAI/thctl/internal/address"
+)
+
+// cborMarshaler is implemented by the actor method parameter types
+type cborMarshaler interface {
+	MarshalCBOR(w io.Writer) error
+}
+
+// PrepareMessage sets the nonce of a message from the sender's next mpool nonce and
+// fills in its gas limit, fee cap and premium from GasEstimateMessageGas. An ID address
+// sender is replaced with its key address so the message can be signed offline.
+// Messages from the Build functions need this before they are signed; a message
+// meant to be proposed by a multisig is wrapped with BuildProposeMessage first.
+func (c *Client) PrepareMessage(ctx context.Context, msg *UnsignedMessage) error {
+	if isIDAddress(msg.From) {
+		var key string
+		if err := c.callRPCWithRetry(ctx, "Filecoin.StateAccountKey", []interface{}{msg.From, nil}, &key); err != nil {
+			return fmt.Errorf("failed to resolve sender %s to a key address: %w", msg.From, err)
+		}
+		msg.From = key
+	}
+
+	var nonce uint64
+	if err := c.callRPCWithRetry(ctx, "Filecoin.MpoolGetNonce", []interface{}{msg.From}, &nonce); err != nil {
+		return fmt.Errorf("failed to get nonce of %s: %w", msg.From, err)
+	}
+
+	var estimated UnsignedMessage
+	spec := map[string]interface{}{"MaxFee": "0"}
+	if err := c.callRPCWithRetry(ctx, "Filecoin.GasEstimateMessageGas", []interface{}{msg, spec, nil}, &estimated); err != nil {
+		return fmt.Errorf("failed to estimate gas: %w", err)
+	}
+
+	msg.Nonce = nonce
+	msg.GasLimit = estimated.GasLimit
+	msg.GasFeeCap = estimated.GasFeeCap
+	msg.GasPremium = estimated.GasPremium
+	return nil
+}
+
+// BuildWithdrawMessage builds a WithdrawBalance message. A nil amount withdraws the
+// whole available balance, and an empty from sends it from the owner. Only the owner
+// and the beneficiary may withdraw.
+func (c *Client) BuildWithdrawMessage(ctx context.Context, minerID string, amount *big.Int, from string) (*UnsignedMessage, error) {
+	info, err := c.getStateMinerInfo(ctx, minerID)
+	if err != nil {
+		return nil, err
+	}
+	if from == "" {
+		from = info.Owner
+	}
+
+	if amount == nil {
+		var available string
+		if err := c.callRPCWithRetry(ctx, "Filecoin.StateMinerAvailableBalance", []interface{}{minerID, nil}, &available); err != nil {
+			return nil, fmt.Errorf("failed to get available balance: %w", err)
+		}
+		amount = parseBigInt(available)
+	}
+	if amount.Sign() <= 0 {
+		return nil, fmt.Errorf("nothing to withdraw: amount must be positive")
+	}
+
+	params := &miner.WithdrawBalanceParams{AmountRequested: stbig.NewFromGo(amount)}
+	return newMinerMessage(minerID, from, builtin.MethodsMiner.WithdrawBalance, params)
+}
+
+// BuildChangeOwnerMessage builds a ChangeOwnerAddress message. The current owner proposes
+// the new owner, then the new owner confirms by sending the same message (confirm).
+func (c *Client) BuildChangeOwnerMessage(ctx context.Context, minerID, newOwner string, confirm bool) (*UnsignedMessage, error) {
+	owner, err := filaddr.Parse(newOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := c.getStateMinerInfo(ctx, minerID)
+	if err != nil {
+		return nil, err
+	}
+	from := info.Owner
+	if confirm {
+		if info.PendingOwnerAddress == nil {
+			return nil, fmt.Errorf("no owner change is pending for %s", minerID)
+		}
+		from = owner.String()
+	}
+
+	return newMinerMessage(minerID, from, builtin.MethodsMiner.ChangeOwnerAddress, &owner)
+}
+
+// BuildChangeWorkerMessage builds a ChangeWorkerAddress message from the owner proposing a
+// new worker while keeping the current control addresses
+func (c *Client) BuildChangeWorkerMessage(ctx context.Context, minerID, newWorker string) (*UnsignedMessage, error) {
+	info, err := c.getStateMinerInfo(ctx, minerID)
+	if err != nil {
+		return nil, err
+	}
+	return c.buildChangeWorkerMessage(ctx, minerID, info, newWorker, info.ControlAddresses)
+}
+
+// BuildConfirmWorkerMessage builds a ConfirmChangeWorkerAddress message from the owner,
+// which takes effect once the pending worker change epoch has been reached
+func (c *Client) BuildConfirmWorkerMessage(ctx context.Context, minerID string) (*UnsignedMessage, error) {
+	info, err := c.getStateMinerInfo(ctx, minerID)
+	if err != nil {
+		return nil, err
+	}
+	if !info.hasPendingWorker() {
+		return nil, fmt.Errorf("no worker change is pending for %s", minerID)
+	}
+
+	head, err := c.GetChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if int64(head.Height) < info.WorkerChangeEpoch {
+		return nil, fmt.Errorf("worker change to %s can be confirmed at epoch %d (current epoch %d)",
+			info.NewWorker, info.WorkerChangeEpoch, head.Height)
+	}
+
+	return newMinerMessage(minerID, info.Owner, builtin.MethodsMiner.ConfirmChangeWorkerAddress, nil)
+}
+
+// BuildChangeControlMessage builds a ChangeWorkerAddress message from the owner replacing
+// the control addresses while keeping the current worker
+func (c *Client) BuildChangeControlMessage(ctx context.Context, minerID string, controls []string) (*UnsignedMessage, error) {
+	info, err := c.getStateMinerInfo(ctx, minerID)
+	if err != nil {
+		return nil, err
+	}
+	return c.buildChangeWorkerMessage(ctx, minerID, info, info.Worker, controls)
+}
+
+// buildChangeWorkerMessage encodes a ChangeWorkerAddress message sent by the owner
+func (c *Client) buildChangeWorkerMessage(ctx context.Context, minerID string, info *stateMinerInfo, worker string, controls []string) (*UnsignedMessage, error) {
+	params := &miner.ChangeWorkerAddressParams{NewControlAddrs: make([]address.Address, 0, len(controls))}
+
+	var err error
+	if params.NewWorker, err = filaddr.Parse(worker); err != nil {
+		return nil, err
+	}
+	for _, control := range controls {
+		addr, err := filaddr.Parse(control)
+		if err != nil {
+			return nil, err
+		}
+		params.NewControlAddrs = append(params.NewControlAddrs, addr)
+	}
+
+	return newMinerMessage(minerID, info.Owner, builtin.MethodsMiner.ChangeWorkerAddress, params)
+}
+
+// BuildChangeBeneficiaryMessage builds a ChangeBeneficiary message. The owner proposes the
+// new beneficiary term, then the new beneficiary confirms by sending the same message (confirm).
+func (c *Client) BuildChangeBeneficiaryMessage(ctx context.Context, minerID, beneficiary string, quota *big.Int, expiration int64, confirm bool) (*UnsignedMessage, error) {
+	addr, err := filaddr.Parse(beneficiary)
+	if err != nil {
+		return nil, err
+	}
+	if quota == nil || quota.Sign() < 0 {
+		return nil, fmt.Errorf("beneficiary quota must not be negative")
+	}
+
+	info, err := c.getStateMinerInfo(ctx, minerID)
+	if err != nil {
+		return nil, err
+	}
+	from := info.Owner
+	if confirm {
+		from = addr.String()
+	}
+
+	params := &miner.ChangeBeneficiaryParams{
+		NewBeneficiary: addr,
+		NewQuota:       stbig.NewFromGo(quota),
+		NewExpiration:  abi.ChainEpoch(expiration),
+	}
+	return newMinerMessage(minerID, from, builtin.MethodsMiner.ChangeBeneficiary, params)
+}
+
+// MpoolPush pushes a signed message to the message pool and returns its CID
+func (c *Client) MpoolPush(ctx context.Context, signed interface{}) (string, error) {
+	var result map[string]string
+	if err := c.callRPCWithRetry(ctx, "Filecoin.MpoolPush", []interface{}{signed}, &result); err != nil {
+		return "", fmt.Errorf("failed to push message: %w", err)
+	}
+	return result["/"], nil
+}
+
+// newMinerMessage encodes the params of a zero value message to a miner. The message
+// still needs PrepareMessage before it can be signed.
+func newMinerMessage(minerID, from string, method abi.MethodNum, params cborMarshaler) (*UnsignedMessage, error) {
+	msg := &UnsignedMessage{
+		To:     minerID,
+		From:   from,
+		Value:  "0",
+		Method: uint64(method),
+	}
+	if params != nil {
+		var buf bytes.Buffer
+		if err := params.MarshalCBOR(&buf); err != nil {
+			return nil, fmt.Errorf("failed to encode params: %w", err)
+		}
+		msg.Params = buf.Bytes()
+	}
+	return msg, nil
+}
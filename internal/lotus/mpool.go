@@ -0,0 +1,347 @@
+package lotus
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/THCloudAI/thctl/internal/cache"
+	"github.com/THCloudAI/thctl/internal/units"
+)
+
+const (
+	// stuckAfterEpochs is how long a message may stay in the pool before it is reported as stuck
+	stuckAfterEpochs = 20
+	// rbfRatioPercent is the premium increase of a replacement, the default of 'lotus mpool replace'
+	rbfRatioPercent = 125
+)
+
+// inclusionTargets are the numbers of epochs gas premiums are estimated for
+var inclusionTargets = []uint64{1, 5, 20}
+
+// Inclusion estimates reported for pending messages
+const (
+	InclusionNextEpoch = "next epoch"
+	InclusionBlocked   = "blocked by nonce gap"
+	InclusionBehind    = "behind a stuck message"
+	InclusionFeeCap    = "fee cap below base fee"
+	InclusionUnlikely  = "unlikely without replacement"
+	InclusionIncluded  = "already included"
+)
+
+// minerMethodNames names the miner methods workers and control addresses send
+var minerMethodNames = map[abi.MethodNum]string{
+	builtin.MethodsMiner.SubmitWindowedPoSt:      "SubmitWindowedPoSt",
+	builtin.MethodsMiner.PreCommitSector:         "PreCommitSector",
+	builtin.MethodsMiner.PreCommitSectorBatch2:   "PreCommitSectorBatch2",
+	builtin.MethodsMiner.ProveCommitSector:       "ProveCommitSector",
+	builtin.MethodsMiner.ProveCommitAggregate:    "ProveCommitAggregate",
+	builtin.MethodsMiner.ProveCommitSectors3:     "ProveCommitSectors3",
+	builtin.MethodsMiner.ProveReplicaUpdates3:    "ProveReplicaUpdates3",
+	builtin.MethodsMiner.DeclareFaults:           "DeclareFaults",
+	builtin.MethodsMiner.DeclareFaultsRecovered:  "DeclareFaultsRecovered",
+	builtin.MethodsMiner.ExtendSectorExpiration2: "ExtendSectorExpiration2",
+	builtin.MethodsMiner.TerminateSectors:        "TerminateSectors",
+	builtin.MethodsMiner.CompactPartitions:       "CompactPartitions",
+	builtin.MethodsMiner.WithdrawBalance:         "WithdrawBalance",
+}
+
+// signedMessage is a single entry of MpoolPending
+type signedMessage struct {
+	Message UnsignedMessage   `json:"Message"`
+	CID     map[string]string `json:"CID"`
+}
+
+// actorNonce is the subset of StateGetActor needed to find the next on-chain nonce
+type actorNonce struct {
+	Nonce uint64 `json:"Nonce"`
+}
+
+// GetMpoolStatus lists the pending messages sent by the worker and control addresses of a
+// miner, with the nonce gaps that block them and an estimate of when each gets included.
+// The first time a message was seen is kept in store, when it is not nil, to report its age.
+func (c *Client) GetMpoolStatus(ctx context.Context, minerID string, store *cache.Store) (*MpoolStatus, error) {
+	info, err := c.getStateMinerInfo(ctx, minerID)
+	if err != nil {
+		return nil, err
+	}
+	network, err := c.Network(ctx)
+	if err != nil {
+		return nil, err
+	}
+	head, err := c.GetChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MpoolStatus{
+		MinerID: minerID,
+		Height:  head.Height,
+		BaseFee: units.FILFromAtto(head.baseFee()),
+		Senders: make([]MpoolSender, 0, len(info.ControlAddresses)+1),
+	}
+	status.Senders = append(status.Senders, MpoolSender{Role: RoleWorker, Address: info.Worker})
+	for i, control := range info.ControlAddresses {
+		status.Senders = append(status.Senders, MpoolSender{Role: fmt.Sprintf("control %d", i+1), Address: control})
+	}
+
+	// One batch for the pool, the premium estimates and the key address and nonce of each sender
+	const senderBase = 1
+	premiumBase := senderBase + 2*len(status.Senders)
+	requests := []map[string]interface{}{newRPCRequest(0, "Filecoin.MpoolPending", nil)}
+	for i, sender := range status.Senders {
+		requests = append(requests,
+			newRPCRequest(senderBase+2*i, "Filecoin.StateAccountKey", sender.Address, nil),
+			newRPCRequest(senderBase+2*i+1, "Filecoin.StateGetActor", sender.Address, nil),
+		)
+	}
+	for i, target := range inclusionTargets {
+		requests = append(requests, newRPCRequest(premiumBase+i, "Filecoin.GasEstimateGasPremium", target, info.Worker, 0, nil))
+	}
+	responses, err := c.BatchCallWithRetry(ctx, requests)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []signedMessage
+	premiums := make([]*big.Int, len(inclusionTargets))
+	for _, resp := range responses {
+		id, ok := resp["id"].(float64)
+		if !ok {
+			continue
+		}
+		n := int(id)
+		if resp["error"] != nil {
+			if n == 0 {
+				return nil, fmt.Errorf("failed to get pending messages: %s", rpcErrorMessage(resp["error"]))
+			}
+			continue
+		}
+		switch {
+		case n == 0:
+			if err := decodeResult(resp["result"], &pending); err != nil {
+				return nil, fmt.Errorf("failed to decode pending messages: %w", err)
+			}
+		case n >= premiumBase:
+			var premium string
+			if err := decodeResult(resp["result"], &premium); err == nil {
+				premiums[n-premiumBase] = parseBigInt(premium)
+			}
+		case (n-senderBase)%2 == 0:
+			var key string
+			if err := decodeResult(resp["result"], &key); err == nil {
+				status.Senders[(n-senderBase)/2].KeyAddress = key
+			}
+		default:
+			var actor actorNonce
+			if err := decodeResult(resp["result"], &actor); err == nil {
+				status.Senders[(n-senderBase)/2].OnChainNonce = actor.Nonce
+			}
+		}
+	}
+	for i, premium := range premiums {
+		if premium != nil {
+			status.PremiumEstimates = append(status.PremiumEstimates, PremiumEstimate{Epochs: inclusionTargets[i], Premium: units.NewFIL(premium)})
+		}
+	}
+
+	firstSeen := make(map[string]int64)
+	seenKey := "pending-" + minerID
+	if store != nil {
+		if _, err := store.Get(seenKey, &firstSeen); err != nil {
+			return nil, err
+		}
+	}
+	now := time.Now().Unix()
+	stillPending := make(map[string]int64)
+
+	for i := range status.Senders {
+		sender := &status.Senders[i]
+		sender.Messages = make([]PendingMessage, 0)
+		for _, signed := range pending {
+			msg := signed.Message
+			if !sameAddress(msg.From, sender.Address) && !sameAddress(msg.From, sender.KeyAddress) {
+				continue
+			}
+			cid := signed.CID["/"]
+			seen, ok := firstSeen[cid]
+			if !ok {
+				seen = now
+			}
+			stillPending[cid] = seen
+
+			pm := PendingMessage{
+				Cid:        cid,
+				Nonce:      msg.Nonce,
+				To:         msg.To,
+				Method:     msg.Method,
+				MethodName: methodName(msg),
+				Value:      units.FILFromAtto(msg.Value),
+				GasLimit:   msg.GasLimit,
+				GasFeeCap:  units.FILFromAtto(msg.GasFeeCap),
+				GasPremium: units.FILFromAtto(msg.GasPremium),
+			}
+			if store != nil {
+				pm.FirstSeen = seen
+				pm.AgeSeconds = now - seen
+			}
+			sender.Messages = append(sender.Messages, pm)
+		}
+		sort.Slice(sender.Messages, func(a, b int) bool { return sender.Messages[a].Nonce < sender.Messages[b].Nonce })
+		status.estimateInclusion(sender, premiums, stuckAfterEpochs*network.BlockDelaySecs)
+		status.StuckCount += sender.stuckCount()
+	}
+
+	if store != nil {
+		if err := store.Put(seenKey, stillPending); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// estimateInclusion finds the nonce gaps of a sender and estimates when each of its
+// messages gets included. A message can only be included after all lower nonces, so
+// a gap or a stuck message holds up every message after it.
+func (s *MpoolStatus) estimateInclusion(sender *MpoolSender, premiums []*big.Int, stuckAfter int64) {
+	baseFee := s.BaseFee.Atto()
+	expected := sender.OnChainNonce
+	held := ""
+	slowest := 0
+
+	for i := range sender.Messages {
+		msg := &sender.Messages[i]
+		if msg.Nonce < sender.OnChainNonce {
+			msg.Inclusion = InclusionIncluded
+			continue
+		}
+		for missing := expected; missing < msg.Nonce; missing++ {
+			sender.NonceGaps = append(sender.NonceGaps, missing)
+			held = InclusionBlocked
+		}
+		expected = msg.Nonce + 1
+
+		switch {
+		case held != "":
+			msg.Inclusion = held
+			msg.Stuck = true
+		case msg.GasFeeCap.Atto().Cmp(baseFee) < 0:
+			msg.Inclusion = InclusionFeeCap
+			msg.Stuck = true
+		default:
+			msg.Inclusion = InclusionUnlikely
+			for j := slowest; j < len(premiums); j++ {
+				if premiums[j] != nil && msg.GasPremium.Atto().Cmp(premiums[j]) >= 0 {
+					msg.Inclusion = inclusionWithin(inclusionTargets[j])
+					slowest = j
+					break
+				}
+			}
+			msg.Stuck = msg.Inclusion == InclusionUnlikely || (msg.FirstSeen != 0 && msg.AgeSeconds > stuckAfter)
+		}
+		if msg.Stuck && held == "" {
+			held = InclusionBehind
+		}
+	}
+}
+
+// stuckCount returns the number of messages of the sender reported as stuck
+func (s *MpoolSender) stuckCount() int {
+	count := 0
+	for _, msg := range s.Messages {
+		if msg.Stuck {
+			count++
+		}
+	}
+	return count
+}
+
+// inclusionWithin describes a premium estimated to get a message included within epochs
+func inclusionWithin(epochs uint64) string {
+	if epochs == 1 {
+		return InclusionNextEpoch
+	}
+	return fmt.Sprintf("within ~%d epochs", epochs)
+}
+
+// methodName names plain sends and the miner methods sent by workers and control addresses
+func methodName(msg UnsignedMessage) string {
+	if msg.Method == uint64(builtin.MethodSend) {
+		return "Send"
+	}
+	if name, ok := minerMethodNames[abi.MethodNum(msg.Method)]; ok {
+		return name
+	}
+	return fmt.Sprintf("Method %d", msg.Method)
+}
+
+// BuildReplaceMessage builds a replacement for the pending message of from with the given
+// nonce, raising its gas premium by at least 25% so the pool accepts it. A nil premium uses
+// the higher of that minimum and the premium estimated for inclusion in the next epochs; a
+// nil fee cap keeps the old one unless the new premium or twice the base fee needs more.
+// The nonce and gas limit are kept, so the message must not be passed to PrepareMessage.
+func (c *Client) BuildReplaceMessage(ctx context.Context, from string, nonce uint64, premium, feeCap *big.Int) (*UnsignedMessage, error) {
+	key := from
+	if isIDAddress(from) {
+		if err := c.callRPCWithRetry(ctx, "Filecoin.StateAccountKey", []interface{}{from, nil}, &key); err != nil {
+			return nil, fmt.Errorf("failed to resolve %s to a key address: %w", from, err)
+		}
+	}
+
+	var pending []signedMessage
+	if err := c.callRPCWithRetry(ctx, "Filecoin.MpoolPending", []interface{}{nil}, &pending); err != nil {
+		return nil, fmt.Errorf("failed to get pending messages: %w", err)
+	}
+	var old *UnsignedMessage
+	for i := range pending {
+		if sameAddress(pending[i].Message.From, key) && pending[i].Message.Nonce == nonce {
+			old = &pending[i].Message
+			break
+		}
+	}
+	if old == nil {
+		return nil, fmt.Errorf("no pending message from %s with nonce %d", from, nonce)
+	}
+
+	oldPremium := parseBigInt(old.GasPremium)
+	minPremium := new(big.Int).Mul(oldPremium, big.NewInt(rbfRatioPercent))
+	minPremium.Div(minPremium, big.NewInt(100)).Add(minPremium, big.NewInt(1))
+
+	if premium == nil {
+		premium = minPremium
+		var estimate string
+		if err := c.callRPCWithRetry(ctx, "Filecoin.GasEstimateGasPremium", []interface{}{inclusionTargets[1], key, old.GasLimit, nil}, &estimate); err != nil {
+			return nil, fmt.Errorf("failed to estimate gas premium: %w", err)
+		}
+		if estimated := parseBigInt(estimate); estimated.Cmp(premium) > 0 {
+			premium = estimated
+		}
+	} else if premium.Cmp(minPremium) < 0 {
+		return nil, fmt.Errorf("gas premium %s is below the minimum replacement premium %s",
+			units.NewFIL(premium), units.NewFIL(minPremium))
+	}
+
+	if feeCap == nil {
+		head, err := c.GetChainHead(ctx)
+		if err != nil {
+			return nil, err
+		}
+		feeCap = parseBigInt(old.GasFeeCap)
+		needed := new(big.Int).Mul(parseBigInt(head.baseFee()), big.NewInt(2))
+		needed.Add(needed, premium)
+		if needed.Cmp(feeCap) > 0 {
+			feeCap = needed
+		}
+	} else if feeCap.Cmp(premium) < 0 {
+		return nil, fmt.Errorf("fee cap %s is below the gas premium %s", units.NewFIL(feeCap), units.NewFIL(premium))
+	}
+
+	replacement := *old
+	replacement.GasPremium = premium.String()
+	replacement.GasFeeCap = feeCap.String()
+	return &replacement, nil
+}
@@ -0,0 +1,383 @@
+package lotus
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	stbig "github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/go-state-types/builtin/v15/miner"
+	"github.com/filecoin-project/go-state-types/builtin/v15/multisig"
+	"golang.org/x/crypto/blake2b"
+
+	filaddr "github.com/THCloudAI/thctl/internal/address"
+	"github.com/THCloudAI/thctl/internal/units"
+)
+
+// vestingSteps is the number of points listed in a multisig vesting schedule
+const vestingSteps = 10
+
+// pendingTransaction is a single entry of MsigGetPending
+type pendingTransaction struct {
+	ID       int64    `json:"ID"`
+	To       string   `json:"To"`
+	Value    string   `json:"Value"`
+	Method   uint64   `json:"Method"`
+	Params   []byte   `json:"Params"`
+	Approved []string `json:"Approved"`
+}
+
+// GetMultisig reads a multisig wallet: its signers and threshold, its balance and how
+// much of it is still locked over time, and its pending transactions with their
+// parameters decoded for the methods thctl knows.
+func (c *Client) GetMultisig(ctx context.Context, addr string) (*MultisigInfo, error) {
+	actor, err := c.GetActorInfo(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	if actor.Multisig == nil {
+		return nil, fmt.Errorf("%s is a %s actor, not a multisig", addr, actor.Type)
+	}
+
+	network, err := c.Network(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &MultisigInfo{
+		Address:       actor.Address,
+		ID:            actor.ID,
+		Robust:        actor.Robust,
+		Height:        actor.Height,
+		Balance:       actor.Balance,
+		MultisigState: *actor.Multisig,
+		Pending:       make([]MultisigTransaction, 0),
+	}
+
+	// List the locked balance at evenly spaced epochs until the end of vesting
+	st := actor.Multisig
+	if st.UnlockDuration > 0 && st.LockedBalance.Sign() > 0 {
+		end := st.StartEpoch + st.UnlockDuration
+		info.UnlockEpoch = end
+		info.UnlockTimestamp = network.EpochToTimestamp(end)
+
+		from := int64(actor.Height)
+		if from < st.StartEpoch {
+			from = st.StartEpoch
+		}
+		for i := 1; i <= vestingSteps; i++ {
+			epoch := from + (end-from)*int64(i)/vestingSteps
+			info.Vesting = append(info.Vesting, VestingStep{
+				Epoch:     epoch,
+				Timestamp: network.EpochToTimestamp(epoch),
+				Locked:    units.NewFIL(multisigLocked(st.InitialBalance.Atto(), st.StartEpoch, st.UnlockDuration, epoch)),
+			})
+		}
+	}
+
+	pending, err := c.getPendingTransactions(ctx, info.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, txn := range pending {
+		info.Pending = append(info.Pending, c.describeTransaction(ctx, info.ID, txn))
+	}
+
+	return info, nil
+}
+
+// getPendingTransactions lists the transactions of a multisig awaiting approval
+func (c *Client) getPendingTransactions(ctx context.Context, msig string) ([]pendingTransaction, error) {
+	var result []pendingTransaction
+	if err := c.callRPCWithRetry(ctx, "Filecoin.MsigGetPending", []interface{}{msig, nil}, &result); err != nil {
+		return nil, fmt.Errorf("failed to get pending transactions of %s: %w", msig, err)
+	}
+	return result, nil
+}
+
+// describeTransaction names the method of a pending transaction and decodes its
+// parameters for the miner and multisig methods used to operate a miner
+func (c *Client) describeTransaction(ctx context.Context, msig string, txn pendingTransaction) MultisigTransaction {
+	result := MultisigTransaction{
+		ID:       txn.ID,
+		To:       txn.To,
+		Value:    units.FILFromAtto(txn.Value),
+		Method:   txn.Method,
+		Params:   txn.Params,
+		Approved: txn.Approved,
+	}
+	if len(txn.Approved) > 0 {
+		result.Proposer = txn.Approved[0]
+	}
+
+	if txn.Method == uint64(builtin.MethodSend) {
+		result.MethodName = "Send"
+		result.Description = fmt.Sprintf("send %s to %s", result.Value, txn.To)
+		return result
+	}
+
+	// Method numbers are only meaningful for a given actor type
+	actorType := ActorMultisig
+	if !sameAddress(txn.To, msig) {
+		var err error
+		if actorType, err = c.GetActorType(ctx, txn.To); err != nil {
+			return result
+		}
+	}
+
+	switch actorType {
+	case ActorMiner:
+		result.MethodName, result.Description = describeMinerCall(abi.MethodNum(txn.Method), txn.Params)
+	case ActorMultisig:
+		result.MethodName, result.Description = describeMultisigCall(abi.MethodNum(txn.Method), txn.Params)
+	}
+	return result
+}
+
+// describeMinerCall decodes the params of the miner methods thctl builds messages for
+func describeMinerCall(method abi.MethodNum, params []byte) (string, string) {
+	r := bytes.NewReader(params)
+	switch method {
+	case builtin.MethodsMiner.WithdrawBalance:
+		var p miner.WithdrawBalanceParams
+		if err := p.UnmarshalCBOR(r); err != nil {
+			return "WithdrawBalance", ""
+		}
+		return "WithdrawBalance", fmt.Sprintf("withdraw %s", units.NewFIL(p.AmountRequested.Int))
+	case builtin.MethodsMiner.ChangeOwnerAddress:
+		var p address.Address
+		if err := p.UnmarshalCBOR(r); err != nil {
+			return "ChangeOwnerAddress", ""
+		}
+		return "ChangeOwnerAddress", fmt.Sprintf("change owner to %s", p)
+	case builtin.MethodsMiner.ChangeWorkerAddress:
+		var p miner.ChangeWorkerAddressParams
+		if err := p.UnmarshalCBOR(r); err != nil {
+			return "ChangeWorkerAddress", ""
+		}
+		return "ChangeWorkerAddress", fmt.Sprintf("change worker to %s with %d control addresses", p.NewWorker, len(p.NewControlAddrs))
+	case builtin.MethodsMiner.ConfirmChangeWorkerAddress:
+		return "ConfirmChangeWorkerAddress", "confirm the pending worker change"
+	case builtin.MethodsMiner.ChangeBeneficiary:
+		var p miner.ChangeBeneficiaryParams
+		if err := p.UnmarshalCBOR(r); err != nil {
+			return "ChangeBeneficiary", ""
+		}
+		return "ChangeBeneficiary", fmt.Sprintf("change beneficiary to %s with a %s quota until epoch %d",
+			p.NewBeneficiary, units.NewFIL(p.NewQuota.Int), p.NewExpiration)
+	}
+	return fmt.Sprintf("Method %d", method), ""
+}
+
+// describeMultisigCall decodes the params of the signer management methods a multisig calls on itself
+func describeMultisigCall(method abi.MethodNum, params []byte) (string, string) {
+	r := bytes.NewReader(params)
+	switch method {
+	case builtin.MethodsMultisig.AddSigner:
+		var p multisig.AddSignerParams
+		if err := p.UnmarshalCBOR(r); err != nil {
+			return "AddSigner", ""
+		}
+		return "AddSigner", fmt.Sprintf("add signer %s", p.Signer)
+	case builtin.MethodsMultisig.RemoveSigner:
+		var p multisig.RemoveSignerParams
+		if err := p.UnmarshalCBOR(r); err != nil {
+			return "RemoveSigner", ""
+		}
+		return "RemoveSigner", fmt.Sprintf("remove signer %s", p.Signer)
+	case builtin.MethodsMultisig.SwapSigner:
+		var p multisig.SwapSignerParams
+		if err := p.UnmarshalCBOR(r); err != nil {
+			return "SwapSigner", ""
+		}
+		return "SwapSigner", fmt.Sprintf("swap signer %s for %s", p.From, p.To)
+	case builtin.MethodsMultisig.ChangeNumApprovalsThreshold:
+		var p multisig.ChangeNumApprovalsThresholdParams
+		if err := p.UnmarshalCBOR(r); err != nil {
+			return "ChangeNumApprovalsThreshold", ""
+		}
+		return "ChangeNumApprovalsThreshold", fmt.Sprintf("change threshold to %d", p.NewThreshold)
+	}
+	return fmt.Sprintf("Method %d", method), ""
+}
+
+// BuildSendMessage builds a plain transfer of amount attoFIL, to be proposed by a multisig
+func (c *Client) BuildSendMessage(ctx context.Context, from, to string, amount *big.Int) (*UnsignedMessage, error) {
+	toAddr, err := filaddr.Parse(to)
+	if err != nil {
+		return nil, err
+	}
+	if amount == nil || amount.Sign() <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+	return &UnsignedMessage{
+		To:     toAddr.String(),
+		From:   from,
+		Value:  amount.String(),
+		Method: uint64(builtin.MethodSend),
+	}, nil
+}
+
+// BuildProposeMessage wraps inner, a message the multisig msig has to send, in a Propose
+// message from signer. The proposal counts as the signer's approval, so with a threshold
+// of one the inner message is executed right away.
+func (c *Client) BuildProposeMessage(ctx context.Context, msig, signer string, inner *UnsignedMessage) (*UnsignedMessage, error) {
+	msigID, signerID, err := c.checkSigner(ctx, msig, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	// The inner message must be one the multisig is allowed to send
+	if inner.From != "" {
+		fromID, err := c.LookupID(ctx, inner.From)
+		if err != nil {
+			return nil, err
+		}
+		if fromID != msigID {
+			return nil, fmt.Errorf("the message has to be sent by %s, not by multisig %s", inner.From, msig)
+		}
+	}
+
+	to, err := filaddr.Parse(inner.To)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := new(big.Int).SetString(inner.Value, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid message value: %q", inner.Value)
+	}
+
+	params := &multisig.ProposeParams{
+		To:     to,
+		Value:  stbig.NewFromGo(value),
+		Method: abi.MethodNum(inner.Method),
+		Params: inner.Params,
+	}
+	return newMultisigMessage(msigID, signerID, builtin.MethodsMultisig.Propose, params)
+}
+
+// BuildApproveMessage builds an Approve message from signer for a pending transaction.
+// The proposal hash is included so the approval cannot apply to a different transaction.
+func (c *Client) BuildApproveMessage(ctx context.Context, msig, signer string, txnID int64) (*UnsignedMessage, error) {
+	return c.buildTxnMessage(ctx, msig, signer, txnID, false)
+}
+
+// BuildCancelMessage builds a Cancel message for a pending transaction, which only its proposer may send
+func (c *Client) BuildCancelMessage(ctx context.Context, msig, signer string, txnID int64) (*UnsignedMessage, error) {
+	return c.buildTxnMessage(ctx, msig, signer, txnID, true)
+}
+
+// buildTxnMessage builds an Approve or Cancel message for a pending transaction
+func (c *Client) buildTxnMessage(ctx context.Context, msig, signer string, txnID int64, cancel bool) (*UnsignedMessage, error) {
+	msigID, signerID, err := c.checkSigner(ctx, msig, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := c.getPendingTransactions(ctx, msigID)
+	if err != nil {
+		return nil, err
+	}
+	var txn *pendingTransaction
+	for i := range pending {
+		if pending[i].ID == txnID {
+			txn = &pending[i]
+			break
+		}
+	}
+	if txn == nil {
+		return nil, fmt.Errorf("transaction %d is not pending on %s", txnID, msig)
+	}
+	if len(txn.Approved) == 0 {
+		return nil, fmt.Errorf("transaction %d has no proposer", txnID)
+	}
+
+	method := builtin.MethodsMultisig.Approve
+	if cancel {
+		method = builtin.MethodsMultisig.Cancel
+		if !sameAddress(txn.Approved[0], signerID) {
+			return nil, fmt.Errorf("only the proposer %s can cancel transaction %d", txn.Approved[0], txnID)
+		}
+	} else {
+		for _, approver := range txn.Approved {
+			if sameAddress(approver, signerID) {
+				return nil, fmt.Errorf("%s has already approved transaction %d", signer, txnID)
+			}
+		}
+	}
+
+	hash, err := proposalHash(txn)
+	if err != nil {
+		return nil, err
+	}
+	params := &multisig.TxnIDParams{ID: multisig.TxnID(txnID), ProposalHash: hash}
+	return newMultisigMessage(msigID, signerID, method, params)
+}
+
+// checkSigner resolves a multisig and a signer to their ID addresses and checks that
+// the signer is one of the multisig's signers
+func (c *Client) checkSigner(ctx context.Context, msig, signer string) (string, string, error) {
+	actor, err := c.GetActorInfo(ctx, msig)
+	if err != nil {
+		return "", "", err
+	}
+	if actor.Multisig == nil {
+		return "", "", fmt.Errorf("%s is a %s actor, not a multisig", msig, actor.Type)
+	}
+
+	signerID, err := c.LookupID(ctx, signer)
+	if err != nil {
+		return "", "", err
+	}
+	for _, s := range actor.Multisig.Signers {
+		if sameAddress(s, signerID) {
+			return actor.ID, signerID, nil
+		}
+	}
+	return "", "", fmt.Errorf("%s is not a signer of %s", signer, msig)
+}
+
+// proposalHash computes the hash a multisig checks approvals and cancellations against
+func proposalHash(txn *pendingTransaction) ([]byte, error) {
+	requester, err := filaddr.Parse(txn.Approved[0])
+	if err != nil {
+		return nil, err
+	}
+	to, err := filaddr.Parse(txn.To)
+	if err != nil {
+		return nil, err
+	}
+	data := &multisig.ProposalHashData{
+		Requester: requester,
+		To:        to,
+		Value:     stbig.NewFromGo(parseBigInt(txn.Value)),
+		Method:    abi.MethodNum(txn.Method),
+		Params:    txn.Params,
+	}
+	serialized, err := data.Serialize()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode proposal: %w", err)
+	}
+	hash := blake2b.Sum256(serialized)
+	return hash[:], nil
+}
+
+// newMultisigMessage encodes a zero value message from a signer to a multisig. The
+// message still needs PrepareMessage before it can be signed.
+func newMultisigMessage(msig, signer string, method abi.MethodNum, params cborMarshaler) (*UnsignedMessage, error) {
+	var buf bytes.Buffer
+	if err := params.MarshalCBOR(&buf); err != nil {
+		return nil, fmt.Errorf("failed to encode params: %w", err)
+	}
+	return &UnsignedMessage{
+		To:     msig,
+		From:   signer,
+		Value:  "0",
+		Method: uint64(method),
+		Params: buf.Bytes(),
+	}, nil
+}
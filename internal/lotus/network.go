@@ -0,0 +1,212 @@
+package lotus
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/THCloudAI/thctl/internal/cache"
+	"github.com/THCloudAI/thctl/internal/units"
+)
+
+// Network names reported by StateNetworkName for the public networks
+const (
+	NetworkMainnet     = "mainnet"
+	NetworkCalibration = "calibrationnet"
+	NetworkButterfly   = "butterflynet"
+)
+
+const (
+	// defaultBlockDelaySecs is the epoch duration of mainnet and the public testnets,
+	// used when the node does not report its network parameters
+	defaultBlockDelaySecs = 30
+	// networkDetectTimeout bounds the calls made to detect the network on connect
+	networkDetectTimeout = 10 * time.Second
+)
+
+// Network describes the network followed by the Lotus node and its constants
+type Network struct {
+	Name             string `json:"name"`
+	Version          uint64 `json:"version"`
+	Testnet          bool   `json:"testnet"`
+	AddressPrefix    string `json:"addressPrefix"`
+	GenesisTimestamp int64  `json:"genesisTimestamp"`
+	BlockDelaySecs   int64  `json:"blockDelaySecs"`
+}
+
+// networkParams is the subset of StateGetNetworkParams used by thctl
+type networkParams struct {
+	BlockDelaySecs int64 `json:"BlockDelaySecs"`
+}
+
+// EpochsPerDay returns the number of epochs in a day
+func (n *Network) EpochsPerDay() int64 {
+	return 24 * 60 * 60 / n.BlockDelaySecs
+}
+
+// EpochToTimestamp converts an epoch to a unix timestamp
+func (n *Network) EpochToTimestamp(epoch int64) int64 {
+	return n.GenesisTimestamp + epoch*n.BlockDelaySecs
+}
+
+// TimeToEpoch returns the first epoch at or after t
+func (n *Network) TimeToEpoch(t time.Time) int64 {
+	return (t.Unix() - n.GenesisTimestamp + n.BlockDelaySecs - 1) / n.BlockDelaySecs
+}
+
+// Network returns the network followed by the node, detecting it on first use. A failed
+// detection is not kept, so the next call tries again.
+func (c *Client) Network(ctx context.Context) (*Network, error) {
+	c.networkMu.Lock()
+	defer c.networkMu.Unlock()
+
+	if c.network != nil {
+		return c.network, nil
+	}
+	network, err := c.detectNetwork(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.network = network
+	applyNetwork(network)
+	c.blockDelay.Store(network.BlockDelaySecs)
+	return network, nil
+}
+
+// detectNetwork asks the node for its network name, version, genesis and epoch duration
+// in a single batch. It calls BatchCall directly so it is not itself subject to detection.
+func (c *Client) detectNetwork(ctx context.Context) (*Network, error) {
+	ctx, cancel := context.WithTimeout(ctx, networkDetectTimeout)
+	defer cancel()
+
+	const (
+		callName = iota
+		callVersion
+		callGenesis
+		callParams
+	)
+	responses, err := c.BatchCall(ctx, []map[string]interface{}{
+		newRPCRequest(callName, "Filecoin.StateNetworkName"),
+		newRPCRequest(callVersion, "Filecoin.StateNetworkVersion", nil),
+		newRPCRequest(callGenesis, "Filecoin.ChainGetGenesis"),
+		newRPCRequest(callParams, "Filecoin.StateGetNetworkParams"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect network: %w", err)
+	}
+
+	network := &Network{BlockDelaySecs: defaultBlockDelaySecs}
+	var genesis TipSet
+	var params networkParams
+	for _, resp := range responses {
+		id, ok := resp["id"].(float64)
+		if !ok {
+			continue
+		}
+		if resp["error"] != nil {
+			// Older nodes lack StateGetNetworkParams; the default epoch duration applies
+			if int(id) == callParams {
+				continue
+			}
+			return nil, fmt.Errorf("failed to detect network: %s", rpcErrorMessage(resp["error"]))
+		}
+
+		switch int(id) {
+		case callName:
+			err = decodeResult(resp["result"], &network.Name)
+		case callVersion:
+			err = decodeResult(resp["result"], &network.Version)
+		case callGenesis:
+			err = decodeResult(resp["result"], &genesis)
+		case callParams:
+			err = decodeResult(resp["result"], &params)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect network: %w", err)
+		}
+	}
+
+	if network.Name == "" {
+		return nil, fmt.Errorf("failed to detect network: node did not report a network name")
+	}
+	if len(genesis.Blocks) == 0 {
+		return nil, fmt.Errorf("failed to detect network: genesis tipset has no blocks")
+	}
+	network.GenesisTimestamp = genesis.Blocks[0].Timestamp
+	if params.BlockDelaySecs > 0 {
+		network.BlockDelaySecs = params.BlockDelaySecs
+	}
+
+	// Every network but mainnet uses testnet addresses
+	network.Testnet = network.Name != NetworkMainnet
+	network.AddressPrefix = address.MainnetPrefix
+	if network.Testnet {
+		network.AddressPrefix = address.TestnetPrefix
+	}
+	return network, nil
+}
+
+// applyNetwork makes addresses and amounts print the way the detected network expects
+func applyNetwork(network *Network) {
+	if network.Testnet {
+		address.CurrentNetwork = address.Testnet
+	} else {
+		address.CurrentNetwork = address.Mainnet
+	}
+	units.SetTestnet(network.Testnet)
+}
+
+// ActorCodes returns the code CIDs of the built-in actors at a network version, keyed by actor name
+func (c *Client) ActorCodes(ctx context.Context, version uint64) (map[string]string, error) {
+	c.networkMu.Lock()
+	codes, ok := c.actorCodes[version]
+	c.networkMu.Unlock()
+	if ok {
+		return codes, nil
+	}
+
+	var result map[string]map[string]string
+	if err := c.callRPCWithRetry(ctx, "Filecoin.StateActorCodeCIDs", []interface{}{version}, &result); err != nil {
+		return nil, fmt.Errorf("failed to get actor code CIDs for network version %d: %w", version, err)
+	}
+	codes = make(map[string]string, len(result))
+	for name, cid := range result {
+		codes[name] = cid["/"]
+	}
+
+	c.networkMu.Lock()
+	if c.actorCodes == nil {
+		c.actorCodes = make(map[uint64]map[string]string)
+	}
+	c.actorCodes[version] = codes
+	c.networkMu.Unlock()
+	return codes, nil
+}
+
+// ActorNames returns the actor names of a set of actor codes, sorted
+func ActorNames(codes map[string]string) []string {
+	names := make([]string, 0, len(codes))
+	for name := range codes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CacheStore returns the cache store for namespace, kept apart per network so entries
+// keyed by address or height never mix between networks. Mainnet keeps the plain
+// namespace so caches written before network detection stay valid. It returns nil,
+// which disables caching, when the network is unknown.
+func (c *Client) CacheStore(ctx context.Context, namespace string) *cache.Store {
+	network, err := c.Network(ctx)
+	if err != nil {
+		return nil
+	}
+	if network.Name == NetworkMainnet {
+		return cache.New(namespace)
+	}
+	return cache.New(filepath.Join(network.Name, namespace))
+}
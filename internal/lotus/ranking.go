@@ -0,0 +1,242 @@
+package lotus
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/THCloudAI/thctl/internal/cache"
+)
+
+const (
+	// powerBatchSize limits the number of StateMinerPower calls in a single batch request
+	powerBatchSize = 500
+	// powerBatchWorkers is the number of batch requests issued concurrently
+	powerBatchWorkers = 4
+	// rankingLatestKey is the cache key of the most recent ranking, overwritten by each new one
+	rankingLatestKey = "ranking-latest"
+
+	// DefaultRankingMaxAge is the default age (in epochs) after which a cached ranking is recomputed
+	DefaultRankingMaxAge = 120
+)
+
+// GetPowerRanking returns the network power ranking, reusing a cached ranking
+// computed at most maxAge epochs before the current head. A nil store disables caching.
+func (c *Client) GetPowerRanking(ctx context.Context, store *cache.Store, maxAge uint64) (*PowerRanking, error) {
+	head, err := c.GetChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if ranking, err := LoadPowerRanking(store); err != nil {
+		return nil, err
+	} else if ranking != nil && ranking.Height <= head.Height && head.Height-ranking.Height <= maxAge {
+		return ranking, nil
+	}
+
+	ranking, err := c.computePowerRanking(ctx, head)
+	if err != nil {
+		return nil, err
+	}
+
+	if store != nil {
+		if err := store.Put(rankingLatestKey, ranking); err != nil {
+			return nil, err
+		}
+	}
+
+	return ranking, nil
+}
+
+// LoadPowerRanking returns the most recent cached ranking, or nil if none exists
+func LoadPowerRanking(store *cache.Store) (*PowerRanking, error) {
+	if store == nil {
+		return nil, nil
+	}
+
+	var ranking PowerRanking
+	found, err := store.Get(rankingLatestKey, &ranking)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &ranking, nil
+}
+
+// Rank returns the ranking entry of a miner, or nil if the miner has no power
+func (r *PowerRanking) Rank(minerID string) *MinerPowerRank {
+	for i := range r.Miners {
+		if sameAddress(r.Miners[i].Miner, minerID) {
+			return &r.Miners[i]
+		}
+	}
+	return nil
+}
+
+// computePowerRanking lists all miners at the given tipset and ranks those with power
+func (c *Client) computePowerRanking(ctx context.Context, head *TipSet) (*PowerRanking, error) {
+	var miners []string
+	if err := c.callRPCWithRetry(ctx, "Filecoin.StateListMiners", []interface{}{head.Cids}, &miners); err != nil {
+		return nil, fmt.Errorf("failed to list miners: %w", err)
+	}
+
+	ranking := &PowerRanking{
+		Height: head.Height,
+		Miners: make([]MinerPowerRank, 0),
+	}
+	if len(head.Blocks) > 0 {
+		ranking.Timestamp = head.Blocks[0].Timestamp
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+		batches  = make(chan []string)
+	)
+
+	for i := 0; i < powerBatchWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				entries, network, err := c.fetchMinerPowers(ctx, batch, head.Cids)
+
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				ranking.Miners = append(ranking.Miners, entries...)
+				if network != nil && ranking.NetworkRawBytePower == "" {
+					ranking.NetworkRawBytePower = network.RawBytePower
+					ranking.NetworkQualityAdjPower = network.QualityAdjPower
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for start := 0; start < len(miners); start += powerBatchSize {
+		end := start + powerBatchSize
+		if end > len(miners) {
+			end = len(miners)
+		}
+		batches <- miners[start:end]
+	}
+	close(batches)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	assignRanks(ranking.Miners)
+	return ranking, nil
+}
+
+// powerClaim is a raw and quality adjusted power pair as returned by Lotus
+type powerClaim struct {
+	RawBytePower    string `json:"RawBytePower"`
+	QualityAdjPower string `json:"QualityAdjPower"`
+}
+
+// fetchMinerPowers fetches the power of a batch of miners, keeping only miners with power.
+// It fails when the power of any miner cannot be read, which would shift the ranks below it.
+func (c *Client) fetchMinerPowers(ctx context.Context, miners []string, tsk []map[string]string) ([]MinerPowerRank, *powerClaim, error) {
+	requests := make([]map[string]interface{}, 0, len(miners))
+	for i, miner := range miners {
+		requests = append(requests, newRPCRequest(i, "Filecoin.StateMinerPower", miner, tsk))
+	}
+
+	responses, err := c.BatchCallWithRetry(ctx, requests)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch miner power: %w", err)
+	}
+	if len(responses) != len(miners) {
+		return nil, nil, fmt.Errorf("failed to fetch miner power: got %d responses for %d miners", len(responses), len(miners))
+	}
+
+	var (
+		entries []MinerPowerRank
+		network *powerClaim
+	)
+	for _, resp := range responses {
+		id, ok := resp["id"].(float64)
+		if !ok {
+			continue
+		}
+		if resp["error"] != nil {
+			return nil, nil, fmt.Errorf("failed to fetch power of miner %s: %s", miners[int(id)], rpcErrorMessage(resp["error"]))
+		}
+
+		var power struct {
+			MinerPower  powerClaim `json:"MinerPower"`
+			TotalPower  powerClaim `json:"TotalPower"`
+			HasMinPower bool       `json:"HasMinPower"`
+		}
+		if err := decodeResult(resp["result"], &power); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode power of miner %s: %w", miners[int(id)], err)
+		}
+		if network == nil {
+			network = &power.TotalPower
+		}
+
+		raw, _ := new(big.Int).SetString(power.MinerPower.RawBytePower, 10)
+		qa, _ := new(big.Int).SetString(power.MinerPower.QualityAdjPower, 10)
+		if (raw == nil || raw.Sign() == 0) && (qa == nil || qa.Sign() == 0) {
+			continue
+		}
+
+		entries = append(entries, MinerPowerRank{
+			Miner:           miners[int(id)],
+			RawBytePower:    power.MinerPower.RawBytePower,
+			QualityAdjPower: power.MinerPower.QualityAdjPower,
+			HasMinPower:     power.HasMinPower,
+		})
+	}
+
+	return entries, network, nil
+}
+
+// assignRanks sets the raw and quality adjusted power ranks of each entry and
+// leaves the entries sorted by quality adjusted power. Miners with equal power share a rank.
+func assignRanks(entries []MinerPowerRank) {
+	rank := func(power func(*MinerPowerRank) string, rankField func(*MinerPowerRank) *uint64) {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return parseBigInt(power(&entries[i])).Cmp(parseBigInt(power(&entries[j]))) > 0
+		})
+		for i := range entries {
+			if i > 0 && parseBigInt(power(&entries[i])).Cmp(parseBigInt(power(&entries[i-1]))) == 0 {
+				*rankField(&entries[i]) = *rankField(&entries[i-1])
+				continue
+			}
+			*rankField(&entries[i]) = uint64(i + 1)
+		}
+	}
+
+	rank(func(e *MinerPowerRank) string { return e.RawBytePower },
+		func(e *MinerPowerRank) *uint64 { return &e.RawBytePowerRank })
+	rank(func(e *MinerPowerRank) string { return e.QualityAdjPower },
+		func(e *MinerPowerRank) *uint64 { return &e.QualityAdjPowerRank })
+}
+
+// parseBigInt parses a decimal string, treating invalid input as zero
+func parseBigInt(s string) *big.Int {
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return new(big.Int)
+	}
+	return v
+}
+
+// ApplyRanking fills in the miner's power ranks from a network ranking
+func (info *MinerInfo) ApplyRanking(r *PowerRanking) {
+	if r == nil {
+		return
+	}
+	if entry := r.Rank(info.ID); entry != nil {
+		info.Miner.RawBytePowerRank = entry.RawBytePowerRank
+		info.Miner.QualityAdjPowerRank = entry.QualityAdjPowerRank
+	}
+}
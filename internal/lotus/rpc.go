@@ -1,10 +1,91 @@
 package lotus
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 )
 
+// Error formats the error a node answered a call with
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("RPC error: %s", e.Message)
+}
+
+// callRPC makes a single JSON-RPC call to one API endpoint
+func callRPC(ctx context.Context, httpClient *http.Client, apiURL, token, method string, params interface{}, result interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+		"id":      1,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	data, err := postRPC(ctx, httpClient, apiURL, token, body)
+	if err != nil {
+		return err
+	}
+	return decodeRPCResponse(data, result)
+}
+
+// postRPC posts a JSON-RPC request body, a single call or a batch, to one API endpoint
+// and returns the response body
+func postRPC(ctx context.Context, httpClient *http.Client, apiURL, token string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("unexpected status code: %d: %w", resp.StatusCode, errUnauthorized)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return data, nil
+}
+
+// decodeRPCResponse decodes the response to a single call into result. The error a node
+// answered with is returned as an *RPCError.
+func decodeRPCResponse(data []byte, result interface{}) error {
+	var rpcResponse struct {
+		Error  *RPCError       `json:"error,omitempty"`
+		Result json.RawMessage `json:"result,omitempty"`
+	}
+	if err := json.Unmarshal(data, &rpcResponse); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if rpcResponse.Error != nil {
+		return rpcResponse.Error
+	}
+
+	if err := json.Unmarshal(rpcResponse.Result, result); err != nil {
+		return fmt.Errorf("failed to unmarshal result: %w", err)
+	}
+	return nil
+}
+
 // handleHTTPError converts HTTP errors to LotusError
 func handleHTTPError(resp *http.Response) error {
 	switch resp.StatusCode {
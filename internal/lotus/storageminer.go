@@ -0,0 +1,719 @@
+package lotus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/THCloudAI/thctl/internal/config"
+)
+
+// StorageMinerClient is a client of the API of a lotus-miner node, which serves the sealing
+// pipeline, the workers and the storage paths of a single miner. The full node API of
+// Client only sees the miner on chain.
+type StorageMinerClient struct {
+	apiURL     string
+	token      string
+	httpClient *http.Client
+}
+
+// NewStorageMiner creates a new lotus-miner API client. The API URL may be a multiaddr,
+// as with New.
+func NewStorageMiner(cfg Config) *StorageMinerClient {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	return &StorageMinerClient{
+		apiURL:     resolveAPIURL(cfg.APIURL),
+		token:      cfg.AuthToken,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// NewStorageMinerFromEnv creates a new lotus-miner API client from environment variables
+func NewStorageMinerFromEnv() (*StorageMinerClient, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %v", err)
+	}
+
+	if cfg.LotusMiner.APIURL == "" {
+		return nil, fmt.Errorf("LOTUS_MINER_API_URL environment variable is not set (e.g. /ip4/127.0.0.1/tcp/2345/http)")
+	}
+
+	return NewStorageMiner(Config{
+		APIURL:    cfg.LotusMiner.APIURL,
+		AuthToken: cfg.LotusMiner.AuthToken,
+		Timeout:   cfg.Lotus.Timeout,
+	}), nil
+}
+
+// call makes a JSON-RPC call to the lotus-miner API
+func (c *StorageMinerClient) call(ctx context.Context, method string, params []interface{}, result interface{}) error {
+	err := callRPC(ctx, c.httpClient, c.apiURL, c.token, method, params, result)
+	// A full node answers miner methods with method not found
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) && rpcErr.Code == -32601 {
+		return fmt.Errorf("%w (is %s a lotus-miner API?)", err, c.apiURL)
+	}
+	return err
+}
+
+// ActorAddress returns the address of the miner the node runs
+func (c *StorageMinerClient) ActorAddress(ctx context.Context) (string, error) {
+	var addr string
+	if err := c.call(ctx, "Filecoin.ActorAddress", []interface{}{}, &addr); err != nil {
+		return "", fmt.Errorf("failed to get miner address: %w", err)
+	}
+	return addr, nil
+}
+
+// sealTaskNames are the short names lotus-miner shows for sealing tasks
+var sealTaskNames = map[string]string{
+	"seal/v0/datacid":                  "DC",
+	"seal/v0/addpiece":                 "AP",
+	"seal/v0/precommit/1":              "PC1",
+	"seal/v0/synthetic":                "SYN",
+	"seal/v0/precommit/2":              "PC2",
+	"seal/v0/commit/1":                 "C1",
+	"seal/v0/commit/2":                 "C2",
+	"seal/v0/finalize":                 "FIN",
+	"seal/v0/finalize/unsealed":        "FUS",
+	"seal/v0/fetch":                    "GET",
+	"seal/v0/unseal":                   "UNS",
+	"seal/v0/replicaupdate":            "RU",
+	"seal/v0/provereplicaupdate/1":     "PR1",
+	"seal/v0/provereplicaupdate/2":     "PR2",
+	"seal/v0/regensectorkey":           "GSK",
+	"seal/v0/finalize/replicaupdate":   "FRU",
+	"seal/v0/download":                 "DL",
+	"post/v0/windowproof":              "WDP",
+	"post/v0/winningproof":             "WNP",
+	"seal/v0/generate_sdr":             "SDR",
+	"seal/v0/generate_tree_c":          "TRC",
+	"seal/v0/generate_tree_r_last":     "TRL",
+	"seal/v0/generate_update_encoding": "ENC",
+}
+
+// SealTaskName returns the short name of a sealing task, such as PC1 for
+// seal/v0/precommit/1
+func SealTaskName(task string) string {
+	if name, ok := sealTaskNames[task]; ok {
+		return name
+	}
+	return task
+}
+
+// Run wait values of a worker job, above which the job is queued on the worker
+const (
+	runWaitPrepared = 1
+	runWaitRunning  = 0
+	runWaitRetWait  = -1
+	runWaitReturned = -2
+	runWaitRetDone  = -3
+)
+
+// SealingJob is a task a worker runs or has queued for a sector
+type SealingJob struct {
+	ID             string    `json:"id"`
+	WorkerID       string    `json:"workerId"`
+	Hostname       string    `json:"hostname"`
+	Sector         uint64    `json:"sector"`
+	Task           string    `json:"task"`
+	TaskType       string    `json:"taskType"`
+	State          string    `json:"state"`
+	Start          time.Time `json:"start"`
+	ElapsedSeconds int64     `json:"elapsedSeconds"`
+}
+
+// SealingJobs are the jobs of every worker of a miner
+type SealingJobs struct {
+	MinerID string       `json:"minerId"`
+	Running int          `json:"running"`
+	Queued  int          `json:"queued"`
+	Jobs    []SealingJob `json:"jobs"`
+}
+
+// workerJob is a job as returned by WorkerJobs
+type workerJob struct {
+	ID struct {
+		ID string `json:"ID"`
+	} `json:"ID"`
+	Sector struct {
+		Number uint64 `json:"Number"`
+	} `json:"Sector"`
+	Task     string    `json:"Task"`
+	RunWait  int       `json:"RunWait"`
+	Start    time.Time `json:"Start"`
+	Hostname string    `json:"Hostname"`
+}
+
+// jobState describes the run wait value of a job the way lotus-miner sealing jobs does
+func jobState(runWait int) string {
+	switch {
+	case runWait > runWaitPrepared:
+		return fmt.Sprintf("assigned(%d)", runWait-1)
+	case runWait == runWaitPrepared:
+		return "prepared"
+	case runWait == runWaitRunning:
+		return "running"
+	case runWait == runWaitRetWait:
+		return "ret-wait"
+	case runWait == runWaitReturned:
+		return "returned"
+	case runWait == runWaitRetDone:
+		return "ret-done"
+	default:
+		return fmt.Sprintf("unknown(%d)", runWait)
+	}
+}
+
+// GetSealingJobs returns the jobs of every worker, running jobs first and then by start time
+func (c *StorageMinerClient) GetSealingJobs(ctx context.Context) (*SealingJobs, error) {
+	minerID, err := c.ActorAddress(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var workers map[string][]workerJob
+	if err := c.call(ctx, "Filecoin.WorkerJobs", []interface{}{}, &workers); err != nil {
+		return nil, fmt.Errorf("failed to get worker jobs: %w", err)
+	}
+
+	now := time.Now()
+	result := &SealingJobs{MinerID: minerID, Jobs: make([]SealingJob, 0)}
+	for workerID, jobs := range workers {
+		for _, j := range jobs {
+			job := SealingJob{
+				ID:       j.ID.ID,
+				WorkerID: workerID,
+				Hostname: j.Hostname,
+				Sector:   j.Sector.Number,
+				Task:     SealTaskName(j.Task),
+				TaskType: j.Task,
+				State:    jobState(j.RunWait),
+				Start:    j.Start,
+			}
+			if !j.Start.IsZero() {
+				job.ElapsedSeconds = int64(now.Sub(j.Start).Seconds())
+			}
+			if j.RunWait == runWaitRunning {
+				result.Running++
+			} else if j.RunWait > runWaitRunning {
+				result.Queued++
+			}
+			result.Jobs = append(result.Jobs, job)
+		}
+	}
+
+	sort.SliceStable(result.Jobs, func(i, j int) bool {
+		a, b := result.Jobs[i], result.Jobs[j]
+		if (a.State == "running") != (b.State == "running") {
+			return a.State == "running"
+		}
+		if !a.Start.Equal(b.Start) {
+			return a.Start.Before(b.Start)
+		}
+		return a.Sector < b.Sector
+	})
+	return result, nil
+}
+
+// SealingWorker is a sealing worker with its resources and the tasks it accepts.
+// Memory sizes are in bytes.
+type SealingWorker struct {
+	ID             string         `json:"id"`
+	Hostname       string         `json:"hostname"`
+	Enabled        bool           `json:"enabled"`
+	CPUs           uint64         `json:"cpus"`
+	CPUsUsed       uint64         `json:"cpusUsed"`
+	GPUs           []string       `json:"gpus"`
+	GPUsUsed       float64        `json:"gpusUsed"`
+	MemPhysical    uint64         `json:"memPhysical"`
+	MemUsed        uint64         `json:"memUsed"`
+	MemSwap        uint64         `json:"memSwap"`
+	MemSwapUsed    uint64         `json:"memSwapUsed"`
+	MemReservedMin uint64         `json:"memReservedMin"`
+	MemReservedMax uint64         `json:"memReservedMax"`
+	Tasks          []string       `json:"tasks"`
+	TaskCounts     map[string]int `json:"taskCounts"`
+}
+
+// MemUsedPercent is the share of physical memory in use or reserved by running tasks
+func (w *SealingWorker) MemUsedPercent() float64 {
+	if w.MemPhysical == 0 {
+		return 0
+	}
+	return float64(w.MemUsed+w.MemReservedMin) * 100 / float64(w.MemPhysical)
+}
+
+// SealingWorkers are the workers of a miner
+type SealingWorkers struct {
+	MinerID string          `json:"minerId"`
+	Workers []SealingWorker `json:"workers"`
+}
+
+// workerStats is a worker as returned by WorkerStats
+type workerStats struct {
+	Info struct {
+		Hostname  string `json:"Hostname"`
+		Resources struct {
+			MemPhysical uint64   `json:"MemPhysical"`
+			MemUsed     uint64   `json:"MemUsed"`
+			MemSwap     uint64   `json:"MemSwap"`
+			MemSwapUsed uint64   `json:"MemSwapUsed"`
+			CPUs        uint64   `json:"CPUs"`
+			GPUs        []string `json:"GPUs"`
+		} `json:"Resources"`
+	} `json:"Info"`
+	Tasks      []string       `json:"Tasks"`
+	Enabled    bool           `json:"Enabled"`
+	MemUsedMin uint64         `json:"MemUsedMin"`
+	MemUsedMax uint64         `json:"MemUsedMax"`
+	GpuUsed    float64        `json:"GpuUsed"`
+	CpuUse     uint64         `json:"CpuUse"`
+	TaskCounts map[string]int `json:"TaskCounts"`
+}
+
+// GetSealingWorkers returns the workers of the miner sorted by hostname
+func (c *StorageMinerClient) GetSealingWorkers(ctx context.Context) (*SealingWorkers, error) {
+	minerID, err := c.ActorAddress(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats map[string]workerStats
+	if err := c.call(ctx, "Filecoin.WorkerStats", []interface{}{}, &stats); err != nil {
+		return nil, fmt.Errorf("failed to get worker stats: %w", err)
+	}
+
+	result := &SealingWorkers{MinerID: minerID, Workers: make([]SealingWorker, 0, len(stats))}
+	for id, s := range stats {
+		worker := SealingWorker{
+			ID:             id,
+			Hostname:       s.Info.Hostname,
+			Enabled:        s.Enabled,
+			CPUs:           s.Info.Resources.CPUs,
+			CPUsUsed:       s.CpuUse,
+			GPUs:           s.Info.Resources.GPUs,
+			GPUsUsed:       s.GpuUsed,
+			MemPhysical:    s.Info.Resources.MemPhysical,
+			MemUsed:        s.Info.Resources.MemUsed,
+			MemSwap:        s.Info.Resources.MemSwap,
+			MemSwapUsed:    s.Info.Resources.MemSwapUsed,
+			MemReservedMin: s.MemUsedMin,
+			MemReservedMax: s.MemUsedMax,
+			Tasks:          make([]string, 0, len(s.Tasks)),
+			TaskCounts:     make(map[string]int),
+		}
+		if worker.GPUs == nil {
+			worker.GPUs = make([]string, 0)
+		}
+		for _, task := range s.Tasks {
+			worker.Tasks = append(worker.Tasks, SealTaskName(task))
+		}
+		sort.Strings(worker.Tasks)
+		for task, n := range s.TaskCounts {
+			if n > 0 {
+				worker.TaskCounts[SealTaskName(baseTaskType(task))] += n
+			}
+		}
+		result.Workers = append(result.Workers, worker)
+	}
+
+	sort.Slice(result.Workers, func(i, j int) bool {
+		a, b := result.Workers[i], result.Workers[j]
+		if a.Hostname != b.Hostname {
+			return a.Hostname < b.Hostname
+		}
+		return a.ID < b.ID
+	})
+	return result, nil
+}
+
+// baseTaskType strips the proof type newer lotus-miner versions append to task count
+// keys, as in seal/v0/precommit/1(32GiB)
+func baseTaskType(task string) string {
+	if i := strings.IndexAny(task, "(|"); i >= 0 {
+		return task[:i]
+	}
+	return task
+}
+
+// Stages of the sealing pipeline, in pipeline order
+const (
+	StageDeals      = "deals"
+	StagePacking    = "packing"
+	StagePreCommit1 = "precommit1"
+	StagePreCommit2 = "precommit2"
+	StagePreCommit  = "precommit"
+	StageWaitSeed   = "wait seed"
+	StageCommit     = "commit"
+	StageFinalize   = "finalize"
+	StageSnapDeals  = "snap deals"
+	StageProving    = "proving"
+	StageFailed     = "failed"
+	StageRemoved    = "removed"
+	StageOther      = "other"
+)
+
+// PipelineStages lists the sealing stages in pipeline order
+var PipelineStages = []string{
+	StageDeals, StagePacking, StagePreCommit1, StagePreCommit2, StagePreCommit, StageWaitSeed,
+	StageCommit, StageFinalize, StageSnapDeals, StageProving, StageFailed, StageRemoved, StageOther,
+}
+
+// sectorStages maps the sector states of lotus-miner to the stage of the pipeline they
+// belong to. States ending in Failed are in StageFailed.
+var sectorStages = map[string]string{
+	"WaitDeals":             StageDeals,
+	"AddPiece":              StageDeals,
+	"Packing":               StagePacking,
+	"GetTicket":             StagePacking,
+	"PreCommit1":            StagePreCommit1,
+	"PreCommit2":            StagePreCommit2,
+	"PreCommitting":         StagePreCommit,
+	"PreCommitWait":         StagePreCommit,
+	"SubmitPreCommitBatch":  StagePreCommit,
+	"PreCommitBatchWait":    StagePreCommit,
+	"WaitSeed":              StageWaitSeed,
+	"Committing":            StageCommit,
+	"CommitFinalize":        StageCommit,
+	"SubmitCommit":          StageCommit,
+	"SubmitCommitAggregate": StageCommit,
+	"CommitAggregateWait":   StageCommit,
+	"CommitWait":            StageCommit,
+	"FinalizeSector":        StageFinalize,
+	"SnapDealsWaitDeals":    StageSnapDeals,
+	"SnapDealsAddPiece":     StageSnapDeals,
+	"SnapDealsPacking":      StageSnapDeals,
+	"UpdateReplica":         StageSnapDeals,
+	"ProveReplicaUpdate":    StageSnapDeals,
+	"SubmitReplicaUpdate":   StageSnapDeals,
+	"WaitMutable":           StageSnapDeals,
+	"ReplicaUpdateWait":     StageSnapDeals,
+	"UpdateActivating":      StageSnapDeals,
+	"ReleaseSectorKey":      StageSnapDeals,
+	"FinalizeReplicaUpdate": StageSnapDeals,
+	"Proving":               StageProving,
+	"Available":             StageProving,
+	"FailedUnrecoverable":   StageFailed,
+	"DealsExpired":          StageFailed,
+	"RecoverDealIDs":        StageFailed,
+	"AbortUpgrade":          StageFailed,
+	"Faulty":                StageFailed,
+	"FaultReported":         StageFailed,
+	"Terminating":           StageRemoved,
+	"TerminateWait":         StageRemoved,
+	"TerminateFinality":     StageRemoved,
+	"Removing":              StageRemoved,
+	"Removed":               StageRemoved,
+}
+
+// SectorStage returns the pipeline stage of a sector state
+func SectorStage(state string) string {
+	if stage, ok := sectorStages[state]; ok {
+		return stage
+	}
+	if strings.HasSuffix(state, "Failed") {
+		return StageFailed
+	}
+	return StageOther
+}
+
+// SectorStateCount is the number of sectors in a state
+type SectorStateCount struct {
+	State string `json:"state"`
+	Stage string `json:"stage"`
+	Count int    `json:"count"`
+}
+
+// SealingSector is a sector in the sealing pipeline, not yet proving or removed
+type SealingSector struct {
+	Number             uint64 `json:"number"`
+	State              string `json:"state"`
+	Stage              string `json:"stage"`
+	Deals              int    `json:"deals"`
+	Retries            uint64 `json:"retries"`
+	LastError          string `json:"lastError,omitempty"`
+	TimeInStateSeconds int64  `json:"timeInStateSeconds"`
+}
+
+// SealingPipeline is the number of sectors in each state of the sealing pipeline, and
+// the sectors still in it
+type SealingPipeline struct {
+	MinerID string             `json:"minerId"`
+	Total   int                `json:"total"`
+	States  []SectorStateCount `json:"states"`
+	Sectors []SealingSector    `json:"sectors"`
+}
+
+// sectorStatus is a sector as returned by SectorsStatus
+type sectorStatus struct {
+	SectorID uint64   `json:"SectorID"`
+	State    string   `json:"State"`
+	Deals    []uint64 `json:"Deals"`
+	Retries  uint64   `json:"Retries"`
+	LastErr  string   `json:"LastErr"`
+	Log      []struct {
+		Kind      string `json:"Kind"`
+		Timestamp int64  `json:"Timestamp"`
+	} `json:"Log"`
+}
+
+// sectorStatusWorkers limits the SectorsStatus calls made at the same time
+const sectorStatusWorkers = 8
+
+// GetSealingPipeline counts the sectors in each state and fetches the status of those
+// still in the pipeline, with how long they have been in their state
+func (c *StorageMinerClient) GetSealingPipeline(ctx context.Context) (*SealingPipeline, error) {
+	minerID, err := c.ActorAddress(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var summary map[string]int
+	if err := c.call(ctx, "Filecoin.SectorsSummary", []interface{}{}, &summary); err != nil {
+		return nil, fmt.Errorf("failed to get sector summary: %w", err)
+	}
+
+	pipeline := &SealingPipeline{
+		MinerID: minerID,
+		States:  make([]SectorStateCount, 0, len(summary)),
+		Sectors: make([]SealingSector, 0),
+	}
+	var sealingStates []string
+	for state, count := range summary {
+		stage := SectorStage(state)
+		pipeline.Total += count
+		pipeline.States = append(pipeline.States, SectorStateCount{State: state, Stage: stage, Count: count})
+		if stage != StageProving && stage != StageRemoved {
+			sealingStates = append(sealingStates, state)
+		}
+	}
+	sort.Slice(pipeline.States, func(i, j int) bool {
+		a, b := stageIndex(pipeline.States[i].Stage), stageIndex(pipeline.States[j].Stage)
+		if a != b {
+			return a < b
+		}
+		return pipeline.States[i].State < pipeline.States[j].State
+	})
+	if len(sealingStates) == 0 {
+		return pipeline, nil
+	}
+
+	var numbers []uint64
+	if err := c.call(ctx, "Filecoin.SectorsListInStates", []interface{}{sealingStates}, &numbers); err != nil {
+		return nil, fmt.Errorf("failed to list sealing sectors: %w", err)
+	}
+
+	sectors := make([]SealingSector, len(numbers))
+	errs := make([]error, len(numbers))
+	now := time.Now().Unix()
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, sectorStatusWorkers)
+	for i, number := range numbers {
+		wg.Add(1)
+		go func(i int, number uint64) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			var status sectorStatus
+			if err := c.call(ctx, "Filecoin.SectorsStatus", []interface{}{number, false}, &status); err != nil {
+				errs[i] = fmt.Errorf("failed to get status of sector %d: %w", number, err)
+				return
+			}
+			sector := SealingSector{
+				Number:    number,
+				State:     status.State,
+				Stage:     SectorStage(status.State),
+				Deals:     len(status.Deals),
+				Retries:   status.Retries,
+				LastError: status.LastErr,
+			}
+			if n := len(status.Log); n > 0 {
+				sector.TimeInStateSeconds = max(now-status.Log[n-1].Timestamp, 0)
+			}
+			sectors[i] = sector
+		}(i, number)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(sectors, func(i, j int) bool {
+		a, b := stageIndex(sectors[i].Stage), stageIndex(sectors[j].Stage)
+		if a != b {
+			return a < b
+		}
+		return sectors[i].Number < sectors[j].Number
+	})
+	pipeline.Sectors = sectors
+	return pipeline, nil
+}
+
+// stageIndex returns the position of a stage in the pipeline
+func stageIndex(stage string) int {
+	for i, s := range PipelineStages {
+		if s == stage {
+			return i
+		}
+	}
+	return len(PipelineStages)
+}
+
+// Sector file types declared in storage paths, as bit flags
+const (
+	fileTypeUnsealed = 1 << iota
+	fileTypeSealed
+	fileTypeCache
+	fileTypeUpdate
+	fileTypeUpdateCache
+)
+
+// StoragePath is a sector storage path of the miner, with its capacity and the sectors
+// it holds. Sizes are in bytes.
+type StoragePath struct {
+	ID          string   `json:"id"`
+	LocalPath   string   `json:"localPath,omitempty"`
+	URLs        []string `json:"urls"`
+	CanSeal     bool     `json:"canSeal"`
+	CanStore    bool     `json:"canStore"`
+	Weight      uint64   `json:"weight"`
+	Groups      []string `json:"groups,omitempty"`
+	AllowTo     []string `json:"allowTo,omitempty"`
+	Capacity    int64    `json:"capacity"`
+	Available   int64    `json:"available"`
+	FSAvailable int64    `json:"fsAvailable"`
+	Reserved    int64    `json:"reserved"`
+	Used        int64    `json:"used"`
+	Max         int64    `json:"max,omitempty"`
+	UsedPercent float64  `json:"usedPercent"`
+	Sealed      int      `json:"sealed"`
+	Unsealed    int      `json:"unsealed"`
+	Updated     int      `json:"updated"`
+	Error       string   `json:"error,omitempty"`
+}
+
+// location returns the local path, or the first URL of a path of another node
+func (p *StoragePath) location() string {
+	if p.LocalPath != "" || len(p.URLs) == 0 {
+		return p.LocalPath
+	}
+	return p.URLs[0]
+}
+
+// StoragePaths are the storage paths of a miner
+type StoragePaths struct {
+	MinerID       string        `json:"minerId"`
+	TotalCapacity int64         `json:"totalCapacity"`
+	TotalUsed     int64         `json:"totalUsed"`
+	Paths         []StoragePath `json:"paths"`
+}
+
+// storageInfo is a path as returned by StorageInfo
+type storageInfo struct {
+	URLs       []string `json:"URLs"`
+	Weight     uint64   `json:"Weight"`
+	MaxStorage uint64   `json:"MaxStorage"`
+	CanSeal    bool     `json:"CanSeal"`
+	CanStore   bool     `json:"CanStore"`
+	Groups     []string `json:"Groups"`
+	AllowTo    []string `json:"AllowTo"`
+}
+
+// fsStat is the usage of a path as returned by StorageStat
+type fsStat struct {
+	Capacity    int64 `json:"Capacity"`
+	Available   int64 `json:"Available"`
+	FSAvailable int64 `json:"FSAvailable"`
+	Reserved    int64 `json:"Reserved"`
+	Max         int64 `json:"Max"`
+	Used        int64 `json:"Used"`
+}
+
+// GetStoragePaths returns the storage paths of the miner with their capacity, usage and
+// the sectors they hold. A path whose usage cannot be read, such as one of a worker that
+// went away, is returned with Error set.
+func (c *StorageMinerClient) GetStoragePaths(ctx context.Context) (*StoragePaths, error) {
+	minerID, err := c.ActorAddress(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var decls map[string][]struct {
+		SectorFileType int `json:"SectorFileType"`
+	}
+	if err := c.call(ctx, "Filecoin.StorageList", []interface{}{}, &decls); err != nil {
+		return nil, fmt.Errorf("failed to list storage paths: %w", err)
+	}
+	var local map[string]string
+	if err := c.call(ctx, "Filecoin.StorageLocal", []interface{}{}, &local); err != nil {
+		return nil, fmt.Errorf("failed to list local storage paths: %w", err)
+	}
+
+	result := &StoragePaths{MinerID: minerID, Paths: make([]StoragePath, 0, len(decls))}
+	for id, sectors := range decls {
+		path := StoragePath{ID: id, LocalPath: local[id], URLs: make([]string, 0)}
+		for _, d := range sectors {
+			if d.SectorFileType&fileTypeSealed != 0 {
+				path.Sealed++
+			}
+			if d.SectorFileType&fileTypeUnsealed != 0 {
+				path.Unsealed++
+			}
+			if d.SectorFileType&fileTypeUpdate != 0 {
+				path.Updated++
+			}
+		}
+
+		var info storageInfo
+		if err := c.call(ctx, "Filecoin.StorageInfo", []interface{}{id}, &info); err != nil {
+			path.Error = fmt.Sprintf("failed to get path info: %v", err)
+			result.Paths = append(result.Paths, path)
+			continue
+		}
+		path.CanSeal, path.CanStore, path.Weight = info.CanSeal, info.CanStore, info.Weight
+		path.Groups, path.AllowTo = info.Groups, info.AllowTo
+		if info.URLs != nil {
+			path.URLs = info.URLs
+		}
+
+		var stat fsStat
+		if err := c.call(ctx, "Filecoin.StorageStat", []interface{}{id}, &stat); err != nil {
+			path.Error = fmt.Sprintf("failed to get path usage: %v", err)
+			result.Paths = append(result.Paths, path)
+			continue
+		}
+		path.Capacity, path.Available, path.FSAvailable = stat.Capacity, stat.Available, stat.FSAvailable
+		path.Reserved, path.Used, path.Max = stat.Reserved, stat.Used, stat.Max
+		if stat.Capacity > 0 {
+			path.UsedPercent = float64(stat.Capacity-stat.FSAvailable) * 100 / float64(stat.Capacity)
+		}
+		result.TotalCapacity += stat.Capacity
+		result.TotalUsed += stat.Capacity - stat.FSAvailable
+		result.Paths = append(result.Paths, path)
+	}
+
+	// Sealing paths first, then by location
+	sort.Slice(result.Paths, func(i, j int) bool {
+		a, b := result.Paths[i], result.Paths[j]
+		if a.CanSeal != b.CanSeal {
+			return a.CanSeal
+		}
+		if a.location() != b.location() {
+			return a.location() < b.location()
+		}
+		return a.ID < b.ID
+	})
+	return result, nil
+}
@@ -2,6 +2,8 @@ package lotus
 
 import (
 	"encoding/json"
+
+	"github.com/THCloudAI/thctl/internal/units"
 )
 
 // RPCRequest represents a JSON-RPC request
@@ -32,6 +34,8 @@ type Response struct {
 	Timestamp int64      `json:"timestamp"`
 	Status    string     `json:"status"`
 	Data      interface{} `json:"data"`
+	// Endpoints are the Lotus endpoints that served the data and the calls of each
+	Endpoints []ServedEndpoint `json:"endpoints,omitempty"`
 }
 
 // MinerInfo represents comprehensive information about a miner
@@ -43,64 +47,92 @@ type MinerInfo struct {
 	CreateTimestamp    int64    `json:"createTimestamp"`
 	LastSeenHeight     uint64   `json:"lastSeenHeight"`
 	LastSeenTimestamp  int64    `json:"lastSeenTimestamp"`
-	Balance            string   `json:"balance"`
+	Balance            units.FIL `json:"balance"`
 	MessageCount       uint64   `json:"messageCount"`
 	TransferCount      uint64   `json:"transferCount"`
 	TokenTransferCount uint64   `json:"tokenTransferCount"`
 	Tokens            uint64   `json:"tokens"`
 	Miner             struct {
-		Owner struct {
-			Address string `json:"address"`
-			Balance string `json:"balance"`
-			ID      string `json:"id,omitempty"`
-		} `json:"owner"`
-		Worker struct {
-			Address string `json:"address"`
-			Balance string `json:"balance"`
-			ID      string `json:"id,omitempty"`
-		} `json:"worker"`
-		Beneficiary struct {
-			Address string `json:"address"`
-			Balance string `json:"balance"`
-			ID      string `json:"id,omitempty"`
-		} `json:"beneficiary"`
+		Owner AddressInfo `json:"owner"`
+		Worker AddressInfo `json:"worker"`
+		Beneficiary AddressInfo `json:"beneficiary"`
 		ControlAddresses     []ControlAddress `json:"controlAddresses"`
 		PeerID              string           `json:"peerId"`
 		MultiAddresses      []string         `json:"multiAddresses"`
 		SectorSize          uint64           `json:"sectorSize"`
-		RawBytePower        string           `json:"rawBytePower"`
-		QualityAdjPower     string           `json:"qualityAdjPower"`
-		NetworkRawBytePower string           `json:"networkRawBytePower"`
-		NetworkQualityAdjPower string        `json:"networkQualityAdjPower"`
+		RawBytePower        units.Bytes      `json:"rawBytePower"`
+		QualityAdjPower     units.Bytes      `json:"qualityAdjPower"`
+		NetworkRawBytePower units.Bytes      `json:"networkRawBytePower"`
+		NetworkQualityAdjPower units.Bytes   `json:"networkQualityAdjPower"`
 		BlocksMined          uint64          `json:"blocksMined"`
 		WeightedBlocksMined  uint64          `json:"weightedBlocksMined"`
-		TotalRewards        string           `json:"totalRewards"`
+		TotalRewards        units.FIL        `json:"totalRewards"`
 		Sectors             struct {
 			Live       uint64 `json:"live"`
 			Active     uint64 `json:"active"`
 			Faulty     uint64 `json:"faulty"`
 			Recovering uint64 `json:"recovering"`
 		} `json:"sectors"`
-		PreCommitDeposits        string `json:"preCommitDeposits"`
-		VestingFunds            string `json:"vestingFunds"`
-		InitialPledgeRequirement string `json:"initialPledgeRequirement"`
-		AvailableBalance        string `json:"availableBalance"`
-		SectorPledgeBalance     string `json:"sectorPledgeBalance"`
-		PledgeBalance           string `json:"pledgeBalance"`
+		PreCommitDeposits        units.FIL `json:"preCommitDeposits"`
+		VestingFunds            units.FIL `json:"vestingFunds"`
+		InitialPledgeRequirement units.FIL `json:"initialPledgeRequirement"`
+		AvailableBalance        units.FIL `json:"availableBalance"`
+		SectorPledgeBalance     units.FIL `json:"sectorPledgeBalance"`
+		PledgeBalance           units.FIL `json:"pledgeBalance"`
 		RawBytePowerRank        uint64 `json:"rawBytePowerRank"`
 		QualityAdjPowerRank     uint64 `json:"qualityAdjPowerRank"`
+		BeneficiaryTerm         BeneficiaryTerm `json:"beneficiaryTerm"`
+		PendingChanges          []PendingChange `json:"pendingChanges"`
 	} `json:"miner"`
 	OwnedMiners     []string `json:"ownedMiners"`
 	WorkerMiners    []string `json:"workerMiners"`
 	BenefitedMiners []string `json:"benefitedMiners"`
 	Address         string   `json:"address"`
+	Warnings        []string `json:"warnings,omitempty"`
+}
+
+// AddressInfo represents an address used by a miner with its resolved forms and balance
+
+type AddressInfo struct {
+	Address string    `json:"address"`
+	Balance units.FIL `json:"balance"`
+	ID      string    `json:"id,omitempty"`
+	Robust  string    `json:"robust,omitempty"`
 }
 
+
 // ControlAddress represents a control address with its balance
-type ControlAddress struct {
-	Address string `json:"address"`
-	Balance string `json:"balance"`
-	ID      string `json:"id,omitempty"`
+type ControlAddress = AddressInfo
+
+// BeneficiaryTerm represents how much the beneficiary of a miner may withdraw and until when.
+// A miner whose owner is its beneficiary has no quota or expiration.
+type BeneficiaryTerm struct {
+	Quota               units.FIL `json:"quota"`
+	UsedQuota           units.FIL `json:"usedQuota"`
+	RemainingQuota      units.FIL `json:"remainingQuota"`
+	Expiration          int64     `json:"expiration"`
+	ExpirationTimestamp int64     `json:"expirationTimestamp"`
+	Expired             bool      `json:"expired"`
+}
+
+// Roles whose changes are tracked as pending changes
+const (
+	RoleOwner       = "owner"
+	RoleWorker      = "worker"
+	RoleBeneficiary = "beneficiary"
+)
+
+// PendingChange represents a proposed change of a miner's owner, worker or beneficiary that
+// has not taken effect yet. EffectiveEpoch is only set for changes that wait for an epoch.
+type PendingChange struct {
+	Role               string     `json:"role"`
+	Current            string     `json:"current"`
+	New                string     `json:"new"`
+	EffectiveEpoch     int64      `json:"effectiveEpoch,omitempty"`
+	EffectiveTimestamp int64      `json:"effectiveTimestamp,omitempty"`
+	NewQuota           *units.FIL `json:"newQuota,omitempty"`
+	NewExpiration      int64      `json:"newExpiration,omitempty"`
+	Action             string     `json:"action"`
 }
 
 // SectorInfo represents information about a sector
@@ -108,16 +140,7 @@ type SectorInfo struct {
 	SectorNumber uint64 `json:"sectorNumber"`
 	State        string `json:"state"`
 	SealedCID    string `json:"sealedCid"`
-	Deals        []struct {
-		DealID      uint64 `json:"dealId"`
-		PieceCID    string `json:"pieceCid"`
-		PieceSize   uint64 `json:"pieceSize"`
-		Client      string `json:"client"`
-		StartEpoch  uint64 `json:"startEpoch"`
-		EndEpoch    uint64 `json:"endEpoch"`
-		StorageFee  string `json:"storageFee"`
-		PieceStatus string `json:"pieceStatus"`
-	} `json:"deals"`
+	Deals        []SectorDeal `json:"deals"`
 	CreationTime      int64  `json:"creationTime"`
 	ExpirationTime    int64  `json:"expirationTime"`
 	DealWeight        string `json:"dealWeight"`
@@ -127,6 +150,18 @@ type SectorInfo struct {
 	ExpectedStoragePledge string `json:"expectedStoragePledge"`
 }
 
+// SectorDeal represents a market deal stored in a sector
+type SectorDeal struct {
+	DealID      uint64 `json:"dealId"`
+	PieceCID    string `json:"pieceCid"`
+	PieceSize   uint64 `json:"pieceSize"`
+	Client      string `json:"client"`
+	StartEpoch  uint64 `json:"startEpoch"`
+	EndEpoch    uint64 `json:"endEpoch"`
+	StorageFee  string `json:"storageFee"`
+	PieceStatus string `json:"pieceStatus"`
+}
+
 // SectorPenalty represents penalty information for a sector
 type SectorPenalty struct {
 	SectorNumber uint64 `json:"sectorNumber"`
@@ -141,3 +176,474 @@ type SectorVested struct {
 	VestedFunds  string `json:"vestedFunds"`
 	Timestamp    int64  `json:"timestamp"`
 }
+
+// TipSet represents the subset of a Lotus tipset used by thctl
+type TipSet struct {
+	Cids   []map[string]string `json:"Cids"`
+	Height uint64              `json:"Height"`
+	Blocks []struct {
+		Miner         string              `json:"Miner"`
+		Timestamp     int64               `json:"Timestamp"`
+		ParentBaseFee string              `json:"ParentBaseFee"`
+		Parents       []map[string]string `json:"Parents"`
+		ElectionProof *struct {
+			WinCount uint64 `json:"WinCount"`
+		} `json:"ElectionProof"`
+	} `json:"Blocks"`
+}
+
+// BlockHistory represents the blocks mined by a miner over a height range
+
+type BlockHistory struct {
+	MinerID      string       `json:"minerId"`
+	FromHeight   uint64       `json:"fromHeight"`
+	ToHeight     uint64       `json:"toHeight"`
+	Epochs       uint64       `json:"epochs"`
+	NullRounds   uint64       `json:"nullRounds"`
+	BlocksMined  uint64       `json:"blocksMined"`
+	WinCount     uint64       `json:"winCount"`
+	TotalRewards units.FIL    `json:"totalRewards"`
+	PowerShare   float64      `json:"powerShare"`
+	ExpectedWins float64      `json:"expectedWins"`
+	Luck         float64      `json:"luck"`
+	Blocks       []MinedBlock `json:"blocks"`
+	Warnings     []string     `json:"warnings,omitempty"`
+}
+
+
+// MinedBlock represents a single block produced by a miner
+
+type MinedBlock struct {
+	Height    uint64    `json:"height"`
+	Cid       string    `json:"cid"`
+	Timestamp int64     `json:"timestamp"`
+	WinCount  uint64    `json:"winCount"`
+	Reward    units.FIL `json:"reward"`
+}
+
+
+// PowerRanking represents the network power ranking of all miners at a tipset
+type PowerRanking struct {
+	Height                 uint64           `json:"height"`
+	Timestamp              int64            `json:"timestamp"`
+	NetworkRawBytePower    string           `json:"networkRawBytePower"`
+	NetworkQualityAdjPower string           `json:"networkQualityAdjPower"`
+	Miners                 []MinerPowerRank `json:"miners"`
+}
+
+// MinerPowerRank represents a miner's power and its rank in the network
+type MinerPowerRank struct {
+	Miner               string `json:"miner"`
+	RawBytePower        string `json:"rawBytePower"`
+	QualityAdjPower     string `json:"qualityAdjPower"`
+	HasMinPower         bool   `json:"hasMinPower"`
+	RawBytePowerRank    uint64 `json:"rawBytePowerRank"`
+	QualityAdjPowerRank uint64 `json:"qualityAdjPowerRank"`
+}
+
+// ActorLifetime represents when an actor was created and when its state last changed
+type ActorLifetime struct {
+	Address             string `json:"address"`
+	CreateHeight        uint64 `json:"createHeight"`
+	CreateTimestamp     int64  `json:"createTimestamp"`
+	LastActiveHeight    uint64 `json:"lastActiveHeight"`
+	LastActiveTimestamp int64  `json:"lastActiveTimestamp"`
+}
+
+// DeadlineSchedule represents the WindowPoSt schedule of a miner across all deadlines
+type DeadlineSchedule struct {
+	MinerID          string           `json:"minerId"`
+	CurrentEpoch     int64            `json:"currentEpoch"`
+	PeriodStart      int64            `json:"periodStart"`
+	CurrentDeadline  uint64           `json:"currentDeadline"`
+	ChallengeWindow  int64            `json:"challengeWindow"`
+	MessagesScanned  bool             `json:"messagesScanned"`
+	Deadlines        []DeadlineStatus `json:"deadlines"`
+	Warnings         []string         `json:"warnings,omitempty"`
+}
+
+// DeadlineStatus represents the state of a single proving deadline
+type DeadlineStatus struct {
+	Index            uint64            `json:"index"`
+	Open             int64             `json:"open"`
+	Close            int64             `json:"close"`
+	IsOpen           bool              `json:"isOpen"`
+	OpensInSeconds   int64             `json:"opensInSeconds"`
+	Live             uint64            `json:"live"`
+	Active           uint64            `json:"active"`
+	Faulty           uint64            `json:"faulty"`
+	Recovering       uint64            `json:"recovering"`
+	Skipped          uint64            `json:"skipped"`
+	ProvenPartitions uint64            `json:"provenPartitions"`
+	DisputableProofs uint64            `json:"disputableProofs"`
+	PoStStatus       string            `json:"postStatus"`
+	LastPoStEpoch    int64             `json:"lastPoStEpoch,omitempty"`
+	Partitions       []PartitionStatus `json:"partitions"`
+}
+
+// PartitionStatus represents the sector counts of a single partition
+type PartitionStatus struct {
+	Index      uint64 `json:"index"`
+	Live       uint64 `json:"live"`
+	Active     uint64 `json:"active"`
+	Faulty     uint64 `json:"faulty"`
+	Recovering uint64 `json:"recovering"`
+	Skipped    uint64 `json:"skipped"`
+	Proven     bool   `json:"proven"`
+}
+
+// ExpirationCalendar represents a miner's sectors grouped by expiration period
+
+type ExpirationCalendar struct {
+	MinerID              string             `json:"minerId"`
+	CurrentEpoch         int64              `json:"currentEpoch"`
+	Bucket               string             `json:"bucket"`
+	SectorSize           uint64             `json:"sectorSize"`
+	TotalSectors         uint64             `json:"totalSectors"`
+	TotalRawBytePower    units.Bytes        `json:"totalRawBytePower"`
+	TotalQualityAdjPower units.Bytes        `json:"totalQualityAdjPower"`
+	TotalInitialPledge   units.FIL          `json:"totalInitialPledge"`
+	Buckets              []ExpirationBucket `json:"buckets"`
+}
+
+
+// ExpirationBucket represents the sectors expiring within a single period and what is at risk with them
+
+type ExpirationBucket struct {
+	Period          string      `json:"period"`
+	StartEpoch      int64       `json:"startEpoch"`
+	EndEpoch        int64       `json:"endEpoch"`
+	Sectors         uint64      `json:"sectors"`
+	RawBytePower    units.Bytes `json:"rawBytePower"`
+	QualityAdjPower units.Bytes `json:"qualityAdjPower"`
+	InitialPledge   units.FIL   `json:"initialPledge"`
+}
+
+
+// ExtensionPlan represents the ExtendSectorExpiration2 messages proposed to extend a miner's sectors
+type ExtensionPlan struct {
+	MinerID        string             `json:"minerId"`
+	CurrentEpoch   int64              `json:"currentEpoch"`
+	ExpiringBefore int64              `json:"expiringBefore"`
+	NewExpiration  int64              `json:"newExpiration"`
+	Sectors        uint64             `json:"sectors"`
+	Messages       []ExtensionMessage `json:"messages"`
+	Skipped        []SkippedSector    `json:"skipped,omitempty"`
+	Warnings       []string           `json:"warnings,omitempty"`
+}
+
+// ExtensionMessage represents a single proposed ExtendSectorExpiration2 message
+type ExtensionMessage struct {
+	Sectors      uint64          `json:"sectors"`
+	Declarations int             `json:"declarations"`
+	Deadlines    []uint64        `json:"deadlines"`
+	Message      UnsignedMessage `json:"message"`
+}
+
+// SkippedSector represents a sector left out of an extension plan
+type SkippedSector struct {
+	SectorNumber uint64 `json:"sectorNumber"`
+	Expiration   int64  `json:"expiration"`
+	Reason       string `json:"reason"`
+}
+
+// UnsignedMessage represents a Filecoin message in the JSON form used by Lotus
+type UnsignedMessage struct {
+	Version    uint64 `json:"Version"`
+	To         string `json:"To"`
+	From       string `json:"From"`
+	Nonce      uint64 `json:"Nonce"`
+	Value      string `json:"Value"`
+	GasLimit   int64  `json:"GasLimit"`
+	GasFeeCap  string `json:"GasFeeCap"`
+	GasPremium string `json:"GasPremium"`
+	Method     uint64 `json:"Method"`
+	Params     []byte `json:"Params"`
+}
+
+// ActorInfo represents an actor of any type with its state
+type ActorInfo struct {
+	Address          string                 `json:"address"`
+	ID               string                 `json:"id"`
+	Robust           string                 `json:"robust,omitempty"`
+	DelegatedAddress string                 `json:"delegatedAddress,omitempty"`
+	EthAddress       string                 `json:"ethAddress,omitempty"`
+	Type             string                 `json:"type"`
+	Code             string                 `json:"code"`
+	NetworkVersion   uint64                 `json:"networkVersion"`
+	Height           uint64                 `json:"height"`
+	Nonce            uint64                 `json:"nonce"`
+	Balance          units.FIL              `json:"balance"`
+	Multisig         *MultisigState         `json:"multisig,omitempty"`
+	EVM              *EVMState              `json:"evm,omitempty"`
+	State            map[string]interface{} `json:"state"`
+}
+
+// MultisigState represents the decoded state of a multisig actor and its vesting
+type MultisigState struct {
+	Signers               []string  `json:"signers"`
+	NumApprovalsThreshold uint64    `json:"numApprovalsThreshold"`
+	NextTxnID             int64     `json:"nextTxnId"`
+	InitialBalance        units.FIL `json:"initialBalance"`
+	StartEpoch            int64     `json:"startEpoch"`
+	UnlockDuration        int64     `json:"unlockDuration"`
+	LockedBalance         units.FIL `json:"lockedBalance"`
+	AvailableBalance      units.FIL `json:"availableBalance"`
+}
+
+// EVMState represents the decoded state of an EVM contract
+type EVMState struct {
+	Bytecode      string `json:"bytecode"`
+	BytecodeHash  string `json:"bytecodeHash"`
+	ContractState string `json:"contractState"`
+	Nonce         uint64 `json:"nonce"`
+	Tombstoned    bool   `json:"tombstoned"`
+}
+
+
+// MultisigInfo represents a multisig wallet with its vesting schedule and pending transactions
+type MultisigInfo struct {
+	Address         string    `json:"address"`
+	ID              string    `json:"id"`
+	Robust          string    `json:"robust,omitempty"`
+	Height          uint64    `json:"height"`
+	Balance         units.FIL `json:"balance"`
+	MultisigState   `yaml:",inline"`
+	UnlockEpoch     int64                 `json:"unlockEpoch,omitempty"`
+	UnlockTimestamp int64                 `json:"unlockTimestamp,omitempty"`
+	Vesting         []VestingStep         `json:"vesting,omitempty"`
+	Pending         []MultisigTransaction `json:"pending"`
+}
+
+// VestingStep represents the balance of a multisig still locked at an epoch
+type VestingStep struct {
+	Epoch     int64     `json:"epoch"`
+	Timestamp int64     `json:"timestamp"`
+	Locked    units.FIL `json:"locked"`
+}
+
+// MultisigTransaction represents a transaction proposed to a multisig and awaiting approval
+type MultisigTransaction struct {
+	ID          int64     `json:"id"`
+	To          string    `json:"to"`
+	Value       units.FIL `json:"value"`
+	Method      uint64    `json:"method"`
+	MethodName  string    `json:"methodName,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Params      []byte    `json:"params,omitempty"`
+	Proposer    string    `json:"proposer"`
+	Approved    []string  `json:"approved"`
+}
+
+// MpoolStatus represents the pending messages of a miner's worker and control addresses
+type MpoolStatus struct {
+	MinerID          string            `json:"minerId"`
+	Height           uint64            `json:"height"`
+	BaseFee          units.FIL         `json:"baseFee"`
+	PremiumEstimates []PremiumEstimate `json:"premiumEstimates"`
+	Senders          []MpoolSender     `json:"senders"`
+	StuckCount       int               `json:"stuckCount"`
+}
+
+// PremiumEstimate represents the gas premium estimated to get a message included within Epochs
+type PremiumEstimate struct {
+	Epochs  uint64    `json:"epochs"`
+	Premium units.FIL `json:"premium"`
+}
+
+// MpoolSender represents an address of a miner and its pending messages. NonceGaps lists the
+// missing nonces that hold up the messages after them.
+type MpoolSender struct {
+	Role         string           `json:"role"`
+	Address      string           `json:"address"`
+	KeyAddress   string           `json:"keyAddress"`
+	OnChainNonce uint64           `json:"onChainNonce"`
+	NonceGaps    []uint64         `json:"nonceGaps,omitempty"`
+	Messages     []PendingMessage `json:"messages"`
+}
+
+// PendingMessage represents a message waiting in the message pool. FirstSeen is the time
+// thctl first saw it pending, as the pool does not record when messages arrive.
+type PendingMessage struct {
+	Cid        string    `json:"cid"`
+	Nonce      uint64    `json:"nonce"`
+	To         string    `json:"to"`
+	Method     uint64    `json:"method"`
+	MethodName string    `json:"methodName"`
+	Value      units.FIL `json:"value"`
+	GasLimit   int64     `json:"gasLimit"`
+	GasFeeCap  units.FIL `json:"gasFeeCap"`
+	GasPremium units.FIL `json:"gasPremium"`
+	FirstSeen  int64     `json:"firstSeen,omitempty"`
+	AgeSeconds int64     `json:"ageSeconds,omitempty"`
+	Inclusion  string    `json:"inclusion"`
+	Stuck      bool      `json:"stuck"`
+}
+
+// MinerDeals represents the market deals of a miner with totals per client
+type MinerDeals struct {
+	MinerID         string        `json:"minerId"`
+	Height          int64         `json:"height"`
+	ExpiringBefore  int64         `json:"expiringBefore"`
+	MarketScanned   bool          `json:"marketScanned"`
+	ActiveDeals     int           `json:"activeDeals"`
+	ExpiringDeals   int           `json:"expiringDeals"`
+	PendingDeals    int           `json:"pendingDeals"`
+	VerifiedBytes   units.Bytes   `json:"verifiedBytes"`
+	UnverifiedBytes units.Bytes   `json:"unverifiedBytes"`
+	Deals           []MarketDeal  `json:"deals"`
+	Clients         []ClientDeals `json:"clients"`
+}
+
+// MarketDeal represents a storage deal of the market actor. SectorNumber is only known
+// for deals found through the sectors of the miner.
+type MarketDeal struct {
+	DealID       uint64      `json:"dealId"`
+	SectorNumber uint64      `json:"sectorNumber,omitempty"`
+	PieceCID     string      `json:"pieceCid"`
+	PieceSize    units.Bytes `json:"pieceSize"`
+	Client       string      `json:"client"`
+	Verified     bool        `json:"verified"`
+	StartEpoch   int64       `json:"startEpoch"`
+	EndEpoch     int64       `json:"endEpoch"`
+	EndTimestamp int64       `json:"endTimestamp"`
+	StorageFee   units.FIL   `json:"storageFee"`
+	Status       string      `json:"status"`
+}
+
+// ClientDeals represents the active deals of one client with a miner. DataCap is the
+// client's remaining allowance, nil when it is not a verified client.
+type ClientDeals struct {
+	Client        string       `json:"client"`
+	Deals         int          `json:"deals"`
+	Bytes         units.Bytes  `json:"bytes"`
+	VerifiedBytes units.Bytes  `json:"verifiedBytes"`
+	StorageFees   units.FIL    `json:"storageFees"`
+	DataCap       *units.Bytes `json:"dataCap,omitempty"`
+}
+
+// NetworkEconomics represents the pledge, rewards and gas of adding sectors at the current height
+type NetworkEconomics struct {
+	Height                 int64             `json:"height"`
+	NetworkVersion         uint64            `json:"networkVersion"`
+	BaseFee                units.FIL         `json:"baseFee"`
+	CirculatingSupply      units.FIL         `json:"circulatingSupply"`
+	NetworkRawBytePower    units.Bytes       `json:"networkRawBytePower"`
+	NetworkQualityAdjPower units.Bytes       `json:"networkQualityAdjPower"`
+	BaselinePower          units.Bytes       `json:"baselinePower"`
+	RewardPerEpoch         units.FIL         `json:"rewardPerEpoch"`
+	RewardPerTiBPerDay     units.FIL         `json:"rewardPerTiBPerDay"`
+	DurationDays           int64             `json:"durationDays"`
+	Sectors                []SectorEconomics `json:"sectors"`
+	Gas                    SectorGas         `json:"gas"`
+	WhatIf                 *WhatIf           `json:"whatIf,omitempty"`
+}
+
+// SectorEconomics represents the initial pledge and expected reward of a new sector
+type SectorEconomics struct {
+	SectorSize      units.Bytes `json:"sectorSize"`
+	Verified        bool        `json:"verified"`
+	QualityAdjPower units.Bytes `json:"qualityAdjPower"`
+	InitialPledge   units.FIL   `json:"initialPledge"`
+	PledgePerTiB    units.FIL   `json:"pledgePerTiB"`
+	RewardPerDay    units.FIL   `json:"rewardPerDay"`
+}
+
+// SectorGas represents the gas used per sector to pre-commit and prove-commit new sectors.
+// Estimated is set when a step did not appear in the scanned tipsets and the miner actor's
+// estimate is used instead.
+type SectorGas struct {
+	Tipsets                 int       `json:"tipsets"`
+	Messages                int       `json:"messages"`
+	PreCommitSectors        int64     `json:"preCommitSectors"`
+	ProveCommitSectors      int64     `json:"proveCommitSectors"`
+	PreCommitGasPerSector   int64     `json:"preCommitGasPerSector"`
+	ProveCommitGasPerSector int64     `json:"proveCommitGasPerSector"`
+	Estimated               bool      `json:"estimated"`
+	CostPerSector           units.FIL `json:"costPerSector"`
+}
+
+// WhatIf represents the pledge, gas and expected rewards of adding capacity to a miner
+type WhatIf struct {
+	Capacity           units.Bytes `json:"capacity"`
+	Verified           bool        `json:"verified"`
+	SectorSize         units.Bytes `json:"sectorSize"`
+	Sectors            uint64      `json:"sectors"`
+	QualityAdjPower    units.Bytes `json:"qualityAdjPower"`
+	NetworkShare       float64     `json:"networkShare"`
+	InitialPledge      units.FIL   `json:"initialPledge"`
+	GasCost            units.FIL   `json:"gasCost"`
+	RewardPerDay       units.FIL   `json:"rewardPerDay"`
+	RewardOverDuration units.FIL   `json:"rewardOverDuration"`
+	DurationDays       int64       `json:"durationDays"`
+	PledgeReturn       float64     `json:"pledgeReturn"`
+}
+
+// MinerLedger represents the daily fund flows of a miner and of its owner, worker and
+// control addresses between two UTC dates. Accounts maps each address to its role.
+type MinerLedger struct {
+	MinerID        string                 `json:"minerId"`
+	From           string                 `json:"from"`
+	To             string                 `json:"to"`
+	FromHeight     int64                  `json:"fromHeight"`
+	ToHeight       int64                  `json:"toHeight"`
+	Accounts       map[string]string      `json:"accounts"`
+	Totals         map[string]units.FIL   `json:"totals"`
+	Days           []LedgerDay            `json:"days"`
+	Entries        []LedgerEntry          `json:"entries"`
+	Reconciliation []LedgerReconciliation `json:"reconciliation"`
+}
+
+// LedgerDay represents the totals per category of one UTC day. The day covers the
+// tipsets executed from StartHeight up to, but not including, EndHeight.
+type LedgerDay struct {
+	Date        string               `json:"date"`
+	StartHeight int64                `json:"startHeight"`
+	EndHeight   int64                `json:"endHeight"`
+	Totals      map[string]units.FIL `json:"totals"`
+	Opening     LedgerFunds          `json:"opening"`
+	Closing     LedgerFunds          `json:"closing"`
+}
+
+// LedgerFunds represents the funds of a miner at a height, read the same way as the
+// Balance, VestingFunds and InitialPledgeRequirement fields of MinerInfo
+type LedgerFunds struct {
+	Height            int64     `json:"height"`
+	Balance           units.FIL `json:"balance"`
+	VestingFunds      units.FIL `json:"vestingFunds"`
+	InitialPledge     units.FIL `json:"initialPledge"`
+	PreCommitDeposits units.FIL `json:"preCommitDeposits"`
+	FeeDebt           units.FIL `json:"feeDebt"`
+}
+
+// LedgerEntry represents a single fund flow and the CID of the block or message it comes
+// from. Derived entries are computed from the change of the miner state over their day
+// rather than read from the chain.
+type LedgerEntry struct {
+	Date      string    `json:"date"`
+	Height    int64     `json:"height"`
+	Timestamp int64     `json:"timestamp"`
+	Account   string    `json:"account"`
+	Address   string    `json:"address"`
+	Category  string    `json:"category"`
+	Amount    units.FIL `json:"amount"`
+	Cid       string    `json:"cid,omitempty"`
+	Derived   bool      `json:"derived"`
+}
+
+// LedgerReconciliation compares the opening amount of a miner fund plus the ledger flows
+// with its closing amount
+type LedgerReconciliation struct {
+	Field      string    `json:"field"`
+	Opening    units.FIL `json:"opening"`
+	Flows      units.FIL `json:"flows"`
+	Closing    units.FIL `json:"closing"`
+	Difference units.FIL `json:"difference"`
+}
+
+// MinerPeer is the libp2p identity a miner advertises on chain
+type MinerPeer struct {
+	MinerID    string   `json:"minerId"`
+	PeerID     string   `json:"peerId"`
+	Multiaddrs []string `json:"multiaddrs"`
+}
@@ -0,0 +1,350 @@
+// Package netcheck dials the multiaddrs a miner advertises on chain and checks that the
+// libp2p peer answering them is the one registered for the miner.
+package netcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/sec"
+	"github.com/libp2p/go-libp2p/core/transport"
+	"github.com/libp2p/go-libp2p/p2p/muxer/yamux"
+	"github.com/libp2p/go-libp2p/p2p/protocol/identify/pb"
+	"github.com/libp2p/go-libp2p/p2p/security/noise"
+	libp2pquic "github.com/libp2p/go-libp2p/p2p/transport/quic"
+	"github.com/libp2p/go-libp2p/p2p/transport/quicreuse"
+	"github.com/libp2p/go-msgio/pbio"
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+	mss "github.com/multiformats/go-multistream"
+	"github.com/quic-go/quic-go"
+)
+
+// Transports of the checked multiaddrs. Only TCP and QUIC multiaddrs are dialed.
+const (
+	TransportTCP       = "tcp"
+	TransportQUIC      = "quic"
+	TransportWebsocket = "websocket"
+	TransportRelay     = "relay"
+	TransportOther     = "other"
+)
+
+// Statuses of a checked multiaddr
+const (
+	// StatusOK means the expected peer completed the libp2p handshake
+	StatusOK = "ok"
+	// StatusConnected means the connection succeeded but there was no peer ID on chain
+	// to check the peer against
+	StatusConnected = "connected"
+	// StatusPeerMismatch means a different peer than the one on chain answered
+	StatusPeerMismatch = "peer_mismatch"
+	// StatusHandshakeFailed means the peer answered but the libp2p handshake failed
+	StatusHandshakeFailed = "handshake_failed"
+	// StatusUnreachable means the TCP connection failed or no QUIC peer answered
+	StatusUnreachable = "unreachable"
+	// StatusSkipped means the multiaddr was not dialed
+	StatusSkipped = "skipped"
+)
+
+const (
+	// yamuxID is the stream multiplexer negotiated after the security handshake
+	yamuxID = "/yamux/1.0.0"
+	// identifyID is the libp2p identify protocol
+	identifyID = "/ipfs/id/1.0.0"
+	// maxIdentifySize bounds the identify message read from the peer
+	maxIdentifySize = 64 << 10
+	// maxDials is the number of multiaddrs dialed at the same time
+	maxDials = 8
+)
+
+// Result is the outcome of dialing a single multiaddr. Latencies are in milliseconds
+// from the start of the dial.
+type Result struct {
+	Multiaddr          string   `json:"multiaddr"`
+	Transport          string   `json:"transport"`
+	Status             string   `json:"status"`
+	DialLatencyMs      float64  `json:"dialLatencyMs,omitempty"`
+	HandshakeLatencyMs float64  `json:"handshakeLatencyMs,omitempty"`
+	RemotePeerID       string   `json:"remotePeerId,omitempty"`
+	AgentVersion       string   `json:"agentVersion,omitempty"`
+	ProtocolVersion    string   `json:"protocolVersion,omitempty"`
+	Protocols          []string `json:"protocols,omitempty"`
+	ListenAddrs        []string `json:"listenAddrs,omitempty"`
+	ObservedAddr       string   `json:"observedAddr,omitempty"`
+	Error              string   `json:"error,omitempty"`
+}
+
+// Report is the result of checking every multiaddr of a miner
+type Report struct {
+	MinerID   string   `json:"minerId"`
+	PeerID    string   `json:"peerId"`
+	Reachable int      `json:"reachable"`
+	Results   []Result `json:"results"`
+}
+
+// Checker dials multiaddrs with a throwaway libp2p identity
+type Checker struct {
+	noise   *noise.Transport
+	quic    transport.Transport
+	timeout time.Duration
+}
+
+// New creates a checker giving each multiaddr up to timeout to connect, handshake and
+// answer identify
+func New(timeout time.Duration) (*Checker, error) {
+	key, _, err := crypto.GenerateEd25519Key(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate libp2p key: %w", err)
+	}
+	transport, err := noise.New(noise.ID, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create noise transport: %w", err)
+	}
+	// Each dial gets its own UDP socket, the checker never listens
+	connManager, err := quicreuse.NewConnManager(quic.StatelessResetKey{}, quic.TokenGeneratorKey{}, quicreuse.DisableReuseport())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create QUIC connection manager: %w", err)
+	}
+	quicTransport, err := libp2pquic.NewTransport(key, connManager, nil, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create QUIC transport: %w", err)
+	}
+	return &Checker{noise: transport, quic: quicTransport, timeout: timeout}, nil
+}
+
+// Check dials the multiaddrs concurrently and returns their results in the same order.
+// The libp2p handshake is run against peerID; when it is empty only the connection is
+// checked.
+func (c *Checker) Check(ctx context.Context, peerID string, multiaddrs []string) ([]Result, error) {
+	var expected peer.ID
+	if peerID != "" {
+		var err error
+		if expected, err = peer.Decode(peerID); err != nil {
+			return nil, fmt.Errorf("invalid peer ID %q: %w", peerID, err)
+		}
+	}
+
+	results := make([]Result, len(multiaddrs))
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, maxDials)
+	for i, addr := range multiaddrs {
+		wg.Add(1)
+		go func(i int, addr string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			results[i] = c.Dial(ctx, expected, addr)
+		}(i, addr)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// Dial checks a single multiaddr. A /p2p component of the multiaddr is used as the
+// expected peer when none is given.
+func (c *Checker) Dial(ctx context.Context, expected peer.ID, addr string) Result {
+	result := Result{Multiaddr: addr, Transport: TransportOther}
+
+	maddr, err := ma.NewMultiaddr(addr)
+	if err != nil {
+		result.Status = StatusSkipped
+		result.Error = fmt.Sprintf("invalid multiaddr: %v", err)
+		return result
+	}
+	maddr, addrPeer := peer.SplitAddr(maddr)
+	if expected == "" {
+		expected = addrPeer
+	}
+
+	result.Transport = transportOf(maddr)
+	switch {
+	case result.Transport == TransportQUIC && !c.quic.CanDial(maddr):
+		result.Status = StatusSkipped
+		result.Error = "only QUIC v1 multiaddrs with an IP address are dialed"
+		return result
+	case result.Transport == TransportQUIC:
+		ctx, cancel := context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+		c.dialQUIC(ctx, expected, maddr, &result)
+		return result
+	case result.Transport != TransportTCP:
+		result.Status = StatusSkipped
+		result.Error = fmt.Sprintf("%s multiaddrs are not dialed, only plain TCP and QUIC ones", result.Transport)
+		return result
+	}
+
+	network, hostport, err := manet.DialArgs(maddr)
+	if err != nil {
+		result.Status = StatusSkipped
+		result.Error = fmt.Sprintf("failed to resolve dial address: %v", err)
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, hostport)
+	if err != nil {
+		result.Status = StatusUnreachable
+		result.Error = err.Error()
+		return result
+	}
+	defer conn.Close()
+	result.DialLatencyMs = millis(time.Since(start))
+
+	if expected == "" {
+		result.Status = StatusConnected
+		return result
+	}
+
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	if err := mss.SelectProtoOrFail(string(noise.ID), conn); err != nil {
+		result.Status = StatusHandshakeFailed
+		result.Error = fmt.Sprintf("failed to negotiate noise: %v", err)
+		return result
+	}
+	secure, err := c.noise.SecureOutbound(ctx, conn, expected)
+	if err != nil {
+		var mismatch sec.ErrPeerIDMismatch
+		if errors.As(err, &mismatch) {
+			result.Status = StatusPeerMismatch
+			result.RemotePeerID = mismatch.Actual.String()
+			result.Error = fmt.Sprintf("expected peer %s", expected)
+			return result
+		}
+		result.Status = StatusHandshakeFailed
+		result.Error = fmt.Sprintf("noise handshake failed: %v", err)
+		return result
+	}
+	defer secure.Close()
+	result.Status = StatusOK
+	result.RemotePeerID = secure.RemotePeer().String()
+	result.HandshakeLatencyMs = millis(time.Since(start))
+
+	// Identify only adds details, the peer is already authenticated
+	if err := identify(ctx, secure, &result); err != nil {
+		result.Error = fmt.Sprintf("identify failed: %v", err)
+	}
+	return result
+}
+
+// dialQUIC connects over QUIC, whose TLS handshake authenticates the peer, and reads the
+// identify message of the peer. Without an expected peer any peer is accepted.
+func (c *Checker) dialQUIC(ctx context.Context, expected peer.ID, maddr ma.Multiaddr, result *Result) {
+	start := time.Now()
+	conn, err := c.quic.Dial(ctx, maddr, expected)
+	if err != nil {
+		var mismatch sec.ErrPeerIDMismatch
+		var transportErr *quic.TransportError
+		switch {
+		case errors.As(err, &mismatch):
+			result.Status = StatusPeerMismatch
+			result.RemotePeerID = mismatch.Actual.String()
+			result.Error = fmt.Sprintf("expected peer %s", expected)
+		case errors.As(err, &transportErr):
+			result.Status = StatusHandshakeFailed
+			result.Error = fmt.Sprintf("QUIC handshake failed: %v", err)
+		default:
+			result.Status = StatusUnreachable
+			result.Error = err.Error()
+		}
+		return
+	}
+	defer conn.Close()
+
+	// The connection is only up once the handshake is done
+	result.DialLatencyMs = millis(time.Since(start))
+	result.HandshakeLatencyMs = result.DialLatencyMs
+	result.RemotePeerID = conn.RemotePeer().String()
+	result.Status = StatusOK
+	if expected == "" {
+		result.Status = StatusConnected
+	}
+
+	if err := readIdentify(ctx, conn, result); err != nil {
+		result.Error = fmt.Sprintf("identify failed: %v", err)
+	}
+}
+
+// identify opens a yamux session over the secured connection and reads the identify
+// message of the peer
+func identify(ctx context.Context, conn net.Conn, result *Result) error {
+	if err := mss.SelectProtoOrFail(yamuxID, conn); err != nil {
+		return fmt.Errorf("failed to negotiate yamux: %w", err)
+	}
+	session, err := yamux.DefaultTransport.NewConn(conn, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start yamux session: %w", err)
+	}
+	defer session.Close()
+	return readIdentify(ctx, session, result)
+}
+
+// readIdentify opens a stream on a multiplexed connection and reads the identify message
+// of the peer
+func readIdentify(ctx context.Context, conn network.MuxedConn, result *Result) error {
+	stream, err := conn.OpenStream(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open stream: %w", err)
+	}
+	defer stream.Close()
+
+	if err := mss.SelectProtoOrFail(identifyID, stream); err != nil {
+		return fmt.Errorf("failed to negotiate identify: %w", err)
+	}
+	var msg pb.Identify
+	if err := pbio.NewDelimitedReader(stream, maxIdentifySize).ReadMsg(&msg); err != nil {
+		return fmt.Errorf("failed to read identify message: %w", err)
+	}
+
+	result.AgentVersion = msg.GetAgentVersion()
+	result.ProtocolVersion = msg.GetProtocolVersion()
+	result.Protocols = append([]string(nil), msg.GetProtocols()...)
+	sort.Strings(result.Protocols)
+	for _, raw := range msg.GetListenAddrs() {
+		if maddr, err := ma.NewMultiaddrBytes(raw); err == nil {
+			result.ListenAddrs = append(result.ListenAddrs, maddr.String())
+		}
+	}
+	if observed, err := ma.NewMultiaddrBytes(msg.GetObservedAddr()); err == nil {
+		result.ObservedAddr = observed.String()
+	}
+	return nil
+}
+
+// millis converts a duration to milliseconds, keeping microseconds
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// transportOf returns the transport a multiaddr is dialed over
+func transportOf(maddr ma.Multiaddr) string {
+	has := func(code int) bool {
+		_, err := maddr.ValueForProtocol(code)
+		return err == nil
+	}
+	switch {
+	case has(ma.P_CIRCUIT):
+		return TransportRelay
+	case has(ma.P_WS) || has(ma.P_WSS):
+		return TransportWebsocket
+	case has(ma.P_QUIC) || has(ma.P_QUIC_V1):
+		return TransportQUIC
+	case has(ma.P_TCP):
+		return TransportTCP
+	default:
+		return TransportOther
+	}
+}
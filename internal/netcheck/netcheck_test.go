@@ -0,0 +1,296 @@
+package netcheck
+
+import (
+	"context"
+	"go/version"
+	"net"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/sec"
+	"github.com/libp2p/go-libp2p/core/transport"
+	"github.com/libp2p/go-libp2p/p2p/host/eventbus"
+	"github.com/libp2p/go-libp2p/p2p/host/peerstore/pstoremem"
+	"github.com/libp2p/go-libp2p/p2p/muxer/yamux"
+	"github.com/libp2p/go-libp2p/p2p/net/swarm"
+	tptu "github.com/libp2p/go-libp2p/p2p/net/upgrader"
+	"github.com/libp2p/go-libp2p/p2p/protocol/identify/pb"
+	"github.com/libp2p/go-libp2p/p2p/security/noise"
+	libp2pquic "github.com/libp2p/go-libp2p/p2p/transport/quic"
+	"github.com/libp2p/go-libp2p/p2p/transport/quicreuse"
+	"github.com/libp2p/go-libp2p/p2p/transport/tcp"
+	"github.com/libp2p/go-msgio/pbio"
+	ma "github.com/multiformats/go-multiaddr"
+	mss "github.com/multiformats/go-multistream"
+	"github.com/quic-go/quic-go"
+)
+
+const (
+	testAgent    = "thctl-netcheck-test"
+	testProtocol = "/fil/kad/calibrationnet/kad/1.0.0"
+)
+
+// testPeer is a libp2p peer listening on TCP and QUIC on localhost that answers identify.
+// It assembles the swarm, transports, security and muxer that libp2p.New() uses by default,
+// without the WebRTC transport that libp2p.New() also pulls in.
+type testPeer struct {
+	id    peer.ID
+	tcp   ma.Multiaddr
+	quic  ma.Multiaddr
+	swarm *swarm.Swarm
+}
+
+func newTestPeer(t *testing.T) *testPeer {
+	t.Helper()
+	key, _, err := crypto.GenerateEd25519Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ps, err := pstoremem.NewPeerstore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ps.AddPrivKey(id, key); err != nil {
+		t.Fatal(err)
+	}
+	if err := ps.AddPubKey(id, key.GetPublic()); err != nil {
+		t.Fatal(err)
+	}
+	s, err := swarm.NewSwarm(id, ps, eventbus.NewBus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	muxers := []tptu.StreamMuxer{{ID: yamuxID, Muxer: yamux.DefaultTransport}}
+	security, err := noise.New(noise.ID, key, muxers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upgrader, err := tptu.New([]sec.SecureTransport{security}, muxers, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tcpTransport, err := tcp.NewTCPTransport(upgrader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	connManager, err := quicreuse.NewConnManager(quic.StatelessResetKey{}, quic.TokenGeneratorKey{}, quicreuse.DisableReuseport())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { connManager.Close() })
+	quicTransport, err := libp2pquic.NewTransport(key, connManager, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tpt := range []transport.Transport{tcpTransport, quicTransport} {
+		if err := s.AddTransport(tpt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.Listen(ma.StringCast("/ip4/127.0.0.1/tcp/0"), ma.StringCast("/ip4/127.0.0.1/udp/0/quic-v1")); err != nil {
+		t.Fatal(err)
+	}
+	p := &testPeer{id: id, swarm: s}
+	for _, addr := range s.ListenAddresses() {
+		switch transportOf(addr) {
+		case TransportTCP:
+			p.tcp = addr
+		case TransportQUIC:
+			p.quic = addr
+		}
+	}
+	if p.tcp == nil || p.quic == nil {
+		t.Fatalf("listening on %v, want a TCP and a QUIC address", s.ListenAddresses())
+	}
+
+	s.SetStreamHandler(p.handle)
+	return p
+}
+
+// handle answers identify on the streams the checker opens
+func (p *testPeer) handle(stream network.Stream) {
+	defer stream.Close()
+	mux := mss.NewMultistreamMuxer[string]()
+	mux.AddHandler(identifyID, nil)
+	if _, _, err := mux.Negotiate(stream); err != nil {
+		stream.Reset()
+		return
+	}
+
+	msg := &pb.Identify{
+		AgentVersion:    ptr(testAgent),
+		ProtocolVersion: ptr("ipfs/0.1.0"),
+		Protocols:       []string{testProtocol, identifyID},
+		ObservedAddr:    stream.Conn().RemoteMultiaddr().Bytes(),
+	}
+	for _, addr := range p.swarm.ListenAddresses() {
+		msg.ListenAddrs = append(msg.ListenAddrs, addr.Bytes())
+	}
+	pbio.NewDelimitedWriter(stream).WriteMsg(msg)
+}
+
+func ptr(s string) *string { return &s }
+
+// skipQUICHandshake skips tests that complete a QUIC handshake with the test peer. The
+// quic-go release that go-libp2p v0.37 requires panics on the listening side once the
+// handshake completes when built with Go 1.24 or later, whose crypto/tls no longer fails
+// to send a session ticket when they are disabled. Handshakes the dialer aborts still run.
+func skipQUICHandshake(t *testing.T, addr ma.Multiaddr) {
+	t.Helper()
+	if transportOf(addr) == TransportQUIC && version.Compare(runtime.Version(), "go1.24") >= 0 {
+		t.Skipf("the QUIC listener of quic-go v0.48 panics with %s", runtime.Version())
+	}
+}
+
+func newTestChecker(t *testing.T) *Checker {
+	t.Helper()
+	checker, err := New(5 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return checker
+}
+
+func TestDialOK(t *testing.T) {
+	p := newTestPeer(t)
+	checker := newTestChecker(t)
+
+	for _, addr := range []ma.Multiaddr{p.tcp, p.quic} {
+		t.Run(transportOf(addr), func(t *testing.T) {
+			skipQUICHandshake(t, addr)
+			result := checker.Dial(context.Background(), p.id, addr.String())
+			if result.Status != StatusOK {
+				t.Fatalf("status = %s (%s), want %s", result.Status, result.Error, StatusOK)
+			}
+			if result.Error != "" {
+				t.Errorf("error = %s", result.Error)
+			}
+			if result.RemotePeerID != p.id.String() {
+				t.Errorf("remote peer = %s, want %s", result.RemotePeerID, p.id)
+			}
+			if result.DialLatencyMs <= 0 || result.HandshakeLatencyMs < result.DialLatencyMs {
+				t.Errorf("latencies = %v, %v", result.DialLatencyMs, result.HandshakeLatencyMs)
+			}
+
+			if result.AgentVersion != testAgent || result.ProtocolVersion != "ipfs/0.1.0" {
+				t.Errorf("identify versions = %q, %q", result.AgentVersion, result.ProtocolVersion)
+			}
+			if len(result.Protocols) != 2 || result.Protocols[0] != testProtocol || result.Protocols[1] != identifyID {
+				t.Errorf("protocols = %v, want sorted [%s %s]", result.Protocols, testProtocol, identifyID)
+			}
+			if len(result.ListenAddrs) != 2 {
+				t.Errorf("listen addrs = %v, want the TCP and QUIC ones", result.ListenAddrs)
+			}
+			if result.ObservedAddr == "" || transportOf(ma.StringCast(result.ObservedAddr)) != transportOf(addr) {
+				t.Errorf("observed addr = %q, want a %s address", result.ObservedAddr, transportOf(addr))
+			}
+		})
+	}
+}
+
+func TestDialPeerInAddr(t *testing.T) {
+	p := newTestPeer(t)
+	checker := newTestChecker(t)
+
+	// The /p2p component is the expected peer when none is given
+	addr := p.tcp.Encapsulate(ma.StringCast("/p2p/" + p.id.String()))
+	if result := checker.Dial(context.Background(), "", addr.String()); result.Status != StatusOK {
+		t.Errorf("status = %s (%s), want %s", result.Status, result.Error, StatusOK)
+	}
+}
+
+func TestDialNoPeerID(t *testing.T) {
+	p := newTestPeer(t)
+	checker := newTestChecker(t)
+
+	for _, addr := range []ma.Multiaddr{p.tcp, p.quic} {
+		t.Run(transportOf(addr), func(t *testing.T) {
+			skipQUICHandshake(t, addr)
+			if result := checker.Dial(context.Background(), "", addr.String()); result.Status != StatusConnected {
+				t.Errorf("status = %s (%s), want %s", result.Status, result.Error, StatusConnected)
+			}
+		})
+	}
+}
+
+func TestDialPeerMismatch(t *testing.T) {
+	p := newTestPeer(t)
+	other := newTestPeer(t)
+	checker := newTestChecker(t)
+
+	for _, addr := range []ma.Multiaddr{p.tcp, p.quic} {
+		t.Run(transportOf(addr), func(t *testing.T) {
+			result := checker.Dial(context.Background(), other.id, addr.String())
+			if result.Status != StatusPeerMismatch {
+				t.Fatalf("status = %s (%s), want %s", result.Status, result.Error, StatusPeerMismatch)
+			}
+			if result.RemotePeerID != p.id.String() {
+				t.Errorf("remote peer = %s, want %s", result.RemotePeerID, p.id)
+			}
+		})
+	}
+}
+
+func TestDialUnreachable(t *testing.T) {
+	p := newTestPeer(t)
+	checker, err := New(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ports that were free a moment ago
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tcpAddr := tcpListener.Addr().(*net.TCPAddr)
+	tcpListener.Close()
+	udpConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	udpAddr := udpConn.LocalAddr().(*net.UDPAddr)
+	udpConn.Close()
+
+	for _, addr := range []string{
+		"/ip4/127.0.0.1/tcp/" + strconv.Itoa(tcpAddr.Port),
+		"/ip4/127.0.0.1/udp/" + strconv.Itoa(udpAddr.Port) + "/quic-v1",
+	} {
+		t.Run(transportOf(ma.StringCast(addr)), func(t *testing.T) {
+			result := checker.Dial(context.Background(), p.id, addr)
+			if result.Status != StatusUnreachable {
+				t.Errorf("status = %s (%s), want %s", result.Status, result.Error, StatusUnreachable)
+			}
+			if result.Error == "" {
+				t.Error("no error reported")
+			}
+		})
+	}
+}
+
+func TestDialSkipped(t *testing.T) {
+	checker := newTestChecker(t)
+	for _, addr := range []string{
+		"not a multiaddr",
+		"/ip4/127.0.0.1/tcp/4001/ws",
+		"/ip4/127.0.0.1/udp/4001/quic",
+		"/dns4/example.com/udp/4001/quic-v1",
+	} {
+		if result := checker.Dial(context.Background(), "", addr); result.Status != StatusSkipped {
+			t.Errorf("%s: status = %s, want %s", addr, result.Status, StatusSkipped)
+		}
+	}
+}
@@ -7,10 +7,12 @@ package cos
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/tencentyun/cos-go-sdk-v5"
 	"github.com/THCloudAI/thctl/internal/storage"
@@ -28,7 +30,7 @@ type Client struct {
 func NewClient(config *storage.Config) (*Client, error) {
 	log := logger.WithModule("cos")
 	
-	u, err := url.Parse(fmt.Sprintf("https://%s.cos.%s.myqcloud.com", config.BucketName, config.Region))
+	u, err := url.Parse(bucketURL(config))
 	if err != nil {
 		log.Errorf("Failed to parse COS URL: %v", err)
 		return nil, err
@@ -58,7 +60,7 @@ func (c *Client) ListBuckets(ctx context.Context) ([]storage.Bucket, error) {
 	result, _, err := c.client.Service.Get(ctx)
 	if err != nil {
 		c.log.Errorf("Failed to list buckets: %v", err)
-		return nil, err
+		return nil, wrapError(err)
 	}
 
 	buckets := make([]storage.Bucket, len(result.Buckets))
@@ -84,7 +86,7 @@ func (c *Client) ListObjects(ctx context.Context, bucket, prefix string) ([]stor
 	result, _, err := c.client.Bucket.Get(ctx, opt)
 	if err != nil {
 		c.log.Errorf("Failed to list objects: %v", err)
-		return nil, err
+		return nil, wrapError(err)
 	}
 
 	objects := make([]storage.Object, len(result.Contents))
@@ -108,7 +110,7 @@ func (c *Client) UploadObject(ctx context.Context, bucket, key string, reader io
 	_, err := c.client.Object.Put(ctx, key, reader, nil)
 	if err != nil {
 		c.log.Errorf("Failed to upload object: %v", err)
-		return err
+		return wrapError(err)
 	}
 	
 	c.log.Infof("Successfully uploaded object %s", key)
@@ -122,7 +124,7 @@ func (c *Client) DownloadObject(ctx context.Context, bucket, key string, writer
 	resp, err := c.client.Object.Get(ctx, key, nil)
 	if err != nil {
 		c.log.Errorf("Failed to download object: %v", err)
-		return err
+		return wrapError(err)
 	}
 	defer resp.Body.Close()
 
@@ -143,9 +145,52 @@ func (c *Client) DeleteObject(ctx context.Context, bucket, key string) error {
 	_, err := c.client.Object.Delete(ctx, key)
 	if err != nil {
 		c.log.Errorf("Failed to delete object: %v", err)
-		return err
+		return wrapError(err)
 	}
 	
 	c.log.Infof("Successfully deleted object %s", key)
 	return nil
 }
+
+// GetBucketRegion implements storage.Provider
+func (c *Client) GetBucketRegion(ctx context.Context, bucket string) (string, error) {
+	c.log.Debugf("Getting location of bucket %s", bucket)
+
+	result, _, err := c.client.Bucket.GetLocation(ctx)
+	if err != nil {
+		c.log.Errorf("Failed to get bucket location: %v", err)
+		return "", wrapError(err)
+	}
+	return result.Location, nil
+}
+
+// Endpoint implements storage.Provider
+func (c *Client) Endpoint() string {
+	return c.client.BaseURL.BucketURL.String()
+}
+
+// bucketURL returns the URL of the configured bucket. The endpoint defaults to the one
+// of the region, and may carry an http:// scheme.
+func bucketURL(config *storage.Config) string {
+	if config.Endpoint == "" {
+		return fmt.Sprintf("https://%s.cos.%s.myqcloud.com", config.BucketName, config.Region)
+	}
+	scheme, host := "https", config.Endpoint
+	if i := strings.Index(host, "://"); i >= 0 {
+		scheme, host = host[:i], host[i+3:]
+	}
+	return fmt.Sprintf("%s://%s.%s", scheme, config.BucketName, strings.TrimSuffix(host, "/"))
+}
+
+// wrapError wraps a COS error response into a storage.Error
+func wrapError(err error) error {
+	var resp *cos.ErrorResponse
+	if !errors.As(err, &resp) {
+		return err
+	}
+	e := &storage.Error{Code: resp.Code, Err: err}
+	if resp.Response != nil {
+		e.StatusCode = resp.Response.StatusCode
+	}
+	return e
+}
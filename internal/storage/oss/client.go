@@ -7,7 +7,9 @@ package oss
 
 import (
 	"context"
+	"errors"
 	"io"
+	"strings"
 
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
 	"github.com/THCloudAI/thctl/internal/storage"
@@ -47,7 +49,7 @@ func (c *Client) ListBuckets(ctx context.Context) ([]storage.Bucket, error) {
 	result, err := c.client.ListBuckets()
 	if err != nil {
 		c.log.Errorf("Failed to list buckets: %v", err)
-		return nil, err
+		return nil, wrapError(err)
 	}
 
 	buckets := make([]storage.Bucket, len(result.Buckets))
@@ -76,7 +78,7 @@ func (c *Client) ListObjects(ctx context.Context, bucket, prefix string) ([]stor
 	lsRes, err := b.ListObjects(oss.Prefix(prefix))
 	if err != nil {
 		c.log.Errorf("Failed to list objects: %v", err)
-		return nil, err
+		return nil, wrapError(err)
 	}
 
 	objects := make([]storage.Object, len(lsRes.Objects))
@@ -106,7 +108,7 @@ func (c *Client) UploadObject(ctx context.Context, bucket, key string, reader io
 	err = b.PutObject(key, reader)
 	if err != nil {
 		c.log.Errorf("Failed to upload object: %v", err)
-		return err
+		return wrapError(err)
 	}
 	
 	c.log.Infof("Successfully uploaded object %s", key)
@@ -126,7 +128,7 @@ func (c *Client) DownloadObject(ctx context.Context, bucket, key string, writer
 	body, err := b.GetObject(key)
 	if err != nil {
 		c.log.Errorf("Failed to get object: %v", err)
-		return err
+		return wrapError(err)
 	}
 	defer body.Close()
 
@@ -153,9 +155,40 @@ func (c *Client) DeleteObject(ctx context.Context, bucket, key string) error {
 	err = b.DeleteObject(key)
 	if err != nil {
 		c.log.Errorf("Failed to delete object: %v", err)
-		return err
+		return wrapError(err)
 	}
 	
 	c.log.Infof("Successfully deleted object %s", key)
 	return nil
 }
+
+// GetBucketRegion implements storage.Provider. OSS reports locations such as
+// oss-cn-hangzhou; the region is returned without the oss- prefix.
+func (c *Client) GetBucketRegion(ctx context.Context, bucket string) (string, error) {
+	c.log.Debugf("Getting location of bucket %s", bucket)
+
+	location, err := c.client.GetBucketLocation(bucket, oss.WithContext(ctx))
+	if err != nil {
+		c.log.Errorf("Failed to get bucket location: %v", err)
+		return "", wrapError(err)
+	}
+	return strings.TrimPrefix(location, "oss-"), nil
+}
+
+// Endpoint implements storage.Provider
+func (c *Client) Endpoint() string {
+	endpoint := c.client.Config.Endpoint
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+	return endpoint
+}
+
+// wrapError wraps an OSS service error into a storage.Error
+func wrapError(err error) error {
+	var resp oss.ServiceError
+	if !errors.As(err, &resp) {
+		return err
+	}
+	return &storage.Error{Code: resp.Code, StatusCode: resp.StatusCode, Err: err}
+}
@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Operations run against the probe object, in order
+const (
+	OperationList   = "list"
+	OperationPut    = "put"
+	OperationGet    = "get"
+	OperationDelete = "delete"
+)
+
+// probePrefix is the key prefix of the temporary objects written by Probe
+const probePrefix = "thctl-doctor-probe-"
+
+// ProbeReport is the outcome of probing a storage provider. Latencies are in
+// milliseconds, the clock skew is the provider clock minus the local one.
+type ProbeReport struct {
+	Endpoint         string           `json:"endpoint"`
+	Bucket           string           `json:"bucket"`
+	Region           string           `json:"region"`
+	ClockSkewSeconds float64          `json:"clockSkewSeconds"`
+	ClockError       string           `json:"clockError,omitempty"`
+	Authenticated    bool             `json:"authenticated"`
+	AuthError        string           `json:"authError,omitempty"`
+	ListBucketsError string           `json:"listBucketsError,omitempty"`
+	BucketFound      bool             `json:"bucketFound"`
+	BucketRegion     string           `json:"bucketRegion,omitempty"`
+	BucketError      string           `json:"bucketError,omitempty"`
+	ProbeKey         string           `json:"probeKey,omitempty"`
+	Cleaned          bool             `json:"cleaned"`
+	Operations       []ProbeOperation `json:"operations"`
+}
+
+// ProbeOperation is the outcome of one operation on the probe object
+type ProbeOperation struct {
+	Name      string  `json:"name"`
+	OK        bool    `json:"ok"`
+	LatencyMs float64 `json:"latencyMs"`
+	Code      string  `json:"code,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// RegionMatches reports whether the bucket is in the configured region. It is true when
+// either is unknown.
+func (r *ProbeReport) RegionMatches() bool {
+	return r.Region == "" || r.BucketRegion == "" || strings.EqualFold(r.Region, r.BucketRegion)
+}
+
+// Probe checks that the provider accepts the credentials of cfg, that the configured
+// bucket exists and in which region, and that objects can be listed, written, read and
+// deleted in it. The object written is deleted again. Probe stops early when the
+// credentials are rejected or the bucket does not exist, as every later check would fail
+// the same way.
+func Probe(ctx context.Context, p Provider, cfg *Config) *ProbeReport {
+	report := &ProbeReport{
+		Endpoint:   p.Endpoint(),
+		Bucket:     cfg.BucketName,
+		Region:     cfg.Region,
+		Operations: make([]ProbeOperation, 0),
+	}
+
+	// A skewed clock makes the provider reject signatures, so measure it first
+	skew, err := ClockSkew(ctx, report.Endpoint)
+	if err != nil {
+		report.ClockError = err.Error()
+	} else {
+		report.ClockSkewSeconds = skew.Seconds()
+	}
+
+	if _, err := p.ListBuckets(ctx); err != nil {
+		// Credentials scoped to a bucket may not list buckets but are still valid
+		if ErrorCode(err) == "" || IsCredentialError(err) {
+			report.AuthError = err.Error()
+			return report
+		}
+		report.ListBucketsError = err.Error()
+	}
+	report.Authenticated = true
+
+	region, err := p.GetBucketRegion(ctx, cfg.BucketName)
+	switch {
+	case err == nil:
+		report.BucketFound = true
+		report.BucketRegion = region
+	case ErrorCode(err) == "NoSuchBucket":
+		report.BucketError = err.Error()
+		return report
+	default:
+		// Reading the location may be denied; the object operations tell whether the bucket exists
+		report.BucketError = err.Error()
+	}
+
+	report.ProbeKey = fmt.Sprintf("%s%d", probePrefix, time.Now().UnixNano())
+	content := []byte(fmt.Sprintf("thctl doctor probe written at %s\n", time.Now().UTC().Format(time.RFC3339)))
+
+	report.run(OperationList, func() error {
+		_, err := p.ListObjects(ctx, cfg.BucketName, probePrefix)
+		return err
+	})
+	written := report.run(OperationPut, func() error {
+		return p.UploadObject(ctx, cfg.BucketName, report.ProbeKey, bytes.NewReader(content))
+	})
+	if !written {
+		report.ProbeKey = ""
+		return report
+	}
+	report.run(OperationGet, func() error {
+		var buf bytes.Buffer
+		if err := p.DownloadObject(ctx, cfg.BucketName, report.ProbeKey, &buf); err != nil {
+			return err
+		}
+		if !bytes.Equal(buf.Bytes(), content) {
+			return fmt.Errorf("read back %d bytes that differ from the %d bytes written", buf.Len(), len(content))
+		}
+		return nil
+	})
+	report.Cleaned = report.run(OperationDelete, func() error {
+		return p.DeleteObject(ctx, cfg.BucketName, report.ProbeKey)
+	})
+	return report
+}
+
+// run times an operation and records its outcome, returning whether it succeeded
+func (r *ProbeReport) run(name string, op func() error) bool {
+	start := time.Now()
+	err := op()
+	result := ProbeOperation{
+		Name:      name,
+		OK:        err == nil,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Code = ErrorCode(err)
+		result.Error = err.Error()
+	}
+	r.Operations = append(r.Operations, result)
+	return result.OK
+}
+
+// ClockSkew estimates how far the clock of the server at url is ahead of the local one,
+// from the Date header of an unauthenticated HEAD request. The Date header has a
+// resolution of a second, so the estimate is within about half a second.
+func ClockSkew(ctx context.Context, url string) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reach %s: %w", url, err)
+	}
+	resp.Body.Close()
+	elapsed := time.Since(start)
+
+	date := resp.Header.Get("Date")
+	if date == "" {
+		return 0, fmt.Errorf("%s returned no Date header", url)
+	}
+	serverTime, err := http.ParseTime(date)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Date header %q: %w", date, err)
+	}
+
+	// The server stamped the response about halfway through the round trip, and
+	// truncated the time to the second
+	local := start.Add(elapsed / 2)
+	return serverTime.Add(500 * time.Millisecond).Sub(local).Round(100 * time.Millisecond), nil
+}
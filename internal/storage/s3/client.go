@@ -0,0 +1,191 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/THCloudAI/thctl/internal/storage"
+	"github.com/THCloudAI/thctl/pkg/framework/logger"
+)
+
+// Client implements the storage.Provider interface for S3 compatible services
+type Client struct {
+	client *minio.Client
+	config *storage.Config
+	log    *logger.Logger
+}
+
+// NewClient creates a new S3 client. The endpoint is a host name and may carry an
+// http:// scheme to disable TLS.
+func NewClient(config *storage.Config) (*Client, error) {
+	log := logger.WithModule("s3")
+
+	client, err := newMinioClient(config, config.Region)
+	if err != nil {
+		log.Errorf("Failed to create S3 client: %v", err)
+		return nil, err
+	}
+
+	log.Infof("Created S3 client for endpoint %s", config.Endpoint)
+
+	return &Client{
+		client: client,
+		config: config,
+		log:    log,
+	}, nil
+}
+
+// newMinioClient creates a client for the configured endpoint signing for region, or
+// looking up the region of each bucket when it is empty
+func newMinioClient(config *storage.Config, region string) (*minio.Client, error) {
+	host, secure := config.Endpoint, true
+	if strings.HasPrefix(host, "http://") {
+		host, secure = strings.TrimPrefix(host, "http://"), false
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "https://"), "/")
+
+	return minio.New(host, &minio.Options{
+		Creds:  credentials.NewStaticV4(config.AccessKey, config.SecretKey, ""),
+		Secure: secure,
+		Region: region,
+	})
+}
+
+// ListBuckets implements storage.Provider
+func (c *Client) ListBuckets(ctx context.Context) ([]storage.Bucket, error) {
+	c.log.Debug("Listing buckets")
+
+	result, err := c.client.ListBuckets(ctx)
+	if err != nil {
+		c.log.Errorf("Failed to list buckets: %v", err)
+		return nil, wrapError(err)
+	}
+
+	buckets := make([]storage.Bucket, len(result))
+	for i, b := range result {
+		buckets[i] = storage.Bucket{
+			Name:         b.Name,
+			CreationDate: b.CreationDate.String(),
+		}
+	}
+
+	c.log.Infof("Listed %d buckets", len(buckets))
+	return buckets, nil
+}
+
+// ListObjects implements storage.Provider
+func (c *Client) ListObjects(ctx context.Context, bucket, prefix string) ([]storage.Object, error) {
+	c.log.Debugf("Listing objects in bucket %s with prefix %s", bucket, prefix)
+
+	objects := make([]storage.Object, 0)
+	for obj := range c.client.ListObjects(ctx, bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			c.log.Errorf("Failed to list objects: %v", obj.Err)
+			return nil, wrapError(obj.Err)
+		}
+		objects = append(objects, storage.Object{
+			Key:          obj.Key,
+			Size:         obj.Size,
+			LastModified: obj.LastModified.String(),
+			ETag:         obj.ETag,
+		})
+	}
+
+	c.log.Infof("Listed %d objects", len(objects))
+	return objects, nil
+}
+
+// UploadObject implements storage.Provider
+func (c *Client) UploadObject(ctx context.Context, bucket, key string, reader io.Reader) error {
+	c.log.Debugf("Uploading object %s to bucket %s", key, bucket)
+
+	// A known size lets small objects go up in a single request
+	size := int64(-1)
+	if r, ok := reader.(interface{ Len() int }); ok {
+		size = int64(r.Len())
+	}
+
+	_, err := c.client.PutObject(ctx, bucket, key, reader, size, minio.PutObjectOptions{})
+	if err != nil {
+		c.log.Errorf("Failed to upload object: %v", err)
+		return wrapError(err)
+	}
+
+	c.log.Infof("Successfully uploaded object %s", key)
+	return nil
+}
+
+// DownloadObject implements storage.Provider
+func (c *Client) DownloadObject(ctx context.Context, bucket, key string, writer io.Writer) error {
+	c.log.Debugf("Downloading object %s from bucket %s", key, bucket)
+
+	obj, err := c.client.GetObject(ctx, bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		c.log.Errorf("Failed to get object: %v", err)
+		return wrapError(err)
+	}
+	defer obj.Close()
+
+	// The request is only sent on the first read
+	_, err = io.Copy(writer, obj)
+	if err != nil {
+		c.log.Errorf("Failed to write object data: %v", err)
+		return wrapError(err)
+	}
+
+	c.log.Infof("Successfully downloaded object %s", key)
+	return nil
+}
+
+// DeleteObject implements storage.Provider
+func (c *Client) DeleteObject(ctx context.Context, bucket, key string) error {
+	c.log.Debugf("Deleting object %s from bucket %s", key, bucket)
+
+	err := c.client.RemoveObject(ctx, bucket, key, minio.RemoveObjectOptions{})
+	if err != nil {
+		c.log.Errorf("Failed to delete object: %v", err)
+		return wrapError(err)
+	}
+
+	c.log.Infof("Successfully deleted object %s", key)
+	return nil
+}
+
+// GetBucketRegion implements storage.Provider
+func (c *Client) GetBucketRegion(ctx context.Context, bucket string) (string, error) {
+	c.log.Debugf("Getting location of bucket %s", bucket)
+
+	// A client with a region answers with that region without asking the service
+	client := c.client
+	if c.config.Region != "" {
+		var err error
+		if client, err = newMinioClient(c.config, ""); err != nil {
+			return "", err
+		}
+	}
+
+	region, err := client.GetBucketLocation(ctx, bucket)
+	if err != nil {
+		c.log.Errorf("Failed to get bucket location: %v", err)
+		return "", wrapError(err)
+	}
+	return region, nil
+}
+
+// Endpoint implements storage.Provider
+func (c *Client) Endpoint() string {
+	return c.client.EndpointURL().String()
+}
+
+// wrapError wraps an S3 error response into a storage.Error
+func wrapError(err error) error {
+	var resp minio.ErrorResponse
+	if !errors.As(err, &resp) {
+		return err
+	}
+	return &storage.Error{Code: resp.Code, StatusCode: resp.StatusCode, Err: err}
+}
@@ -7,6 +7,7 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"io"
 )
 
@@ -22,6 +23,10 @@ type Provider interface {
 	DownloadObject(ctx context.Context, bucket, key string, writer io.Writer) error
 	// DeleteObject deletes an object from the storage
 	DeleteObject(ctx context.Context, bucket, key string) error
+	// GetBucketRegion returns the region a bucket was created in
+	GetBucketRegion(ctx context.Context, bucket string) (string, error)
+	// Endpoint returns the base URL requests are sent to
+	Endpoint() string
 }
 
 // Bucket represents a storage bucket
@@ -47,3 +52,46 @@ type Config struct {
 	Endpoint   string `mapstructure:"endpoint"`
 	BucketName string `mapstructure:"bucket_name"`
 }
+
+// Error is an error returned by the storage service, with its error code such as
+// AccessDenied or NoSuchBucket
+type Error struct {
+	Code       string
+	StatusCode int
+	Err        error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// credentialErrorCodes are the error codes of requests whose credentials were rejected,
+// as opposed to authenticated requests that were denied
+var credentialErrorCodes = map[string]bool{
+	"InvalidAccessKeyId":    true,
+	"InvalidSecretId":       true,
+	"SignatureDoesNotMatch": true,
+	"RequestTimeTooSkewed":  true,
+	"ExpiredToken":          true,
+	"InvalidToken":          true,
+	"InvalidSecurityToken":  true,
+}
+
+// ErrorCode returns the service error code of err, or an empty string when the
+// service did not answer with one
+func ErrorCode(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ""
+}
+
+// IsCredentialError reports whether the service rejected the credentials of the request
+func IsCredentialError(err error) bool {
+	return credentialErrorCodes[ErrorCode(err)]
+}
@@ -0,0 +1,165 @@
+package units
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ByteBase selects binary (IEC, powers of 1024) or decimal (SI, powers of 1000) byte units
+type ByteBase int
+
+// Byte unit systems
+const (
+	IEC ByteBase = iota
+	SI
+)
+
+var (
+	iecUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB", "ZiB", "YiB"}
+	siUnits  = []string{"B", "kB", "MB", "GB", "TB", "PB", "EB", "ZB", "YB"}
+)
+
+// String returns the name of the unit system
+func (b ByteBase) String() string {
+	if b == SI {
+		return "si"
+	}
+	return "iec"
+}
+
+// units returns the unit names and the factor between consecutive units
+func (b ByteBase) units() ([]string, int64) {
+	if b == SI {
+		return siUnits, 1000
+	}
+	return iecUnits, 1024
+}
+
+// Bytes is a size in bytes, such as a sector size or an amount of storage power.
+// Network power exceeds the range of uint64, so sizes are held as big integers and
+// encoded as decimal strings in JSON and YAML, the way Lotus encodes power.
+type Bytes struct {
+	n *big.Int
+}
+
+// NewBytes returns a size in bytes
+func NewBytes(n *big.Int) Bytes {
+	if n == nil {
+		return Bytes{new(big.Int)}
+	}
+	return Bytes{new(big.Int).Set(n)}
+}
+
+// BytesFromString parses a decimal byte count as returned by Lotus; anything
+// that is not a number is treated as zero
+func BytesFromString(s string) Bytes {
+	n, ok := new(big.Int).SetString(strings.TrimSpace(s), 10)
+	if !ok {
+		return Bytes{new(big.Int)}
+	}
+	return Bytes{n}
+}
+
+// ParseBytes parses a size with an optional IEC or SI unit, such as "32GiB",
+// "1.5 PiB", "500 GB" or "2048"
+func ParseBytes(s string) (Bytes, error) {
+	s = strings.TrimSpace(s)
+	split := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+
+	amount, unit := s, ""
+	if split >= 0 {
+		amount, unit = strings.TrimSpace(s[:split]), strings.TrimSpace(s[split:])
+	}
+
+	factor := big.NewInt(1)
+	if unit != "" {
+		found := false
+		for _, base := range []ByteBase{IEC, SI} {
+			names, step := base.units()
+			for i, name := range names {
+				if strings.EqualFold(name, unit) {
+					factor.Exp(big.NewInt(step), big.NewInt(int64(i)), nil)
+					found = true
+					break
+				}
+			}
+			if found {
+				break
+			}
+		}
+		if !found {
+			return Bytes{}, fmt.Errorf("invalid size %q: unknown unit %q", s, unit)
+		}
+	}
+
+	r, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return Bytes{}, fmt.Errorf("invalid size: %q", s)
+	}
+	r.Mul(r, new(big.Rat).SetInt(factor))
+	if !r.IsInt() {
+		return Bytes{}, fmt.Errorf("size %q is not a whole number of bytes", s)
+	}
+	return Bytes{new(big.Int).Set(r.Num())}, nil
+}
+
+// Int returns the size in bytes, treating an unset size as zero
+func (b Bytes) Int() *big.Int {
+	if b.n == nil {
+		return new(big.Int)
+	}
+	return b.n
+}
+
+// String formats the size with the current display settings
+func (b Bytes) String() string {
+	return CurrentDisplay().Bytes(b)
+}
+
+// MarshalJSON encodes the size as a decimal string
+func (b Bytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.Int().String())
+}
+
+// UnmarshalJSON decodes a size from a string or a number
+func (b *Bytes) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		b.n = new(big.Int)
+		return nil
+	}
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return fmt.Errorf("invalid byte count: %s", data)
+	}
+	b.n = n
+	return nil
+}
+
+// MarshalYAML encodes the size as a decimal string
+func (b Bytes) MarshalYAML() (interface{}, error) {
+	return b.Int().String(), nil
+}
+
+// formatBytes formats a size in the largest unit of base in which it is at least one
+func formatBytes(n *big.Int, base ByteBase, precision int) string {
+	names, step := base.units()
+	value := new(big.Rat).SetInt(n)
+	abs := new(big.Rat).Abs(value)
+	factor := new(big.Rat).SetInt64(step)
+
+	unit := 0
+	for abs.Cmp(factor) >= 0 && unit < len(names)-1 {
+		value.Quo(value, factor)
+		abs.Quo(abs, factor)
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%s %s", n.String(), names[0])
+	}
+	return fmt.Sprintf("%s %s", value.FloatString(precision), names[unit])
+}
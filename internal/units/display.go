@@ -0,0 +1,159 @@
+package units
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Default number of decimals shown for FIL amounts and byte sizes
+const (
+	DefaultFILPrecision   = 6
+	DefaultBytesPrecision = 2
+)
+
+// Display controls how FIL amounts and byte sizes are formatted for humans.
+// Machine readable output (JSON, YAML) always uses attoFIL and bytes.
+type Display struct {
+	// FILUnit is the denomination FIL amounts are shown in, unless AutoFIL is set
+	FILUnit FILUnit
+	// AutoFIL shows each amount in the largest denomination in which it is at least one
+	AutoFIL bool
+	// FILPrecision is the number of decimals shown for FIL amounts
+	FILPrecision int
+	// ByteBase selects IEC (KiB, MiB) or SI (kB, MB) byte units
+	ByteBase ByteBase
+	// BytesPrecision is the number of decimals shown for byte sizes
+	BytesPrecision int
+	// Testnet shows amounts as tFIL
+	Testnet bool
+}
+
+var (
+	displayMu sync.RWMutex
+	display   = DefaultDisplay()
+)
+
+// DefaultDisplay returns the display settings used unless configured otherwise
+func DefaultDisplay() Display {
+	return Display{
+		FILUnit:        WholeFIL,
+		FILPrecision:   DefaultFILPrecision,
+		ByteBase:       IEC,
+		BytesPrecision: DefaultBytesPrecision,
+	}
+}
+
+// CurrentDisplay returns the display settings used by FIL.String and Bytes.String
+func CurrentDisplay() Display {
+	displayMu.RLock()
+	defer displayMu.RUnlock()
+	return display
+}
+
+// SetDisplay replaces the display settings used by FIL.String and Bytes.String
+func SetDisplay(d Display) {
+	displayMu.Lock()
+	defer displayMu.Unlock()
+	display = d
+}
+
+// SetTestnet selects whether amounts are shown as tFIL
+func SetTestnet(testnet bool) {
+	displayMu.Lock()
+	defer displayMu.Unlock()
+	display.Testnet = testnet
+}
+
+// FIL formats an amount of FIL
+func (d Display) FIL(f FIL) string {
+	unit := d.FILUnit
+	if d.AutoFIL {
+		unit = autoFILUnit(f.Atto())
+	}
+	precision := d.FILPrecision
+	if unit == AttoFIL {
+		precision = 0
+	}
+	return formatFIL(f.Atto(), unit, precision, d.Testnet)
+}
+
+// Bytes formats a size in bytes
+func (d Display) Bytes(b Bytes) string {
+	return formatBytes(b.Int(), d.ByteBase, d.BytesPrecision)
+}
+
+// UnitsFlag is a flag value selecting the units of human readable output. It takes a
+// comma separated list of a FIL denomination (fil, millifil, ..., attofil) or "auto",
+// and a byte unit system (iec or si), and applies it to the current display settings.
+type UnitsFlag struct {
+	value string
+}
+
+// String returns the flag value
+func (f *UnitsFlag) String() string {
+	return f.value
+}
+
+// Set parses the flag value and applies it to the current display settings
+func (f *UnitsFlag) Set(value string) error {
+	d := CurrentDisplay()
+	for _, part := range strings.Split(value, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		switch part {
+		case "":
+		case "auto":
+			d.AutoFIL = true
+		case "iec":
+			d.ByteBase = IEC
+		case "si":
+			d.ByteBase = SI
+		default:
+			unit, err := ParseFILUnit(part)
+			if err != nil {
+				return fmt.Errorf("unknown unit %q (use fil, millifil, microfil, nanofil, picofil, femtofil, attofil, auto, iec or si)", part)
+			}
+			d.FILUnit, d.AutoFIL = unit, false
+		}
+	}
+	SetDisplay(d)
+	f.value = value
+	return nil
+}
+
+// Type returns the flag type shown in help output
+func (f *UnitsFlag) Type() string {
+	return "units"
+}
+
+// PrecisionFlag is a flag value setting the number of decimals of FIL amounts
+type PrecisionFlag struct {
+	value string
+}
+
+// String returns the flag value
+func (f *PrecisionFlag) String() string {
+	if f.value == "" {
+		return strconv.Itoa(DefaultFILPrecision)
+	}
+	return f.value
+}
+
+// Set parses the flag value and applies it to the current display settings
+func (f *PrecisionFlag) Set(value string) error {
+	precision, err := strconv.Atoi(value)
+	if err != nil || precision < 0 || precision > 18 {
+		return fmt.Errorf("precision must be between 0 and 18")
+	}
+	d := CurrentDisplay()
+	d.FILPrecision = precision
+	SetDisplay(d)
+	f.value = value
+	return nil
+}
+
+// Type returns the flag type shown in help output
+func (f *PrecisionFlag) Type() string {
+	return "int"
+}
@@ -0,0 +1,189 @@
+package units
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// FILUnit is a denomination of FIL, identified by its power of ten relative to attoFIL
+type FILUnit int
+
+// FIL denominations
+const (
+	AttoFIL  FILUnit = 0
+	FemtoFIL FILUnit = 3
+	PicoFIL  FILUnit = 6
+	NanoFIL  FILUnit = 9
+	MicroFIL FILUnit = 12
+	MilliFIL FILUnit = 15
+	WholeFIL FILUnit = 18
+)
+
+// filUnitNames maps each denomination to its display name
+var filUnitNames = map[FILUnit]string{
+	AttoFIL:  "attoFIL",
+	FemtoFIL: "femtoFIL",
+	PicoFIL:  "picoFIL",
+	NanoFIL:  "nanoFIL",
+	MicroFIL: "microFIL",
+	MilliFIL: "milliFIL",
+	WholeFIL: "FIL",
+}
+
+// filUnitsDescending lists the denominations from largest to smallest
+var filUnitsDescending = []FILUnit{WholeFIL, MilliFIL, MicroFIL, NanoFIL, PicoFIL, FemtoFIL, AttoFIL}
+
+// String returns the name of the unit
+func (u FILUnit) String() string {
+	if name, ok := filUnitNames[u]; ok {
+		return name
+	}
+	return fmt.Sprintf("FILUnit(%d)", int(u))
+}
+
+// scale returns the number of attoFIL in one unit
+func (u FILUnit) scale() *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(u)), nil)
+}
+
+// ParseFILUnit parses a unit name such as "FIL", "milliFIL" or "attofil". The
+// testnet spelling ("tFIL", "nanotFIL") is accepted too.
+func ParseFILUnit(s string) (FILUnit, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	name = strings.Replace(name, "tfil", "fil", 1)
+	for unit, unitName := range filUnitNames {
+		if strings.ToLower(unitName) == name {
+			return unit, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown FIL unit: %q", s)
+}
+
+// FIL is an amount of FIL held as attoFIL. It is encoded as a decimal attoFIL
+// string in JSON and YAML, the way Lotus encodes token amounts.
+type FIL struct {
+	atto *big.Int
+}
+
+// NewFIL returns an amount of attoFIL
+func NewFIL(atto *big.Int) FIL {
+	if atto == nil {
+		return FIL{new(big.Int)}
+	}
+	return FIL{new(big.Int).Set(atto)}
+}
+
+// FILFromAtto parses a decimal attoFIL string as returned by Lotus; anything
+// that is not a number is treated as zero
+func FILFromAtto(s string) FIL {
+	atto, ok := new(big.Int).SetString(strings.TrimSpace(s), 10)
+	if !ok {
+		return FIL{new(big.Int)}
+	}
+	return FIL{atto}
+}
+
+// ParseFIL parses an amount with an optional unit, such as "1.5", "1.5 FIL",
+// "200 nanoFIL" or "3tFIL". Amounts without a unit are in FIL. The amount must
+// be a whole number of attoFIL.
+func ParseFIL(s string) (FIL, error) {
+	s = strings.TrimSpace(s)
+	split := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '-' && r != '+'
+	})
+
+	amount, unit := s, WholeFIL
+	if split >= 0 {
+		amount = strings.TrimSpace(s[:split])
+		var err error
+		if unit, err = ParseFILUnit(strings.TrimSpace(s[split:])); err != nil {
+			return FIL{}, fmt.Errorf("invalid FIL amount %q: %v", s, err)
+		}
+	}
+
+	r, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return FIL{}, fmt.Errorf("invalid FIL amount: %q", s)
+	}
+	r.Mul(r, new(big.Rat).SetInt(unit.scale()))
+	if !r.IsInt() {
+		return FIL{}, fmt.Errorf("FIL amount %q is not a whole number of attoFIL", s)
+	}
+	return FIL{new(big.Int).Set(r.Num())}, nil
+}
+
+// Atto returns the amount in attoFIL, treating an unset amount as zero
+func (f FIL) Atto() *big.Int {
+	if f.atto == nil {
+		return new(big.Int)
+	}
+	return f.atto
+}
+
+// String formats the amount with the current display settings
+func (f FIL) String() string {
+	return CurrentDisplay().FIL(f)
+}
+
+// MarshalJSON encodes the amount as a decimal attoFIL string
+func (f FIL) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.Atto().String())
+}
+
+// UnmarshalJSON decodes an attoFIL amount from a string or a number
+func (f *FIL) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		f.atto = new(big.Int)
+		return nil
+	}
+	atto, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return fmt.Errorf("invalid attoFIL amount: %s", data)
+	}
+	f.atto = atto
+	return nil
+}
+
+// MarshalYAML encodes the amount as a decimal attoFIL string
+func (f FIL) MarshalYAML() (interface{}, error) {
+	return f.Atto().String(), nil
+}
+
+// Add returns the sum of two amounts
+func (f FIL) Add(other FIL) FIL {
+	return FIL{new(big.Int).Add(f.Atto(), other.Atto())}
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of the amount
+func (f FIL) Sign() int {
+	return f.Atto().Sign()
+}
+
+// Cmp compares two amounts like big.Int.Cmp
+func (f FIL) Cmp(other FIL) int {
+	return f.Atto().Cmp(other.Atto())
+}
+
+// formatFIL formats an amount in the given unit with a fixed number of decimals
+func formatFIL(atto *big.Int, unit FILUnit, precision int, testnet bool) string {
+	r := new(big.Rat).SetFrac(atto, unit.scale())
+	name := unit.String()
+	if testnet {
+		name = strings.Replace(name, "FIL", "tFIL", 1)
+	}
+	return fmt.Sprintf("%s %s", r.FloatString(precision), name)
+}
+
+// autoFILUnit picks the largest unit in which the amount is at least one
+func autoFILUnit(atto *big.Int) FILUnit {
+	abs := new(big.Int).Abs(atto)
+	for _, unit := range filUnitsDescending {
+		if abs.Cmp(unit.scale()) >= 0 {
+			return unit
+		}
+	}
+	return WholeFIL
+}
@@ -0,0 +1,226 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/filecoin-project/go-address"
+	secp "github.com/filecoin-project/go-crypto"
+	"github.com/filecoin-project/go-state-types/crypto"
+	filaddr "github.com/THCloudAI/thctl/internal/address"
+	bls "github.com/kilic/bls12-381"
+	"golang.org/x/crypto/blake2b"
+)
+
+// Key types, named as in Lotus key exports
+const (
+	KeyTypeSecp256k1 = "secp256k1"
+	KeyTypeBLS       = "bls"
+)
+
+// blsDomain is the domain separation tag of Filecoin BLS signatures
+var blsDomain = []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_NUL_")
+
+// blsOrder is the order of the BLS12-381 scalar field
+var blsOrder, _ = new(big.Int).SetString("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001", 16)
+
+// KeyInfo is a private key in the format used by Lotus key exports
+type KeyInfo struct {
+	Type       string `json:"Type"`
+	PrivateKey []byte `json:"PrivateKey"`
+}
+
+// GenerateKey creates a new random key of the given type
+func GenerateKey(keyType string) (*KeyInfo, error) {
+	switch keyType {
+	case KeyTypeSecp256k1:
+		sk, err := secp.GenerateKey()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate secp256k1 key: %w", err)
+		}
+		return &KeyInfo{Type: KeyTypeSecp256k1, PrivateKey: sk}, nil
+	case KeyTypeBLS:
+		// Reduce 64 random bytes so the scalar is uniform modulo the field order
+		seed := make([]byte, 64)
+		if _, err := rand.Read(seed); err != nil {
+			return nil, fmt.Errorf("failed to generate bls key: %w", err)
+		}
+		scalar := new(big.Int).Mod(new(big.Int).SetBytes(seed), blsOrder)
+		if scalar.Sign() == 0 {
+			return nil, fmt.Errorf("failed to generate bls key: zero scalar")
+		}
+		return &KeyInfo{Type: KeyTypeBLS, PrivateKey: blsScalarToKey(scalar)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", keyType)
+	}
+}
+
+// ParseKeyInfo parses a key as exported by `lotus wallet export` (hex encoded JSON) or as plain JSON
+func ParseKeyInfo(data []byte) (*KeyInfo, error) {
+	data = bytes.TrimSpace(data)
+	if decoded, err := hex.DecodeString(string(data)); err == nil {
+		data = decoded
+	}
+
+	var ki KeyInfo
+	if err := json.Unmarshal(data, &ki); err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+	ki.Type = strings.ToLower(ki.Type)
+	if err := ki.validate(); err != nil {
+		return nil, err
+	}
+	return &ki, nil
+}
+
+// Export encodes the key in the hex format used by `lotus wallet export`
+func (ki *KeyInfo) Export() (string, error) {
+	data, err := json.Marshal(ki)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
+
+// validate checks that the private key has the size its type requires
+func (ki *KeyInfo) validate() error {
+	switch ki.Type {
+	case KeyTypeSecp256k1, KeyTypeBLS:
+	default:
+		return fmt.Errorf("unsupported key type: %s", ki.Type)
+	}
+	if len(ki.PrivateKey) != 32 {
+		return fmt.Errorf("invalid %s private key length: %d", ki.Type, len(ki.PrivateKey))
+	}
+	if ki.Type == KeyTypeBLS {
+		if scalar := blsKeyToScalar(ki.PrivateKey); scalar.Sign() == 0 || scalar.Cmp(blsOrder) >= 0 {
+			return fmt.Errorf("invalid bls private key")
+		}
+	}
+	return nil
+}
+
+// PublicKey returns the public key of the key: an uncompressed secp256k1 point or a compressed G1 point
+func (ki *KeyInfo) PublicKey() ([]byte, error) {
+	if err := ki.validate(); err != nil {
+		return nil, err
+	}
+	if ki.Type == KeyTypeSecp256k1 {
+		return secp.PublicKey(ki.PrivateKey), nil
+	}
+	g1 := bls.NewG1()
+	return g1.ToCompressed(g1.MulScalarBig(g1.New(), g1.One(), blsKeyToScalar(ki.PrivateKey))), nil
+}
+
+// Address returns the address controlled by the key
+func (ki *KeyInfo) Address() (address.Address, error) {
+	pub, err := ki.PublicKey()
+	if err != nil {
+		return address.Undef, err
+	}
+	if ki.Type == KeyTypeSecp256k1 {
+		return address.NewSecp256k1Address(pub)
+	}
+	return address.NewBLSAddress(pub)
+}
+
+// Sign signs data the way Lotus does: secp256k1 keys sign the blake2b-256 digest of the
+// data, BLS keys sign the data itself
+func (ki *KeyInfo) Sign(data []byte) (*crypto.Signature, error) {
+	if err := ki.validate(); err != nil {
+		return nil, err
+	}
+
+	if ki.Type == KeyTypeSecp256k1 {
+		digest := blake2b.Sum256(data)
+		sig, err := secp.Sign(ki.PrivateKey, digest[:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign: %w", err)
+		}
+		return &crypto.Signature{Type: crypto.SigTypeSecp256k1, Data: sig}, nil
+	}
+
+	g2 := bls.NewG2()
+	point, err := g2.HashToCurve(data, blsDomain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %w", err)
+	}
+	sig := g2.MulScalarBig(g2.New(), point, blsKeyToScalar(ki.PrivateKey))
+	return &crypto.Signature{Type: crypto.SigTypeBLS, Data: g2.ToCompressed(sig)}, nil
+}
+
+// Verify checks that sig is a signature of data by the key behind addr
+func Verify(sig *crypto.Signature, addr address.Address, data []byte) error {
+	switch sig.Type {
+	case crypto.SigTypeSecp256k1:
+		if addr.Protocol() != address.SECP256K1 {
+			return fmt.Errorf("secp256k1 signature for %s address %s", filaddr.ProtocolName(addr), addr)
+		}
+		digest := blake2b.Sum256(data)
+		pub, err := secp.EcRecover(digest[:], sig.Data)
+		if err != nil {
+			return fmt.Errorf("invalid signature: %w", err)
+		}
+		signer, err := address.NewSecp256k1Address(pub)
+		if err != nil {
+			return err
+		}
+		if signer != addr {
+			return fmt.Errorf("signature was made by %s, not %s", signer, addr)
+		}
+		return nil
+
+	case crypto.SigTypeBLS:
+		if addr.Protocol() != address.BLS {
+			return fmt.Errorf("bls signature for %s address %s", filaddr.ProtocolName(addr), addr)
+		}
+		g1, g2 := bls.NewG1(), bls.NewG2()
+		pub, err := g1.FromCompressed(addr.Payload())
+		if err != nil {
+			return fmt.Errorf("invalid bls public key: %w", err)
+		}
+		point, err := g2.FromCompressed(sig.Data)
+		if err != nil {
+			return fmt.Errorf("invalid signature: %w", err)
+		}
+		hash, err := g2.HashToCurve(data, blsDomain)
+		if err != nil {
+			return err
+		}
+		// e(pk, H(m)) == e(g1, sig)
+		engine := bls.NewEngine()
+		engine.AddPair(pub, hash)
+		engine.AddPairInv(g1.One(), point)
+		if !engine.Check() {
+			return fmt.Errorf("signature does not match %s", addr)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported signature type: %d", sig.Type)
+	}
+}
+
+// blsKeyToScalar decodes a BLS private key, which Lotus serializes little-endian
+func blsKeyToScalar(key []byte) *big.Int {
+	be := make([]byte, len(key))
+	for i := range key {
+		be[len(key)-1-i] = key[i]
+	}
+	return new(big.Int).SetBytes(be)
+}
+
+// blsScalarToKey encodes a BLS scalar as a 32 byte little-endian private key
+func blsScalarToKey(scalar *big.Int) []byte {
+	be := scalar.FillBytes(make([]byte, 32))
+	key := make([]byte, 32)
+	for i := range be {
+		key[31-i] = be[i]
+	}
+	return key
+}
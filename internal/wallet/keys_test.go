@@ -0,0 +1,242 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/crypto"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// Key and signature vectors exported from the Lotus signers: secp256k1 through
+// lotus/lib/sigs/secp, BLS through blst (the backend of filecoin-ffi) with the
+// Filecoin domain separation tag and little-endian private keys.
+//
+// Lotus draws the secp256k1 nonce from crypto/rand, so only BLS signatures are
+// deterministic and compared byte for byte; secp256k1 signatures are checked by
+// recovering the signer.
+var keyVectors = []struct {
+	name    string
+	keyType string
+	key     string
+	address string
+	// cid and signature of vectorMessage sent from address
+	cid           string
+	signature     string
+	deterministic bool
+}{
+	{
+		name:      "secp256k1",
+		keyType:   KeyTypeSecp256k1,
+		key:       "8a3c5d1e2f40b6c7d8e9fa0b1c2d3e4f5061728394a5b6c7d8e9f00112233445",
+		address:   "f1z6hvn6rrnuttjboqn6c3dpa6ys4dt4qr7jmuokq",
+		cid:       "bafy2bzacecxus2u6fk2vlpqtlivzhmnb4ybahwt4gjopusjqht7gua5s7bdyu",
+		signature: "5fa3baa0f87bfaa93fdcf1cb7a154baabca5096692ff7f29baa89e30d03c1ef5150ca36d51a0b3859176b6bad3f826051f6c694418057851789f74bb3930e5fc00",
+	},
+	{
+		name:          "bls",
+		keyType:       KeyTypeBLS,
+		key:           "0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20",
+		address:       "f3xdz2nqpdohj33oq2atts6p5lt3qe7hsxeom4643depowai2fsys6wrh6waw53cvitp2klg67tbm575idqxaa",
+		cid:           "bafy2bzacedn4pkpjuf4ceaa7uwufnsrtqjsjeqa5w7dzb4shyhteez22jwwce",
+		signature:     "922f62c29c1f63bac71f60867376b5eb61f5249cec4dc9beaf4b606cc307576d9a3242fa01b938faca9ea7e35f09ac0c193b878876cdfb6143eee5bf1eca71562c4c3ae0960dc259f24e4746160eed6d5dd275613dbf712b8525ea9d509a3896",
+		deterministic: true,
+	},
+}
+
+// vectorMessage is the message signed in keyVectors
+func vectorMessage(t *testing.T, from string) *types.Message {
+	t.Helper()
+	return &types.Message{
+		Version:    0,
+		To:         mustAddress(t, "f01234"),
+		From:       mustAddress(t, from),
+		Nonce:      7,
+		Value:      types.FromFil(1),
+		GasLimit:   1000000,
+		GasFeeCap:  abi.NewTokenAmount(100000),
+		GasPremium: abi.NewTokenAmount(1000),
+		Method:     0,
+	}
+}
+
+func mustAddress(t *testing.T, s string) address.Address {
+	t.Helper()
+	addr, err := address.NewFromString(s)
+	if err != nil {
+		t.Fatalf("invalid address %s: %v", s, err)
+	}
+	return addr
+}
+
+func vectorKey(t *testing.T, keyType, key string) *KeyInfo {
+	t.Helper()
+	pk, err := hex.DecodeString(key)
+	if err != nil {
+		t.Fatalf("invalid key hex: %v", err)
+	}
+	return &KeyInfo{Type: keyType, PrivateKey: pk}
+}
+
+func TestKeyAddress(t *testing.T) {
+	for _, v := range keyVectors {
+		t.Run(v.name, func(t *testing.T) {
+			addr, err := vectorKey(t, v.keyType, v.key).Address()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if addr != mustAddress(t, v.address) {
+				t.Errorf("address = %s, want %s", addr, v.address)
+			}
+		})
+	}
+}
+
+// TestKeyAddressSigningTools checks the secp256k1 key of the filecoin-signing-tools vectors
+func TestKeyAddressSigningTools(t *testing.T) {
+	pk, _ := base64.StdEncoding.DecodeString("8VcW07ADswS4BV2cxi5rnIadVsyTDDhY1NfDH19T8Uo=")
+	addr, err := (&KeyInfo{Type: KeyTypeSecp256k1, PrivateKey: pk}).Address()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := mustAddress(t, "t1d2xrzcslx7xlbbylc5c3d5lvandqw4iwl6epxba"); addr != want {
+		t.Errorf("address = %s, want %s", addr, want)
+	}
+}
+
+func TestSignMessage(t *testing.T) {
+	for _, v := range keyVectors {
+		t.Run(v.name, func(t *testing.T) {
+			msg := vectorMessage(t, v.address)
+			if got := msg.Cid().String(); got != v.cid {
+				t.Fatalf("message cid = %s, want %s", got, v.cid)
+			}
+
+			sm, err := SignMessage(vectorKey(t, v.keyType, v.key), msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hex.EncodeToString(sm.Signature.Data); v.deterministic && got != v.signature {
+				t.Errorf("signature = %s, want %s", got, v.signature)
+			}
+			// For secp256k1 this recovers the signer from the signature
+			if err := VerifyMessage(sm); err != nil {
+				t.Errorf("signature does not verify: %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyVectors(t *testing.T) {
+	for _, v := range keyVectors {
+		t.Run(v.name, func(t *testing.T) {
+			sigType := crypto.SigTypeSecp256k1
+			if v.keyType == KeyTypeBLS {
+				sigType = crypto.SigTypeBLS
+			}
+			data, _ := hex.DecodeString(v.signature)
+			sig := &crypto.Signature{Type: sigType, Data: data}
+			msg := vectorMessage(t, v.address)
+
+			if err := Verify(sig, msg.From, msg.Cid().Bytes()); err != nil {
+				t.Errorf("vector signature does not verify: %v", err)
+			}
+
+			msg.Nonce++
+			if err := Verify(sig, msg.From, msg.Cid().Bytes()); err == nil {
+				t.Error("signature verified for a different message")
+			}
+
+			other := keyVectors[0].address
+			if v.address == other {
+				other = keyVectors[1].address
+			}
+			if err := Verify(sig, mustAddress(t, other), vectorMessage(t, v.address).Cid().Bytes()); err == nil {
+				t.Error("signature verified for a different address")
+			}
+		})
+	}
+}
+
+func TestSignMessageWrongSender(t *testing.T) {
+	v := keyVectors[0]
+	msg := vectorMessage(t, keyVectors[1].address)
+	if _, err := SignMessage(vectorKey(t, v.keyType, v.key), msg); err == nil {
+		t.Error("signed a message sent from another address")
+	}
+
+	msg.From = mustAddress(t, "f01000")
+	if _, err := SignMessage(vectorKey(t, v.keyType, v.key), msg); err == nil {
+		t.Error("signed a message sent from an ID address")
+	}
+}
+
+func TestGenerateKeySignVerify(t *testing.T) {
+	for _, keyType := range []string{KeyTypeSecp256k1, KeyTypeBLS} {
+		t.Run(keyType, func(t *testing.T) {
+			ki, err := GenerateKey(keyType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			addr, err := ki.Address()
+			if err != nil {
+				t.Fatal(err)
+			}
+			data := []byte("thctl")
+			sig, err := ki.Sign(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := Verify(sig, addr, data); err != nil {
+				t.Errorf("signature does not verify: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseKeyInfo(t *testing.T) {
+	for _, v := range keyVectors {
+		t.Run(v.name, func(t *testing.T) {
+			ki := vectorKey(t, v.keyType, v.key)
+			exported, err := ki.Export()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Lotus exports the hex of {"Type":...,"PrivateKey":<base64>}
+			lotusExport := hex.EncodeToString([]byte(`{"Type":"` + v.keyType + `","PrivateKey":"` +
+				base64.StdEncoding.EncodeToString(ki.PrivateKey) + `"}`))
+			if exported != lotusExport {
+				t.Errorf("export = %s, want %s", exported, lotusExport)
+			}
+
+			for _, input := range []string{exported, exported + "\n"} {
+				parsed, err := ParseKeyInfo([]byte(input))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if parsed.Type != v.keyType || !bytes.Equal(parsed.PrivateKey, ki.PrivateKey) {
+					t.Errorf("parsed key = %+v, want %+v", parsed, ki)
+				}
+			}
+		})
+	}
+}
+
+func TestParseKeyInfoInvalid(t *testing.T) {
+	for name, input := range map[string]string{
+		"not json":     "zz",
+		"unknown type": `{"Type":"ed25519","PrivateKey":"AQID"}`,
+		"short key":    `{"Type":"secp256k1","PrivateKey":"AQID"}`,
+		// The scalar field order, which is not a valid BLS key
+		"bls overflow": `{"Type":"bls","PrivateKey":"` + base64.StdEncoding.EncodeToString(blsScalarToKey(blsOrder)) + `"}`,
+	} {
+		if _, err := ParseKeyInfo([]byte(input)); err == nil {
+			t.Errorf("%s: parsed without error", name)
+		}
+	}
+}
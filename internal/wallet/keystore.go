@@ -0,0 +1,229 @@
+package wallet
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/THCloudAI/thctl/internal/config"
+	"golang.org/x/crypto/scrypt"
+)
+
+// scrypt parameters used to derive the key encrypting each keystore entry
+const (
+	scryptN      = 1 << 17
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+)
+
+// ErrKeyNotFound is returned when the keystore holds no key for an address
+var ErrKeyNotFound = errors.New("key not found")
+
+// Keystore stores private keys encrypted with a passphrase, one file per address,
+// under the keystore directory of the thctl config directory
+type Keystore struct {
+	dir string
+}
+
+// Entry describes a key held by the keystore
+type Entry struct {
+	Address string `json:"address"`
+	Type    string `json:"type"`
+}
+
+// encryptedKey is the on-disk form of a keystore entry
+type encryptedKey struct {
+	Version int          `json:"version"`
+	Address string       `json:"address"`
+	Type    string       `json:"type"`
+	Crypto  cryptoParams `json:"crypto"`
+}
+
+// cryptoParams describes how a keystore entry is encrypted
+type cryptoParams struct {
+	KDF        string `json:"kdf"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       []byte `json:"salt"`
+	Cipher     string `json:"cipher"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// OpenKeystore returns the keystore kept in the thctl config directory
+func OpenKeystore() *Keystore {
+	return &Keystore{dir: filepath.Join(config.GetConfigDir(), "keystore")}
+}
+
+// Dir returns the directory backing the keystore
+func (ks *Keystore) Dir() string {
+	return ks.dir
+}
+
+// Put encrypts a key with passphrase and stores it, returning its address.
+// An existing key for the same address is never overwritten.
+func (ks *Keystore) Put(ki *KeyInfo, passphrase string) (address.Address, error) {
+	addr, err := ki.Address()
+	if err != nil {
+		return address.Undef, err
+	}
+	if passphrase == "" {
+		return address.Undef, fmt.Errorf("passphrase must not be empty")
+	}
+
+	if err := os.MkdirAll(ks.dir, 0700); err != nil {
+		return address.Undef, fmt.Errorf("failed to create keystore directory: %w", err)
+	}
+	if _, err := os.Stat(ks.path(addr)); err == nil {
+		return address.Undef, fmt.Errorf("key for %s already exists", addr)
+	}
+
+	plaintext, err := json.Marshal(ki)
+	if err != nil {
+		return address.Undef, err
+	}
+
+	params := cryptoParams{
+		KDF:    "scrypt",
+		N:      scryptN,
+		R:      scryptR,
+		P:      scryptP,
+		Salt:   make([]byte, 32),
+		Cipher: "aes-256-gcm",
+	}
+	if _, err := rand.Read(params.Salt); err != nil {
+		return address.Undef, err
+	}
+	aead, err := params.aead(passphrase)
+	if err != nil {
+		return address.Undef, err
+	}
+	params.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(params.Nonce); err != nil {
+		return address.Undef, err
+	}
+	// The address and type are authenticated so they can't be swapped between entries
+	params.Ciphertext = aead.Seal(nil, params.Nonce, plaintext, []byte(addr.String()+ki.Type))
+
+	data, err := json.MarshalIndent(encryptedKey{
+		Version: 1,
+		Address: addr.String(),
+		Type:    ki.Type,
+		Crypto:  params,
+	}, "", "  ")
+	if err != nil {
+		return address.Undef, err
+	}
+
+	// Write to a temporary file first so a failed write never leaves a partial key
+	tmp := ks.path(addr) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return address.Undef, fmt.Errorf("failed to write key: %w", err)
+	}
+	if err := os.Rename(tmp, ks.path(addr)); err != nil {
+		return address.Undef, fmt.Errorf("failed to write key: %w", err)
+	}
+	return addr, nil
+}
+
+// Get decrypts the key of an address with passphrase
+func (ks *Keystore) Get(addr address.Address, passphrase string) (*KeyInfo, error) {
+	data, err := os.ReadFile(ks.path(addr))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, addr)
+		}
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+
+	var stored encryptedKey
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("invalid keystore entry for %s: %w", addr, err)
+	}
+	if stored.Crypto.KDF != "scrypt" || stored.Crypto.Cipher != "aes-256-gcm" {
+		return nil, fmt.Errorf("unsupported keystore encryption for %s", addr)
+	}
+
+	aead, err := stored.Crypto.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, stored.Crypto.Nonce, stored.Crypto.Ciphertext, []byte(stored.Address+stored.Type))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt key for %s: wrong passphrase?", addr)
+	}
+
+	var ki KeyInfo
+	if err := json.Unmarshal(plaintext, &ki); err != nil {
+		return nil, fmt.Errorf("invalid key for %s: %w", addr, err)
+	}
+	if actual, err := ki.Address(); err != nil || actual != addr {
+		return nil, fmt.Errorf("keystore entry for %s holds a different key", addr)
+	}
+	return &ki, nil
+}
+
+// Has reports whether the keystore holds a key for addr
+func (ks *Keystore) Has(addr address.Address) bool {
+	_, err := os.Stat(ks.path(addr))
+	return err == nil
+}
+
+// List returns the keys held by the keystore sorted by address
+func (ks *Keystore) List() ([]Entry, error) {
+	files, err := os.ReadDir(ks.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Entry{}, nil
+		}
+		return nil, fmt.Errorf("failed to read keystore: %w", err)
+	}
+
+	entries := make([]Entry, 0, len(files))
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".key") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(ks.dir, f.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keystore: %w", err)
+		}
+		var stored encryptedKey
+		if err := json.Unmarshal(data, &stored); err != nil {
+			continue
+		}
+		entries = append(entries, Entry{Address: stored.Address, Type: stored.Type})
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Address < entries[j].Address })
+	return entries, nil
+}
+
+// path returns the file holding the key of addr. Keys are stored under their
+// mainnet address so f and t addresses refer to the same entry.
+func (ks *Keystore) path(addr address.Address) string {
+	return filepath.Join(ks.dir, "f"+addr.String()[1:]+".key")
+}
+
+// aead derives the entry's encryption key from passphrase
+func (p cryptoParams) aead(passphrase string) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), p.Salt, p.N, p.R, p.P, scryptKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,128 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+)
+
+func TestKeystoreRoundTrip(t *testing.T) {
+	ks := &Keystore{dir: t.TempDir()}
+
+	for _, v := range keyVectors {
+		t.Run(v.name, func(t *testing.T) {
+			ki := vectorKey(t, v.keyType, v.key)
+			addr, err := ks.Put(ki, "correct horse")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if addr != mustAddress(t, v.address) {
+				t.Errorf("stored under %s, want %s", addr, v.address)
+			}
+			if !ks.Has(addr) {
+				t.Error("keystore does not report the stored key")
+			}
+
+			// The private key is never stored in clear
+			data, err := os.ReadFile(ks.path(addr))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Contains(data, ki.PrivateKey) || strings.Contains(string(data), v.key) {
+				t.Error("keystore entry holds the private key in clear")
+			}
+
+			got, err := ks.Get(addr, "correct horse")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Type != ki.Type || !bytes.Equal(got.PrivateKey, ki.PrivateKey) {
+				t.Errorf("decrypted key = %+v, want %+v", got, ki)
+			}
+
+			if _, err := ks.Get(addr, "wrong horse"); err == nil {
+				t.Error("decrypted with a wrong passphrase")
+			}
+			if _, err := ks.Put(ki, "another passphrase"); err == nil {
+				t.Error("overwrote an existing key")
+			}
+		})
+	}
+
+	entries, err := ks.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(keyVectors) {
+		t.Errorf("listed %d keys, want %d", len(entries), len(keyVectors))
+	}
+}
+
+func TestKeystoreTestnetAddress(t *testing.T) {
+	ks := &Keystore{dir: t.TempDir()}
+	v := keyVectors[0]
+	if _, err := ks.Put(vectorKey(t, v.keyType, v.key), "passphrase"); err != nil {
+		t.Fatal(err)
+	}
+
+	// f and t addresses refer to the same entry
+	testnet, err := address.NewFromString("t" + v.address[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.Get(testnet, "passphrase"); err != nil {
+		t.Errorf("key not found by its testnet address: %v", err)
+	}
+}
+
+func TestKeystoreNotFound(t *testing.T) {
+	ks := &Keystore{dir: t.TempDir()}
+	if _, err := ks.Get(mustAddress(t, keyVectors[0].address), "passphrase"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("err = %v, want ErrKeyNotFound", err)
+	}
+}
+
+func TestKeystoreRejectsSwappedEntry(t *testing.T) {
+	ks := &Keystore{dir: t.TempDir()}
+	secpKey, blsKey := keyVectors[0], keyVectors[1]
+	secpAddr, err := ks.Put(vectorKey(t, secpKey.keyType, secpKey.key), "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	blsAddr, err := ks.Put(vectorKey(t, blsKey.keyType, blsKey.key), "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Relabel the BLS entry as the secp256k1 one
+	data, err := os.ReadFile(ks.path(blsAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry encryptedKey
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatal(err)
+	}
+	entry.Address = secpAddr.String()
+	data, _ = json.Marshal(entry)
+	if err := os.WriteFile(ks.path(secpAddr), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ks.Get(secpAddr, "passphrase"); err == nil {
+		t.Error("decrypted an entry moved to another address")
+	}
+}
+
+func TestKeystoreEmptyPassphrase(t *testing.T) {
+	ks := &Keystore{dir: t.TempDir()}
+	v := keyVectors[0]
+	if _, err := ks.Put(vectorKey(t, v.keyType, v.key), ""); err == nil {
+		t.Error("stored a key with an empty passphrase")
+	}
+}
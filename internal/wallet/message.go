@@ -0,0 +1,34 @@
+package wallet
+
+import (
+	"fmt"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// SignMessage signs the CID of a message with the key of its sender
+func SignMessage(ki *KeyInfo, msg *types.Message) (*types.SignedMessage, error) {
+	if msg.From.Protocol() == address.ID {
+		return nil, fmt.Errorf("message is sent from ID address %s; it must be resolved to its key address before signing", msg.From)
+	}
+
+	addr, err := ki.Address()
+	if err != nil {
+		return nil, err
+	}
+	if addr != msg.From {
+		return nil, fmt.Errorf("key %s cannot sign a message from %s", addr, msg.From)
+	}
+
+	sig, err := ki.Sign(msg.Cid().Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return &types.SignedMessage{Message: *msg, Signature: *sig}, nil
+}
+
+// VerifyMessage checks that a signed message was signed by its sender
+func VerifyMessage(sm *types.SignedMessage) error {
+	return Verify(&sm.Signature, sm.Message.From, sm.Message.Cid().Bytes())
+}
@@ -4,14 +4,17 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-var globalLogger *zap.SugaredLogger
+// Logger is the logger handed out to modules
+type Logger = zap.SugaredLogger
+
+// globalLogger discards everything until Init is called
+var globalLogger = zap.NewNop().Sugar()
 
 // Config represents logger configuration
 type Config struct {
@@ -98,7 +101,7 @@ func Init(cfg *Config) error {
 }
 
 // WithModule returns a logger with module field
-func WithModule(module string) *zap.SugaredLogger {
+func WithModule(module string) *Logger {
 	return globalLogger.With("module", module)
 }
 
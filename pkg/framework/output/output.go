@@ -41,6 +41,16 @@ func (f Format) String() string {
 	return string(f)
 }
 
+// JSON prints data to stdout as indented JSON
+func JSON(data interface{}) error {
+	return NewPrinter(FormatJSON).Print(data)
+}
+
+// YAML prints data to stdout as YAML
+func YAML(data interface{}) error {
+	return NewPrinter(FormatYAML).Print(data)
+}
+
 // Printer handles output formatting
 type Printer struct {
 	format Format